@@ -0,0 +1,478 @@
+// Command portview is a TUI for discovering and managing localhost dev
+// servers.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+
+	"github.com/jeramiahgcoffey/portview/internal/config"
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+	"github.com/jeramiahgcoffey/portview/internal/tui"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "portview:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	configFlag := flag.String("config", "", "path to config file (default $XDG_CONFIG_HOME/portview/config.yaml)")
+	summary := flag.Bool("summary", false, "print one line per listening port and exit")
+	exportLabels := flag.Bool("export-labels", false, "print labelled ports as \"name localhost:port\" lines and exit")
+	count := flag.Bool("count", false, "print the number of listening ports and exit")
+	audit := flag.Bool("audit", false, "list listening processes run from suspicious_paths such as /tmp and exit")
+	jsonOut := flag.Bool("json", false, "print the listening ports as JSON and exit")
+	jsonFieldsFlag := flag.String("json-fields", "", "like -json, but only these comma-separated `FIELDS` (e.g. port,pid,process,label)")
+	saveSnapshot := flag.String("save-snapshot", "", "save the current scan as a named snapshot and exit")
+	snapshot := flag.String("snapshot", "", "view a saved snapshot instead of scanning")
+	diff := flag.Bool("diff", false, "print what changed between the snapshots `A B` (files or saved names) and exit")
+	title := flag.String("title", "", "header `TITLE`, in place of the config's title or \"portview\"")
+	highContrast := flag.Bool("high-contrast", false, "mark health with text and avoid colour-only cues")
+	logFile := flag.String("log", "", "append diagnostic logs to `FILE`")
+	portsFlag := flag.String("ports", "", "scan only these comma-separated `PORTS`, ignoring port_range")
+	unix := flag.Bool("unix", false, "also list listening Unix domain sockets")
+	filter := flag.String("filter", "", "start with the list filtered by `TEXT`, as if typed after /")
+	filterMode := flag.Bool("filter-mode", false, "start with the filter input open")
+	favorites := flag.Bool("favorites", false, "start showing only the favorite ports (toggle with F)")
+	monitor := flag.String("monitor", "", "print health changes of the comma-separated `PORTS` each interval until interrupted, without the TUI")
+	exitOnDown := flag.Bool("exit-on-down", false, "with -monitor, exit with status 1 as soon as a port is down")
+	var settings settingFlags
+	flag.DurationVar(&settings.interval, "interval", 0, "refresh interval, overriding refresh_interval (0 disables auto-refresh)")
+	flag.StringVar(&settings.portRange, "port-range", "", "show only ports in `MIN-MAX`, overriding port_range")
+	saveFlags := flag.Bool("save-flags", false, "write -interval and -port-range to the config file for later runs")
+	flag.Parse()
+	settings.set = make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { settings.set[f.Name] = true })
+
+	configPath := *configFlag
+	if configPath == "" {
+		p, err := config.DefaultPath()
+		if err != nil {
+			return err
+		}
+		configPath = p
+	}
+
+	var jsonFields []string
+	if *jsonFieldsFlag != "" {
+		f, err := tui.ParseJSONFields(*jsonFieldsFlag)
+		if err != nil {
+			return err
+		}
+		jsonFields = f
+		*jsonOut = true
+	}
+
+	headless := *summary || *count || *audit || *jsonOut || *exportLabels || *saveSnapshot != "" || *diff || *monitor != ""
+	if headless || noColor() {
+		// Headless output is for pipes and scripts; never style it.
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+	if *diff {
+		if flag.NArg() != 2 {
+			return errors.New("-diff needs two snapshots, e.g. -diff before.json after.json")
+		}
+		return printDiff(configPath, flag.Arg(0), flag.Arg(1))
+	}
+	var notice string
+	if !headless {
+		n, err := firstRun(configPath)
+		if err != nil {
+			return err
+		}
+		notice = n
+	}
+
+	cfg, fileCfg, err := loadConfig(configPath, settings, *saveFlags)
+	if err != nil {
+		return err
+	}
+
+	if *exportLabels {
+		fmt.Print(tui.FormatLabels(cfg.Labels))
+		return nil
+	}
+
+	ports, err := parsePortList(*portsFlag)
+	if err != nil {
+		return fmt.Errorf("-ports: %w", err)
+	}
+	if *monitor != "" {
+		return runMonitor(*monitor, *exitOnDown, cfg)
+	}
+	var s scanner.Scanner = scanner.New(scannerOptions(cfg, ports, *unix))
+	if *snapshot != "" {
+		servers, err := loadSnapshot(configPath, *snapshot)
+		if err != nil {
+			return err
+		}
+		s = &scanner.MockScanner{Servers: servers}
+	}
+	if *summary {
+		return printSummary(s, cfg)
+	}
+	if *count {
+		return printCount(s, cfg)
+	}
+	if *audit {
+		return printAudit(s, cfg)
+	}
+	if *jsonOut {
+		return printJSON(s, cfg, jsonFields)
+	}
+	if *saveSnapshot != "" {
+		return writeSnapshot(s, configPath, *saveSnapshot)
+	}
+
+	if conflicts := cfg.Conflicts(); len(conflicts) > 0 {
+		notice = joinNotice(notice, "config: "+strings.Join(conflicts, "; "))
+	}
+	opts := tui.Options{ConfigPath: configPath, Notice: notice, Snapshot: *snapshot, HighContrast: *highContrast, Title: *title, Filter: *filter, FilterMode: *filterMode, Favorites: *favorites, AsRoot: os.Geteuid() == 0}
+	if *logFile != "" {
+		f, err := os.OpenFile(*logFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("opening log: %w", err)
+		}
+		defer f.Close()
+		opts.Logger = slog.New(slog.NewJSONHandler(f, nil))
+	}
+	if !*saveFlags {
+		opts.BeforeSave = settings.restore(cfg, fileCfg)
+	}
+	if *snapshot == "" {
+		opts.NewScanner = func(cfg config.Config) scanner.Scanner {
+			return scanner.New(scannerOptions(cfg, ports, *unix))
+		}
+		if len(ports) == 0 {
+			all := scannerOptions(cfg, nil, false)
+			all.MinPort, all.MaxPort = 1, 65535
+			opts.Unfiltered = scanner.New(all)
+		}
+	}
+	return runProgram(tui.New(s, cfg, opts))
+}
+
+// scannerOptions are the scanner settings cfg asks for. ports, if set,
+// replaces the port range, and unix adds Unix domain sockets.
+func scannerOptions(cfg config.Config, ports []int, unix bool) scanner.Options {
+	return scanner.Options{
+		MinPort:       cfg.PortRange.Min,
+		MaxPort:       cfg.PortRange.Max,
+		Ports:         ports,
+		Unix:          unix,
+		UDP:           cfg.IncludeUDP,
+		HealthTimeout: cfg.HealthTimeout,
+		LsofPath:      cfg.LsofPath,
+		LsofArgs:      cfg.LsofArgs,
+	}
+}
+
+// settingFlags are the flags that override config settings for one run, or
+// for good with -save-flags.
+type settingFlags struct {
+	interval  time.Duration
+	portRange string
+	set       map[string]bool // names of the flags given on the command line
+}
+
+// apply returns cfg with the settings given on the command line in place.
+func (f settingFlags) apply(cfg config.Config) (config.Config, error) {
+	if f.set["interval"] {
+		cfg.RefreshInterval = f.interval
+	}
+	if f.set["port-range"] {
+		r, err := config.ParsePortRange(f.portRange)
+		if err != nil {
+			return config.Config{}, fmt.Errorf("-port-range: %w", err)
+		}
+		cfg.PortRange = r
+	}
+	if err := cfg.Validate(); err != nil {
+		return config.Config{}, err
+	}
+	return cfg, nil
+}
+
+// restore returns an Options.BeforeSave that puts the file's values back for
+// the settings f overrode in cfg, so saving a label edit does not make them
+// permanent. A setting changed again in the TUI, say by widening the range,
+// is kept.
+func (f settingFlags) restore(cfg, file config.Config) func(config.Config) config.Config {
+	return func(c config.Config) config.Config {
+		if f.set["interval"] && c.RefreshInterval == cfg.RefreshInterval {
+			c.RefreshInterval = file.RefreshInterval
+		}
+		if f.set["port-range"] && c.PortRange == cfg.PortRange {
+			c.PortRange = file.PortRange
+		}
+		return c
+	}
+}
+
+// loadConfig loads the config at path and applies f, returning the result
+// along with the config as the file has it. With save set, the result is
+// written back so the flags stick.
+func loadConfig(path string, f settingFlags, save bool) (cfg, file config.Config, err error) {
+	file, err = config.Load(path)
+	if err != nil {
+		return config.Config{}, config.Config{}, err
+	}
+	if cfg, err = f.apply(file.Clone()); err != nil {
+		return config.Config{}, config.Config{}, err
+	}
+	if save {
+		if !f.set["interval"] && !f.set["port-range"] {
+			return config.Config{}, config.Config{}, errors.New("-save-flags needs -interval or -port-range")
+		}
+		if err := config.Save(path, cfg); err != nil {
+			return config.Config{}, config.Config{}, err
+		}
+	}
+	return cfg, file, nil
+}
+
+// runProgram runs the TUI, then prints the port picked with P, if any, so
+// that $(portview) can feed it to another command. The TUI draws on stderr
+// when stdout is not a terminal, leaving stdout for the port alone.
+//
+// Bubble Tea restores the terminal and prints the stack itself when the
+// model panics; this turns that, or a panic escaping Run, into an error for
+// main to report.
+func runProgram(m tui.Model) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("crashed: %v", r)
+		}
+	}()
+	opts := []tea.ProgramOption{tea.WithAltScreen(), tea.WithReportFocus()}
+	if !isTerminal(os.Stdout) {
+		opts = append(opts, tea.WithOutput(os.Stderr))
+	}
+	final, err := tea.NewProgram(m, opts...).Run()
+	if errors.Is(err, tea.ErrProgramPanic) {
+		return errors.New("crashed; the stack trace above shows where")
+	}
+	if err != nil {
+		return err
+	}
+	if fm, ok := final.(tui.Model); ok {
+		if s, ok := fm.Chosen(); ok {
+			if s.IsSocket() {
+				fmt.Println(s.SocketPath)
+			} else {
+				fmt.Println(s.Port)
+			}
+		}
+	}
+	return nil
+}
+
+// noColor reports whether the user asked for uncoloured output through the
+// NO_COLOR convention (https://no-color.org): any non-empty value counts.
+func noColor() bool {
+	return os.Getenv("NO_COLOR") != ""
+}
+
+// isTerminal reports whether f is a character device such as a tty.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// firstRun writes a commented default config if none exists yet and returns
+// a notice telling the user where it went. Failing to write it is not fatal;
+// portview works without a config file.
+func firstRun(path string) (string, error) {
+	_, err := os.Stat(path)
+	if err == nil {
+		return "", nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("checking config: %w", err)
+	}
+	if err := config.WriteDefaultCommented(path); err != nil {
+		return "", nil
+	}
+	return "created default config at " + path, nil
+}
+
+// parsePortList parses a comma-separated port list such as the --ports value,
+// e.g. "8080,3000,5432". An empty value means no allow-list.
+func parsePortList(s string) ([]int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var ports []int
+	for _, f := range strings.Split(s, ",") {
+		port, err := strconv.Atoi(strings.TrimSpace(f))
+		if err != nil || port < 1 || port > 65535 {
+			return nil, fmt.Errorf("invalid port %q", f)
+		}
+		ports = append(ports, port)
+	}
+	return ports, nil
+}
+
+// joinNotice appends b to the startup notice a.
+func joinNotice(a, b string) string {
+	if a == "" {
+		return b
+	}
+	return a + " · " + b
+}
+
+// headlessTimeout bounds the single scan made by non-interactive flags.
+const headlessTimeout = 10 * time.Second
+
+// scanErr wraps a failed scan's error. A degraded scan still produced a
+// port list, so it is only warned about on stderr.
+func scanErr(err error) error {
+	if errors.Is(err, scanner.ErrDegraded) {
+		fmt.Fprintln(os.Stderr, "portview: warning:", err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("scan failed: %w", err)
+	}
+	return nil
+}
+
+func printSummary(s scanner.Scanner, cfg config.Config) error {
+	ctx, cancel := context.WithTimeout(context.Background(), headlessTimeout)
+	defer cancel()
+	servers, err := tui.ScanOnce(ctx, s, cfg)
+	if err := scanErr(err); err != nil {
+		return err
+	}
+	fmt.Print(tui.FormatSummary(servers))
+	return nil
+}
+
+// printAudit lists the servers running from suspicious_paths. Hidden ports
+// are deliberately included: an audit should not be silenced by the view.
+func printAudit(s scanner.Scanner, cfg config.Config) error {
+	ctx, cancel := context.WithTimeout(context.Background(), headlessTimeout)
+	defer cancel()
+	cfg.Hidden = nil
+	servers, err := tui.ScanOnce(ctx, s, cfg)
+	if err := scanErr(err); err != nil {
+		return err
+	}
+	fmt.Print(tui.FormatAudit(tui.Suspicious(servers, cfg)))
+	return nil
+}
+
+func printJSON(s scanner.Scanner, cfg config.Config, fields []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), headlessTimeout)
+	defer cancel()
+	servers, err := tui.ScanOnce(ctx, s, cfg)
+	if err := scanErr(err); err != nil {
+		return err
+	}
+	out, err := tui.FormatJSON(servers, fields)
+	if err != nil {
+		return err
+	}
+	fmt.Print(out)
+	return nil
+}
+
+func printCount(s scanner.Scanner, cfg config.Config) error {
+	ctx, cancel := context.WithTimeout(context.Background(), headlessTimeout)
+	defer cancel()
+	n, err := tui.CountListening(ctx, s, cfg)
+	if err := scanErr(err); err != nil {
+		return err
+	}
+	fmt.Println(n)
+	return nil
+}
+
+// runMonitor watches the ports in list, printing their health changes until
+// interrupted.
+func runMonitor(list string, exitOnDown bool, cfg config.Config) error {
+	ports, err := parsePortList(list)
+	if err != nil {
+		return fmt.Errorf("-monitor: %w", err)
+	}
+	if len(ports) == 0 {
+		return errors.New("-monitor needs at least one port, e.g. -monitor 8080,3000")
+	}
+	if cfg.RefreshInterval <= 0 {
+		return errors.New("-monitor needs a refresh interval; set -interval or refresh_interval")
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	opts := scannerOptions(cfg, ports, false)
+	opts.UDP = false // only TCP listeners are health-checked
+	s := scanner.New(opts)
+	return tui.Monitor(ctx, s, os.Stdout, tui.MonitorOptions{Ports: ports, Interval: cfg.RefreshInterval, ExitOnDown: exitOnDown})
+}
+
+func loadSnapshot(configPath, name string) ([]scanner.Server, error) {
+	path, err := config.SnapshotPath(configPath, name)
+	if err != nil {
+		return nil, err
+	}
+	return scanner.LoadSnapshot(path)
+}
+
+// printDiff prints what changed from snapshot a to snapshot b. Each is a
+// file when it looks like a path, such as before.json, and otherwise the name
+// of a snapshot saved with -save-snapshot.
+func printDiff(configPath, a, b string) error {
+	load := func(arg string) ([]scanner.Server, error) {
+		if strings.ContainsRune(arg, filepath.Separator) || filepath.Ext(arg) == ".json" {
+			return scanner.LoadSnapshot(arg)
+		}
+		return loadSnapshot(configPath, arg)
+	}
+	before, err := load(a)
+	if err != nil {
+		return err
+	}
+	after, err := load(b)
+	if err != nil {
+		return err
+	}
+	fmt.Print(tui.FormatDiff(scanner.DiffServers(before, after)))
+	return nil
+}
+
+// writeSnapshot saves one raw scan under name. Labels and hidden ports are
+// applied when the snapshot is viewed, like any other scan.
+func writeSnapshot(s scanner.Scanner, configPath, name string) error {
+	path, err := config.SnapshotPath(configPath, name)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), headlessTimeout)
+	defer cancel()
+	servers, err := s.Scan(ctx)
+	if err := scanErr(err); err != nil {
+		return err
+	}
+	if err := scanner.SaveSnapshot(path, servers); err != nil {
+		return err
+	}
+	fmt.Println("saved snapshot to", path)
+	return nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jeramiahgcoffey/portview/internal/config"
+)
+
+func TestLoadConfigSaveFlags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	base := config.Default()
+	base.SetLabel(3000, "web")
+	if err := config.Save(path, base); err != nil {
+		t.Fatal(err)
+	}
+	flags := settingFlags{
+		interval:  10 * time.Second,
+		portRange: "3000-9000",
+		set:       map[string]bool{"interval": true, "port-range": true},
+	}
+
+	cfg, file, err := loadConfig(path, flags, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.RefreshInterval != 10*time.Second || cfg.PortRange != (config.PortRange{Min: 3000, Max: 9000}) {
+		t.Errorf("overridden config = %+v", cfg)
+	}
+	if file.RefreshInterval != base.RefreshInterval {
+		t.Errorf("file config = %+v, want it untouched by the flags", file)
+	}
+	if onDisk, _ := config.Load(path); onDisk.RefreshInterval != base.RefreshInterval {
+		t.Error("without -save-flags the file must not change")
+	}
+	restored := flags.restore(cfg, file)(cfg)
+	if restored.RefreshInterval != base.RefreshInterval || restored.PortRange != base.PortRange {
+		t.Errorf("restore() = %+v, want the file's interval and range back", restored)
+	}
+	widened := cfg
+	widened.PortRange.Max = 10000
+	if got := flags.restore(cfg, file)(widened); got.PortRange.Max != 10000 {
+		t.Error("restore() should keep a range changed again in the TUI")
+	}
+
+	if _, _, err := loadConfig(path, flags, true); err != nil {
+		t.Fatal(err)
+	}
+	onDisk, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if onDisk.RefreshInterval != 10*time.Second || onDisk.PortRange.Min != 3000 || onDisk.PortRange.Max != 9000 {
+		t.Errorf("saved config = %+v, want the flag values", onDisk)
+	}
+	if onDisk.Labels[3000] != "web" {
+		t.Error("saving flags should keep the rest of the config")
+	}
+}
+
+func TestLoadConfigRejectsBadFlags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	tests := []struct {
+		name  string
+		flags settingFlags
+		save  bool
+	}{
+		{"bad range", settingFlags{portRange: "9000-80", set: map[string]bool{"port-range": true}}, false},
+		{"negative interval", settingFlags{interval: -time.Second, set: map[string]bool{"interval": true}}, false},
+		{"nothing to save", settingFlags{set: map[string]bool{}}, true},
+	}
+	for _, tt := range tests {
+		if _, _, err := loadConfig(path, tt.flags, tt.save); err == nil {
+			t.Errorf("%s: loadConfig() error = nil", tt.name)
+		}
+	}
+}
+
+func TestNoColor(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		want  bool
+	}{
+		{"", false},
+		{"1", true},
+		{"0", true}, // any non-empty value counts
+	} {
+		t.Setenv("NO_COLOR", tc.value)
+		if got := noColor(); got != tc.want {
+			t.Errorf("NO_COLOR=%q: noColor() = %v, want %v", tc.value, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,753 @@
+// Package config loads and saves portview's user configuration, stored as
+// YAML under the XDG config directory. Files ending in .json are read and
+// written as JSON instead.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultRefreshInterval = 3 * time.Second
+	defaultMinPort         = 1024
+	defaultMaxPort         = 65535
+)
+
+// Config holds user preferences and per-port metadata.
+type Config struct {
+	RefreshInterval time.Duration  `yaml:"refresh_interval" json:"refresh_interval"`
+	PortRange       PortRange      `yaml:"port_range" json:"port_range"`
+	Labels          map[int]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	Hidden          []int          `yaml:"hidden,omitempty" json:"hidden,omitempty"`
+	// Favorites are the ports marked with "m", which the favorites-only
+	// view narrows the list to.
+	Favorites []int `yaml:"favorites,omitempty" json:"favorites,omitempty"`
+	// StartFavorites opens the favorites-only view at startup, as
+	// --favorites does.
+	StartFavorites bool `yaml:"start_favorites,omitempty" json:"start_favorites,omitempty"`
+	// Title replaces "portview" in the header, e.g. to name the project
+	// being watched.
+	Title string `yaml:"title,omitempty" json:"title,omitempty"`
+	// HealthTimeout bounds each health-check connection made during a scan.
+	// Zero means the scanner's default of one second.
+	HealthTimeout time.Duration `yaml:"health_timeout,omitempty" json:"health_timeout,omitempty"`
+	// IdleQuit exits portview after this long without a key press. Zero
+	// disables it.
+	IdleQuit time.Duration `yaml:"idle_quit,omitempty" json:"idle_quit,omitempty"`
+	// BackgroundInterval replaces RefreshInterval while the terminal is
+	// unfocused. Zero keeps the normal interval.
+	BackgroundInterval time.Duration `yaml:"background_interval,omitempty" json:"background_interval,omitempty"`
+	// Notify sends a desktop notification when a server goes down or stops
+	// listening.
+	Notify bool `yaml:"notify,omitempty" json:"notify,omitempty"`
+	// NoOpenRanges lists ports, as "5432" or "5000-6000", that "o" refuses
+	// to open in a browser.
+	NoOpenRanges []string `yaml:"no_open_ranges,omitempty" json:"no_open_ranges,omitempty"`
+	// ServiceNames shows the well-known service name next to each port,
+	// e.g. "80 (http)". Toggled from the TUI with "N".
+	ServiceNames bool `yaml:"service_names,omitempty" json:"service_names,omitempty"`
+	// ShowCommand puts each server's command line in the process column,
+	// in place of the short process name. Toggled from the TUI with "v".
+	ShowCommand bool `yaml:"show_command,omitempty" json:"show_command,omitempty"`
+	// CollapseVersions lists versioned binaries under their plain name,
+	// e.g. "python3.11" as "python". Filtering still matches the full name.
+	CollapseVersions bool `yaml:"collapse_versions,omitempty" json:"collapse_versions,omitempty"`
+	// DedupeByPort merges rows with the same port and PID, such as a
+	// server bound to both 127.0.0.1 and [::], into one.
+	DedupeByPort bool `yaml:"dedupe_by_port,omitempty" json:"dedupe_by_port,omitempty"`
+	// AutoLabels label ports that have no explicit label, by range. The
+	// first matching rule wins.
+	AutoLabels []AutoLabel `yaml:"auto_labels,omitempty" json:"auto_labels,omitempty"`
+	// ViewMode is the list layout: "flat", "pid" or "label". Unknown values
+	// fall back to flat.
+	ViewMode string `yaml:"view_mode,omitempty" json:"view_mode,omitempty"`
+	// HiddenMode is what happens to hidden ports: "remove" (the default)
+	// leaves them out of the list, "dim" keeps them at the bottom, dimmed.
+	HiddenMode string `yaml:"hidden_mode,omitempty" json:"hidden_mode,omitempty"`
+	// CursorStyle marks the selected row: "arrow" (the default) puts ">" in
+	// the gutter, "block" highlights the whole row in reverse video, and
+	// any other string of up to three characters, such as "▶", is used in
+	// place of ">".
+	CursorStyle string `yaml:"cursor_style,omitempty" json:"cursor_style,omitempty"`
+	// TimeFormat is how the status bar says when the list was refreshed:
+	// "relative" (the default, "refreshed 3s ago"), "clock" ("refreshed at
+	// 12:03:04") or "both".
+	TimeFormat string `yaml:"time_format,omitempty" json:"time_format,omitempty"`
+	// DefaultSort orders each scan: "port" (the default), "pid" or
+	// "process".
+	DefaultSort string `yaml:"default_sort,omitempty" json:"default_sort,omitempty"`
+	// AllowRestart enables ctrl+r, which kills a server and re-runs its
+	// command line. Off by default since it starts processes.
+	AllowRestart bool `yaml:"allow_restart,omitempty" json:"allow_restart,omitempty"`
+	// ConfirmRootKills asks a second time before a kill when portview runs
+	// as root, where it can reach every user's processes.
+	ConfirmRootKills bool `yaml:"confirm_root_kills,omitempty" json:"confirm_root_kills,omitempty"`
+	// QuickConfirm asks about kills and restarts with a one-line prompt in
+	// the status bar rather than a centered box over the list.
+	QuickConfirm bool `yaml:"quick_confirm,omitempty" json:"quick_confirm,omitempty"`
+	// ShowExcluded adds how many listening ports the port range leaves out
+	// to the status bar, as "+7 outside range".
+	ShowExcluded bool `yaml:"show_excluded,omitempty" json:"show_excluded,omitempty"`
+	// IncludeUDP adds bound UDP sockets to the list, shown with a PROTO
+	// column. Only the Linux scanner reads them.
+	IncludeUDP bool `yaml:"include_udp,omitempty" json:"include_udp,omitempty"`
+	// ExposedBanner lists the ports bound beyond loopback in a box at
+	// startup, until any key is pressed.
+	ExposedBanner bool `yaml:"exposed_banner,omitempty" json:"exposed_banner,omitempty"`
+	// HighContrast marks health with text ("[OK]", "[DOWN]") and uses bold
+	// and reverse video instead of relying on colour.
+	HighContrast bool `yaml:"high_contrast,omitempty" json:"high_contrast,omitempty"`
+	// DisabledActions turns off the named actions, from Actions. Their
+	// keys are ignored and left out of the help overlay.
+	DisabledActions []string `yaml:"disabled_actions,omitempty" json:"disabled_actions,omitempty"`
+	// SuspiciousPaths are directories that --audit flags executables
+	// under. Unset means DefaultSuspiciousPaths.
+	SuspiciousPaths []string `yaml:"suspicious_paths,omitempty" json:"suspicious_paths,omitempty"`
+	// LsofPath and LsofArgs override the lsof binary and the arguments of
+	// its listening-socket scan on macOS. Unset means "lsof" with
+	// -iTCP -sTCP:LISTEN -nP.
+	LsofPath string   `yaml:"lsof_path,omitempty" json:"lsof_path,omitempty"`
+	LsofArgs []string `yaml:"lsof_args,omitempty" json:"lsof_args,omitempty"`
+	// LogPaths maps ports to the log file "L" tails for them.
+	LogPaths map[int]string `yaml:"log_paths,omitempty" json:"log_paths,omitempty"`
+	// Hooks maps events, from HookEvents, to shell commands run when they
+	// fire. None are set by default; each one runs with portview's
+	// privileges.
+	Hooks map[string]string `yaml:"hooks,omitempty" json:"hooks,omitempty"`
+}
+
+// SortKeys are the accepted values of Config.DefaultSort.
+var SortKeys = []string{"port", "pid", "process"}
+
+// HiddenModes are the accepted values of Config.HiddenMode.
+var HiddenModes = []string{"remove", "dim"}
+
+// CursorStyles are the named values of Config.CursorStyle; anything else is
+// a custom marker.
+var CursorStyles = []string{"arrow", "block"}
+
+// TimeFormats are the accepted values of Config.TimeFormat.
+var TimeFormats = []string{"relative", "clock", "both"}
+
+// maxCursorMarker is how many characters a custom cursor_style may have.
+const maxCursorMarker = 3
+
+// Actions are the accepted values of Config.DisabledActions.
+var Actions = []string{"kill", "restart", "label", "hide", "open", "snapshot", "edit_config"}
+
+// HookEvents are the accepted keys of Config.Hooks: a kill was confirmed by
+// a later scan, or a server's health check went down or came back up.
+var HookEvents = []string{"on_kill", "on_down", "on_up"}
+
+// DefaultSuspiciousPaths are the world-writable scratch directories that
+// legitimate servers rarely run from but dropped binaries often do.
+var DefaultSuspiciousPaths = []string{"/tmp", "/var/tmp", "/dev/shm"}
+
+// AutoLabel labels every port in Ports, a single port or range in the same
+// form as no_open_ranges.
+type AutoLabel struct {
+	Ports string `yaml:"ports" json:"ports"`
+	Label string `yaml:"label" json:"label"`
+}
+
+// PortRange bounds which ports are scanned, inclusive on both ends.
+type PortRange struct {
+	Min int `yaml:"min" json:"min"`
+	Max int `yaml:"max" json:"max"`
+}
+
+// Default returns the configuration used when no file exists.
+func Default() Config {
+	return Config{
+		RefreshInterval: defaultRefreshInterval,
+		PortRange:       PortRange{Min: defaultMinPort, Max: defaultMaxPort},
+		Labels:          map[int]string{},
+	}
+}
+
+// DefaultPath returns the config file location, honoring $XDG_CONFIG_HOME and
+// falling back to ~/.config.
+func DefaultPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("locating home directory: %w", err)
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "portview", "config.yaml"), nil
+}
+
+// SnapshotPath returns where the snapshot called name is stored: a
+// snapshots directory beside the config file at configPath.
+func SnapshotPath(configPath, name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid snapshot name %q", name)
+	}
+	return filepath.Join(filepath.Dir(configPath), "snapshots", name+".json"), nil
+}
+
+// Load reads the config at path. Fields missing from the file keep their
+// defaults, and a missing file yields Default() with no error.
+func Load(path string) (Config, error) {
+	cfg := Default()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("reading config: %w", err)
+	}
+	if err := decode(path, data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if cfg.Labels == nil {
+		cfg.Labels = map[int]string{}
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Save writes cfg to path, creating parent directories as needed. The file is
+// written to a temporary sibling and renamed so readers never see a partial
+// write.
+func Save(path string, cfg Config) error {
+	data, err := encode(path, cfg)
+	if err != nil {
+		return fmt.Errorf("encoding config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config-*"+filepath.Ext(path))
+	if err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing config: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	return nil
+}
+
+// isJSON reports whether path should be read and written as JSON.
+func isJSON(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".json")
+}
+
+func decode(path string, data []byte, cfg *Config) error {
+	if isJSON(path) {
+		return json.Unmarshal(data, cfg)
+	}
+	return yaml.Unmarshal(data, cfg)
+}
+
+func encode(path string, cfg Config) ([]byte, error) {
+	if isJSON(path) {
+		data, err := json.MarshalIndent(cfg, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	}
+	return yaml.Marshal(cfg)
+}
+
+// commentedTemplate is the annotated config written on first run. It must
+// stay loadable and decode to Default().
+const commentedTemplate = `# portview configuration
+#
+# Every field is optional: delete or comment out a line to fall back to the
+# built-in default.
+
+# How often to rescan for listening ports, as a Go duration (500ms, 3s, 1m).
+# 0 turns auto-refresh off; scans then only happen when you press "r".
+refresh_interval: %s
+
+# Only ports within this inclusive range are shown. Ports below 1024 are
+# skipped by default to keep system services out of the list.
+port_range:
+  min: %d
+  max: %d
+
+# Header title, e.g. to tell several sessions apart. Also available as
+# --title.
+# title: portview — myproject (staging)
+
+# Friendly names shown next to a port. Set them from the TUI with "l".
+# labels:
+#   3000: frontend
+#   8080: api
+
+# Log files to follow with "L" (tail -F) for the selected port.
+# log_paths:
+#   3000: /home/me/src/web/dev.log
+
+# Ports that are never shown. Toggle them from the TUI with "h".
+# hidden:
+#   - 5432
+
+# Favorite ports, marked from the TUI with "m". "F" shows only these.
+# favorites:
+#   - 3000
+#   - 8080
+
+# Start in the favorites-only view, as --favorites does. "F" switches to
+# the full list.
+# start_favorites: true
+
+# What hiding does: remove (leave hidden ports out of the list) or dim (keep
+# them at the bottom of the list, dimmed).
+# hidden_mode: remove
+
+# How the selected row is marked: arrow (">" in the gutter), block (the
+# whole row in reverse video), or your own marker of up to three
+# characters, such as "▶" or "»".
+# cursor_style: arrow
+
+# How the status bar shows the last refresh: relative ("refreshed 3s ago"),
+# clock ("refreshed at 12:03:04") or both ("refreshed 12:03:04, 3s ago").
+# time_format: relative
+
+# How long a health check waits for a server to accept a connection before
+# marking it down.
+# health_timeout: 1s
+
+# Quit after this long without a key press. 0 or unset keeps running.
+# idle_quit: 30m
+
+# Refresh interval while the terminal window is not focused, to save CPU.
+# Needs a terminal that reports focus. 0 or unset keeps refresh_interval.
+# background_interval: 30s
+
+# List layout, toggled from the TUI with "g": flat, pid (grouped by owning
+# process) or label.
+# view_mode: flat
+
+# Order of the list after each scan: port, pid or process.
+# default_sort: port
+
+# Ports that are not web servers, so "o" will not open them in a browser.
+# no_open_ranges:
+#   - "5432"
+#   - 5000-6000
+
+# Name well-known ports in the list, e.g. "5432 (postgresql)". Toggled from
+# the TUI with "N".
+# service_names: true
+
+# Show the full command line where the process name would go, in one wide
+# column. Toggled from the TUI with "v".
+# show_command: true
+
+# List versioned binaries under their plain name, e.g. python3.11 as python.
+# The filter still matches the full name.
+# collapse_versions: true
+
+# Show a process listening on several addresses for one port (say
+# 127.0.0.1 and [::]) as a single row.
+# dedupe_by_port: true
+
+# Label ports by convention when they have no label of their own.
+# auto_labels:
+#   - ports: 3000-3099
+#     label: frontend
+#   - ports: 8000-8099
+#     label: api
+
+# Turn off actions on shared terminals: kill, restart, label, hide, open,
+# snapshot, edit_config.
+# disabled_actions: [kill, restart]
+
+# Shell commands to run, detached, when an event fires: on_kill once a
+# scan confirms a kill, on_down and on_up when a server's health changes.
+# {port}, {pid} and {process} are replaced with the server's details; the
+# process name is passed as $PORTVIEW_PROCESS rather than pasted into the
+# command, so a hostile name cannot run anything. PORTVIEW_PORT and
+# PORTVIEW_PID are set too. Hooks run as you (or as root under sudo), so
+# only set commands you trust.
+# hooks:
+#   on_down: notify-send portview "{process} on :{port} is down"
+#   on_kill: logger "portview killed {pid} on {port}"
+
+# Directories that --audit flags listening executables under. Unset means
+# /tmp, /var/tmp and /dev/shm.
+# suspicious_paths: [/tmp, /var/tmp, /dev/shm, /home/shared]
+
+# macOS only: the lsof to run and the arguments of its scan, for machines
+# where lsof is elsewhere or needs other flags. The output must keep lsof's
+# default columns with a NAME like "127.0.0.1:8080 (LISTEN)", so keep -nP
+# and do not add -F or -t. Unset means lsof -iTCP -sTCP:LISTEN -nP.
+# lsof_path: /usr/sbin/lsof
+# lsof_args: [-iTCP, -sTCP:LISTEN, -nP, -w]
+
+# Show health as [OK]/[DOWN] text and mark the selection with reverse video
+# instead of relying on colour. Also available as --high-contrast.
+# high_contrast: true
+
+# Let ctrl+r restart a server: stop it and re-run its captured command line
+# in the same directory. Arguments are split on spaces, so commands that
+# relied on shell quoting may not come back the same.
+# allow_restart: true
+
+# As root, kills reach every user's processes. Ask twice before each one.
+# confirm_root_kills: true
+
+# Ask about kills and restarts in the status bar instead of a box over the
+# list.
+# quick_confirm: true
+
+# Count the listening ports outside port_range in the status bar, as
+# "+7 outside range".
+# show_excluded: true
+
+# List bound UDP sockets too (Linux only), with a PROTO column telling them
+# apart from TCP listeners.
+# include_udp: true
+
+# At startup, list any ports reachable from other machines (bound to
+# something other than loopback) in a box until a key is pressed.
+# exposed_banner: true
+
+# Send a desktop notification (notify-send on Linux, osascript on macOS)
+# when a server stops answering or stops listening.
+# notify: true
+`
+
+// WriteDefaultCommented writes an annotated default config to path, creating
+// parent directories as needed. JSON has no comments, so a .json path gets
+// the plain defaults. It never overwrites an existing file; if path already
+// exists the returned error satisfies errors.Is(err, fs.ErrExist).
+func WriteDefaultCommented(path string) error {
+	content := []byte(fmt.Sprintf(commentedTemplate, defaultRefreshInterval, defaultMinPort, defaultMaxPort))
+	if isJSON(path) {
+		data, err := encode(path, Default())
+		if err != nil {
+			return fmt.Errorf("encoding config: %w", err)
+		}
+		content = data
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("creating config: %w", err)
+	}
+	_, err = f.Write(content)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	return nil
+}
+
+// Validate reports the first invalid field in c.
+func (c Config) Validate() error {
+	if c.RefreshInterval < 0 {
+		return fmt.Errorf("refresh_interval must not be negative, got %s", c.RefreshInterval)
+	}
+	if c.HealthTimeout < 0 {
+		return fmt.Errorf("health_timeout must not be negative, got %s", c.HealthTimeout)
+	}
+	if c.IdleQuit < 0 {
+		return fmt.Errorf("idle_quit must not be negative, got %s", c.IdleQuit)
+	}
+	if c.BackgroundInterval < 0 {
+		return fmt.Errorf("background_interval must not be negative, got %s", c.BackgroundInterval)
+	}
+	if c.PortRange.Min < 1 || c.PortRange.Max > 65535 {
+		return fmt.Errorf("port_range must be within 1-65535, got %d-%d", c.PortRange.Min, c.PortRange.Max)
+	}
+	if c.PortRange.Min > c.PortRange.Max {
+		return fmt.Errorf("port_range min %d is greater than max %d", c.PortRange.Min, c.PortRange.Max)
+	}
+	for port := range c.Labels {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("labels: %d is not a valid port", port)
+		}
+	}
+	for port, path := range c.LogPaths {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("log_paths: %d is not a valid port", port)
+		}
+		if path == "" {
+			return fmt.Errorf("log_paths: port %d has an empty path", port)
+		}
+	}
+	if c.DefaultSort != "" && !slices.Contains(SortKeys, c.DefaultSort) {
+		return fmt.Errorf("default_sort must be one of %s, got %q", strings.Join(SortKeys, ", "), c.DefaultSort)
+	}
+	if c.HiddenMode != "" && !slices.Contains(HiddenModes, c.HiddenMode) {
+		return fmt.Errorf("hidden_mode must be one of %s, got %q", strings.Join(HiddenModes, ", "), c.HiddenMode)
+	}
+	if c.TimeFormat != "" && !slices.Contains(TimeFormats, c.TimeFormat) {
+		return fmt.Errorf("time_format must be one of %s, got %q", strings.Join(TimeFormats, ", "), c.TimeFormat)
+	}
+	if !slices.Contains(CursorStyles, c.CursorStyle) {
+		if n := utf8.RuneCountInString(c.CursorStyle); n > maxCursorMarker || strings.ContainsFunc(c.CursorStyle, unicode.IsControl) {
+			return fmt.Errorf("cursor_style must be %s or a marker of up to %d characters, got %q", strings.Join(CursorStyles, ", "), maxCursorMarker, c.CursorStyle)
+		}
+	}
+	for _, a := range c.DisabledActions {
+		if !slices.Contains(Actions, a) {
+			return fmt.Errorf("disabled_actions: %q is not one of %s", a, strings.Join(Actions, ", "))
+		}
+	}
+	for event, command := range c.Hooks {
+		if !slices.Contains(HookEvents, event) {
+			return fmt.Errorf("hooks: %q is not one of %s", event, strings.Join(HookEvents, ", "))
+		}
+		if strings.TrimSpace(command) == "" {
+			return fmt.Errorf("hooks: %s has an empty command", event)
+		}
+	}
+	for _, p := range c.SuspiciousPaths {
+		if !filepath.IsAbs(p) {
+			return fmt.Errorf("suspicious_paths: %q is not an absolute path", p)
+		}
+	}
+	for _, a := range c.LsofArgs {
+		if a == "-t" || strings.HasPrefix(a, "-F") {
+			return fmt.Errorf("lsof_args: %s changes lsof's output, which portview could not parse", a)
+		}
+	}
+	for _, r := range c.NoOpenRanges {
+		if _, err := ParsePortRange(r); err != nil {
+			return fmt.Errorf("no_open_ranges: %w", err)
+		}
+	}
+	for _, rule := range c.AutoLabels {
+		if _, err := ParsePortRange(rule.Ports); err != nil {
+			return fmt.Errorf("auto_labels: %w", err)
+		}
+		if strings.TrimSpace(rule.Label) == "" {
+			return fmt.Errorf("auto_labels: %s has an empty label", rule.Ports)
+		}
+	}
+	return nil
+}
+
+// Conflicts describes entries that are valid but cannot take effect: a
+// label or favorite on a hidden port, or a label, favorite or hidden entry
+// for a port outside port_range, whose row is never shown. With hidden_mode
+// dim, hidden rows are still listed, so hiding conflicts with nothing. Label
+// conflicts come first, then favorites, each kind in port order.
+func (c Config) Conflicts() []string {
+	var out []string
+	hides := !c.DimHidden()
+	ports := make([]int, 0, len(c.Labels))
+	for port := range c.Labels {
+		ports = append(ports, port)
+	}
+	slices.Sort(ports)
+	for _, port := range ports {
+		switch {
+		case !c.PortRange.Contains(port):
+			out = append(out, fmt.Sprintf("port %d is labelled %q but outside port_range", port, c.Labels[port]))
+		case hides && c.IsHidden(port):
+			out = append(out, fmt.Sprintf("port %d is labelled %q but hidden", port, c.Labels[port]))
+		}
+	}
+	favorites := slices.Clone(c.Favorites)
+	slices.Sort(favorites)
+	for _, port := range favorites {
+		switch {
+		case !c.PortRange.Contains(port):
+			out = append(out, fmt.Sprintf("port %d is a favorite but outside port_range", port))
+		case hides && c.IsHidden(port):
+			out = append(out, fmt.Sprintf("port %d is a favorite but hidden", port))
+		}
+	}
+	hidden := slices.Clone(c.Hidden)
+	slices.Sort(hidden)
+	for _, port := range hidden {
+		if !c.PortRange.Contains(port) {
+			out = append(out, fmt.Sprintf("port %d is hidden but outside port_range", port))
+		}
+	}
+	return out
+}
+
+// ParsePortRange parses a single port ("5432") or an inclusive range
+// ("5000-6000").
+func ParsePortRange(s string) (PortRange, error) {
+	lo, hi, isRange := strings.Cut(strings.TrimSpace(s), "-")
+	if !isRange {
+		hi = lo
+	}
+	min, err1 := strconv.Atoi(strings.TrimSpace(lo))
+	max, err2 := strconv.Atoi(strings.TrimSpace(hi))
+	if err1 != nil || err2 != nil || min < 1 || max > 65535 || min > max {
+		return PortRange{}, fmt.Errorf("invalid port range %q", s)
+	}
+	return PortRange{Min: min, Max: max}, nil
+}
+
+// Contains reports whether port lies within r.
+func (r PortRange) Contains(port int) bool {
+	return port >= r.Min && port <= r.Max
+}
+
+// ActionDisabled reports whether action is listed in disabled_actions.
+func (c Config) ActionDisabled(action string) bool {
+	return slices.Contains(c.DisabledActions, action)
+}
+
+// Widen returns the smallest range containing r and every port in ports.
+func (r PortRange) Widen(ports ...int) PortRange {
+	for _, p := range ports {
+		r.Min = min(r.Min, p)
+		r.Max = max(r.Max, p)
+	}
+	return r
+}
+
+// NoOpen reports whether port falls in one of the no_open_ranges. Entries
+// that do not parse are ignored; Validate reports them.
+func (c Config) NoOpen(port int) bool {
+	for _, s := range c.NoOpenRanges {
+		if r, err := ParsePortRange(s); err == nil && r.Contains(port) {
+			return true
+		}
+	}
+	return false
+}
+
+// SuspiciousExe reports whether exe, a resolved executable path, lies under
+// one of the suspicious_paths. Matching is on whole path elements, so /tmp
+// covers /tmp/x but not /tmpfiles/x. An empty exe is never suspicious.
+func (c Config) SuspiciousExe(exe string) bool {
+	if exe == "" {
+		return false
+	}
+	dirs := c.SuspiciousPaths
+	if dirs == nil {
+		dirs = DefaultSuspiciousPaths
+	}
+	exe = filepath.Clean(exe)
+	for _, dir := range dirs {
+		dir = filepath.Clean(dir)
+		rel, err := filepath.Rel(dir, exe)
+		if err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// LabelFor returns port's label: the explicit one if set, else the label of
+// the first auto_labels rule that matches.
+func (c Config) LabelFor(port int) string {
+	if label, ok := c.Labels[port]; ok {
+		return label
+	}
+	for _, rule := range c.AutoLabels {
+		if r, err := ParsePortRange(rule.Ports); err == nil && r.Contains(port) {
+			return rule.Label
+		}
+	}
+	return ""
+}
+
+// Clone returns a deep copy of c, safe to hand to another goroutine.
+func (c Config) Clone() Config {
+	out := c
+	out.Labels = make(map[int]string, len(c.Labels))
+	for port, label := range c.Labels {
+		out.Labels[port] = label
+	}
+	out.Hidden = slices.Clone(c.Hidden)
+	out.Favorites = slices.Clone(c.Favorites)
+	out.NoOpenRanges = slices.Clone(c.NoOpenRanges)
+	out.AutoLabels = slices.Clone(c.AutoLabels)
+	out.DisabledActions = slices.Clone(c.DisabledActions)
+	out.SuspiciousPaths = slices.Clone(c.SuspiciousPaths)
+	out.LsofArgs = slices.Clone(c.LsofArgs)
+	out.LogPaths = maps.Clone(c.LogPaths)
+	out.Hooks = maps.Clone(c.Hooks)
+	return out
+}
+
+// SetLabel assigns label to port. An empty label removes it.
+func (c *Config) SetLabel(port int, label string) {
+	if label == "" {
+		c.RemoveLabel(port)
+		return
+	}
+	if c.Labels == nil {
+		c.Labels = map[int]string{}
+	}
+	c.Labels[port] = label
+}
+
+// RemoveLabel deletes any label assigned to port.
+func (c *Config) RemoveLabel(port int) {
+	delete(c.Labels, port)
+}
+
+// IsHidden reports whether port is in the hidden list.
+func (c Config) IsHidden(port int) bool {
+	return slices.Contains(c.Hidden, port)
+}
+
+// IsFavorite reports whether port is in the favorites list.
+func (c Config) IsFavorite(port int) bool {
+	return slices.Contains(c.Favorites, port)
+}
+
+// ToggleFavorite adds port to the favorites list, or removes it if already
+// present. It reports whether the port is a favorite afterwards.
+func (c *Config) ToggleFavorite(port int) bool {
+	if i := slices.Index(c.Favorites, port); i >= 0 {
+		c.Favorites = slices.Delete(c.Favorites, i, i+1)
+		return false
+	}
+	c.Favorites = append(c.Favorites, port)
+	slices.Sort(c.Favorites)
+	return true
+}
+
+// DimHidden reports whether hidden ports stay listed, dimmed, rather than
+// being removed.
+func (c Config) DimHidden() bool {
+	return c.HiddenMode == "dim"
+}
+
+// ToggleHidden adds port to the hidden list, or removes it if already
+// present. It reports whether the port is hidden afterwards.
+func (c *Config) ToggleHidden(port int) bool {
+	if i := slices.Index(c.Hidden, port); i >= 0 {
+		c.Hidden = slices.Delete(c.Hidden, i, i+1)
+		return false
+	}
+	c.Hidden = append(c.Hidden, port)
+	slices.Sort(c.Hidden)
+	return true
+}
@@ -0,0 +1,508 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadMissingFileReturnsDefault(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), "nope.yaml"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Errorf("Load() = %+v, want Default()", cfg)
+	}
+}
+
+func TestLoadPartialFileKeepsDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("labels:\n  3000: frontend\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.RefreshInterval != defaultRefreshInterval {
+		t.Errorf("RefreshInterval = %s, want %s", cfg.RefreshInterval, defaultRefreshInterval)
+	}
+	if cfg.PortRange != (PortRange{Min: defaultMinPort, Max: defaultMaxPort}) {
+		t.Errorf("PortRange = %+v, want defaults", cfg.PortRange)
+	}
+	if cfg.Labels[3000] != "frontend" {
+		t.Errorf("Labels[3000] = %q, want frontend", cfg.Labels[3000])
+	}
+}
+
+func TestLoadRejectsInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("port_range:\n  min: 9000\n  max: 8000\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load() error = nil, want validation error")
+	}
+}
+
+func TestLoadZeroRefreshInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("refresh_interval: 0s\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v, want 0 accepted as manual refresh", err)
+	}
+	if cfg.RefreshInterval != 0 {
+		t.Errorf("RefreshInterval = %s, want 0", cfg.RefreshInterval)
+	}
+	cfg.RefreshInterval = -time.Second
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() accepted a negative refresh_interval")
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "config.yaml")
+	want := Config{
+		RefreshInterval: 5 * time.Second,
+		PortRange:       PortRange{Min: 3000, Max: 9000},
+		Labels:          map[int]string{3000: "frontend", 8080: "api"},
+		Hidden:          []int{5432},
+	}
+	if err := Save(path, want); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+}
+
+func TestDefaultPathHonorsXDG(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/xdg")
+	got, err := DefaultPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join("/tmp/xdg", "portview", "config.yaml"); got != want {
+		t.Errorf("DefaultPath() = %q, want %q", got, want)
+	}
+}
+
+func TestSetLabel(t *testing.T) {
+	cfg := Default()
+	cfg.SetLabel(3000, "web")
+	if cfg.Labels[3000] != "web" {
+		t.Errorf("Labels[3000] = %q, want web", cfg.Labels[3000])
+	}
+	cfg.SetLabel(3000, "")
+	if _, ok := cfg.Labels[3000]; ok {
+		t.Error("empty SetLabel should remove the label")
+	}
+}
+
+func TestToggleHidden(t *testing.T) {
+	cfg := Default()
+	if !cfg.ToggleHidden(5432) || !cfg.IsHidden(5432) {
+		t.Fatal("first toggle should hide 5432")
+	}
+	if cfg.ToggleHidden(5432) || cfg.IsHidden(5432) {
+		t.Fatal("second toggle should unhide 5432")
+	}
+}
+
+func TestCloneIsDeep(t *testing.T) {
+	cfg := Default()
+	cfg.SetLabel(3000, "web")
+	cfg.Hidden = []int{22}
+	clone := cfg.Clone()
+	cfg.SetLabel(3000, "changed")
+	cfg.Hidden[0] = 80
+	if clone.Labels[3000] != "web" || clone.Hidden[0] != 22 {
+		t.Errorf("Clone() shares state with original: %+v", clone)
+	}
+}
+
+func TestWriteDefaultCommented(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "portview", "config.yaml")
+	if err := WriteDefaultCommented(path); err != nil {
+		t.Fatalf("WriteDefaultCommented() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"refresh_interval:", "port_range:", "min:", "max:", "labels:", "hidden:"} {
+		if !strings.Contains(string(data), key) {
+			t.Errorf("commented config missing %q", key)
+		}
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() of commented config error = %v", err)
+	}
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Errorf("commented config loads as %+v, want Default()", cfg)
+	}
+}
+
+func TestWriteDefaultCommentedNeverOverwrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("refresh_interval: 9s\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := WriteDefaultCommented(path)
+	if !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("WriteDefaultCommented() error = %v, want fs.ErrExist", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "refresh_interval: 9s\n" {
+		t.Errorf("existing config was modified: %q", data)
+	}
+}
+
+func TestLoadIdleQuit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("idle_quit: 15m\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.IdleQuit != 15*time.Minute {
+		t.Errorf("IdleQuit = %s, want 15m", cfg.IdleQuit)
+	}
+
+	cfg.IdleQuit = -time.Second
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should reject a negative idle_quit")
+	}
+}
+
+func TestLoadHealthTimeout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("health_timeout: 250ms\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.HealthTimeout != 250*time.Millisecond {
+		t.Errorf("HealthTimeout = %s, want 250ms", cfg.HealthTimeout)
+	}
+
+	cfg.HealthTimeout = -time.Second
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should reject a negative health_timeout")
+	}
+}
+
+func TestSnapshotPath(t *testing.T) {
+	got, err := SnapshotPath("/home/dev/.config/portview/config.yaml", "before-deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/home/dev/.config/portview/snapshots/before-deploy.json"; got != want {
+		t.Errorf("SnapshotPath() = %q, want %q", got, want)
+	}
+	for _, name := range []string{"", "..", "../escape", "a/b"} {
+		if _, err := SnapshotPath("/tmp/config.yaml", name); err == nil {
+			t.Errorf("SnapshotPath(%q) should be rejected", name)
+		}
+	}
+}
+
+func TestParsePortRange(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    PortRange
+		wantErr bool
+	}{
+		{"5432", PortRange{5432, 5432}, false},
+		{"5000-6000", PortRange{5000, 6000}, false},
+		{" 5000 - 6000 ", PortRange{5000, 6000}, false},
+		{"6000-5000", PortRange{}, true},
+		{"0-10", PortRange{}, true},
+		{"grpc", PortRange{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePortRange(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParsePortRange(%q) = %+v, %v; want %+v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestLoadNoOpenRanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("no_open_ranges:\n  - 5432\n  - 5000-6000\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	for port, want := range map[int]bool{5432: true, 5000: true, 5999: true, 6001: false, 3000: false} {
+		if got := cfg.NoOpen(port); got != want {
+			t.Errorf("NoOpen(%d) = %v, want %v", port, got, want)
+		}
+	}
+
+	cfg.NoOpenRanges = []string{"9-1"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should reject a malformed no_open_ranges entry")
+	}
+}
+
+func TestViewModeRoundTrip(t *testing.T) {
+	for _, name := range []string{"config.yaml", "config.json"} {
+		path := filepath.Join(t.TempDir(), name)
+		cfg := Default()
+		cfg.ViewMode = "label"
+		if err := Save(path, cfg); err != nil {
+			t.Fatalf("Save(%s) error = %v", name, err)
+		}
+		got, err := Load(path)
+		if err != nil {
+			t.Fatalf("Load(%s) error = %v", name, err)
+		}
+		if got.ViewMode != "label" {
+			t.Errorf("%s: ViewMode = %q, want label", name, got.ViewMode)
+		}
+	}
+}
+
+func TestValidateDefaultSort(t *testing.T) {
+	cfg := Default()
+	for _, key := range append([]string{""}, SortKeys...) {
+		cfg.DefaultSort = key
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("default_sort %q: Validate() error = %v", key, err)
+		}
+	}
+	cfg.DefaultSort = "health"
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should reject an unknown default_sort")
+	}
+}
+
+func TestValidateHiddenMode(t *testing.T) {
+	cfg := Default()
+	for _, mode := range append([]string{""}, HiddenModes...) {
+		cfg.HiddenMode = mode
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("hidden_mode %q: Validate() error = %v", mode, err)
+		}
+	}
+	cfg.HiddenMode = "fade"
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should reject an unknown hidden_mode")
+	}
+}
+
+func TestValidateCursorStyle(t *testing.T) {
+	cfg := Default()
+	for _, style := range []string{"", "arrow", "block", "▶", "»", "->"} {
+		cfg.CursorStyle = style
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("cursor_style %q: Validate() error = %v", style, err)
+		}
+	}
+	for _, bad := range []string{"-->>", "\t"} {
+		cfg.CursorStyle = bad
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Validate() should reject cursor_style %q", bad)
+		}
+	}
+}
+
+func TestValidateTimeFormat(t *testing.T) {
+	cfg := Default()
+	for _, f := range []string{"", "relative", "clock", "both"} {
+		cfg.TimeFormat = f
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("time_format %q: Validate() error = %v", f, err)
+		}
+	}
+	cfg.TimeFormat = "iso"
+	if err := cfg.Validate(); err == nil {
+		t.Error(`Validate() should reject time_format "iso"`)
+	}
+}
+
+func TestValidateLsofArgs(t *testing.T) {
+	cfg := Default()
+	cfg.LsofArgs = []string{"-iTCP", "-sTCP:LISTEN", "-nP", "-w"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	for _, bad := range []string{"-t", "-Fpcn"} {
+		cfg.LsofArgs = []string{"-iTCP", bad}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Validate() should reject lsof_args with %s", bad)
+		}
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	cfg := Default()
+	cfg.PortRange = PortRange{Min: 1024, Max: 9999}
+	cfg.Labels = map[int]string{8080: "api", 3000: "web", 22: "ssh"}
+	cfg.Hidden = []int{8080, 80, 5432}
+	cfg.Favorites = []int{5432, 3000, 443}
+
+	want := []string{
+		`port 22 is labelled "ssh" but outside port_range`,
+		`port 8080 is labelled "api" but hidden`,
+		`port 443 is a favorite but outside port_range`,
+		`port 5432 is a favorite but hidden`,
+		`port 80 is hidden but outside port_range`,
+	}
+	if got := cfg.Conflicts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Conflicts() =\n%q\nwant\n%q", got, want)
+	}
+	if got := Default().Conflicts(); len(got) != 0 {
+		t.Errorf("Default().Conflicts() = %q, want none", got)
+	}
+
+	// Dimmed rows are still listed, so only the range conflicts remain.
+	cfg.HiddenMode = "dim"
+	want = []string{
+		`port 22 is labelled "ssh" but outside port_range`,
+		`port 443 is a favorite but outside port_range`,
+		`port 80 is hidden but outside port_range`,
+	}
+	if got := cfg.Conflicts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Conflicts() with hidden_mode dim =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestLabelForAutoLabels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `labels:
+  3001: storybook
+auto_labels:
+  - ports: 3000-3099
+    label: frontend
+  - ports: 3000-9000
+    label: shadowed
+  - ports: "8080"
+    label: api
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		port int
+		want string
+	}{
+		{3000, "frontend"},
+		{3001, "storybook"}, // explicit label wins
+		{3099, "frontend"},
+		{3100, "shadowed"}, // first matching rule wins
+		{8080, "shadowed"},
+		{9001, ""},
+	}
+	for _, tt := range tests {
+		if got := cfg.LabelFor(tt.port); got != tt.want {
+			t.Errorf("LabelFor(%d) = %q, want %q", tt.port, got, tt.want)
+		}
+	}
+}
+
+func TestValidateAutoLabels(t *testing.T) {
+	for _, rule := range []AutoLabel{{Ports: "30xx", Label: "web"}, {Ports: "3000", Label: " "}} {
+		cfg := Default()
+		cfg.AutoLabels = []AutoLabel{rule}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Validate() accepted auto_labels rule %+v", rule)
+		}
+	}
+}
+
+func TestPortRangeWiden(t *testing.T) {
+	r := PortRange{Min: 1024, Max: 9000}
+	if got, want := r.Widen(80, 9443), (PortRange{Min: 80, Max: 9443}); got != want {
+		t.Errorf("Widen(80, 9443) = %+v, want %+v", got, want)
+	}
+	if got := r.Widen(3000); got != r {
+		t.Errorf("Widen of an included port = %+v, want %+v unchanged", got, r)
+	}
+}
+
+func TestValidateDisabledActions(t *testing.T) {
+	cfg := Default()
+	cfg.DisabledActions = Actions
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with every action disabled: %v", err)
+	}
+	cfg.DisabledActions = []string{"kill", "nuke"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() accepted an unknown action")
+	}
+}
+
+func TestValidateHooks(t *testing.T) {
+	cfg := Default()
+	cfg.Hooks = map[string]string{"on_kill": "true", "on_down": "true", "on_up": "true"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with every hook set: %v", err)
+	}
+	cfg.Hooks = map[string]string{"on_crash": "true"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() accepted an unknown hook event")
+	}
+	cfg.Hooks = map[string]string{"on_up": " "}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() accepted an empty hook command")
+	}
+}
+
+func TestSuspiciousExe(t *testing.T) {
+	cfg := Default()
+	tests := []struct {
+		exe  string
+		want bool
+	}{
+		{"/tmp/miner", true},
+		{"/tmp/.hidden/x", true},
+		{"/dev/shm/payload", true},
+		{"/var/tmp/../tmp/x", true},
+		{"/tmpfiles/server", false},
+		{"/tmp", false},
+		{"/usr/bin/node", false},
+		{"/tmp/../usr/bin/node", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := cfg.SuspiciousExe(tt.exe); got != tt.want {
+			t.Errorf("SuspiciousExe(%q) = %v, want %v", tt.exe, got, tt.want)
+		}
+	}
+
+	cfg.SuspiciousPaths = []string{"/home/shared/"}
+	if !cfg.SuspiciousExe("/home/shared/bin/x") || cfg.SuspiciousExe("/tmp/miner") {
+		t.Error("suspicious_paths should replace the defaults")
+	}
+	cfg.SuspiciousPaths = []string{"tmp"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() accepted a relative suspicious path")
+	}
+}
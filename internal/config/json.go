@@ -0,0 +1,71 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// MarshalJSON writes durations as Go duration strings ("3s") rather than
+// encoding/json's default of integer nanoseconds, matching the YAML form.
+func (c Config) MarshalJSON() ([]byte, error) {
+	type plain Config
+	return json.Marshal(struct {
+		plain
+		RefreshInterval    jsonDuration `json:"refresh_interval"`
+		IdleQuit           jsonDuration `json:"idle_quit,omitempty"`
+		BackgroundInterval jsonDuration `json:"background_interval,omitempty"`
+		HealthTimeout      jsonDuration `json:"health_timeout,omitempty"`
+	}{
+		plain:              plain(c),
+		RefreshInterval:    jsonDuration(c.RefreshInterval),
+		IdleQuit:           jsonDuration(c.IdleQuit),
+		BackgroundInterval: jsonDuration(c.BackgroundInterval),
+		HealthTimeout:      jsonDuration(c.HealthTimeout),
+	})
+}
+
+// UnmarshalJSON accepts durations as strings ("3s") or integer nanoseconds.
+// Fields absent from data keep their current values.
+func (c *Config) UnmarshalJSON(data []byte) error {
+	type plain Config
+	aux := struct {
+		*plain
+		RefreshInterval    *jsonDuration `json:"refresh_interval"`
+		IdleQuit           *jsonDuration `json:"idle_quit"`
+		BackgroundInterval *jsonDuration `json:"background_interval"`
+		HealthTimeout      *jsonDuration `json:"health_timeout"`
+	}{
+		plain:              (*plain)(c),
+		RefreshInterval:    (*jsonDuration)(&c.RefreshInterval),
+		IdleQuit:           (*jsonDuration)(&c.IdleQuit),
+		BackgroundInterval: (*jsonDuration)(&c.BackgroundInterval),
+		HealthTimeout:      (*jsonDuration)(&c.HealthTimeout),
+	}
+	return json.Unmarshal(data, &aux)
+}
+
+// jsonDuration is a time.Duration that encodes as a duration string.
+type jsonDuration time.Duration
+
+func (d jsonDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *jsonDuration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		parsed, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		*d = jsonDuration(parsed)
+		return nil
+	}
+	var n int64
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("duration must be a string like \"3s\" or integer nanoseconds, got %s", data)
+	}
+	*d = jsonDuration(n)
+	return nil
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveLoadJSONRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	want := Config{
+		RefreshInterval:    1500 * time.Millisecond,
+		PortRange:          PortRange{Min: 2000, Max: 9999},
+		Labels:             map[int]string{3000: "frontend", 8080: "api"},
+		Hidden:             []int{5432, 6379},
+		IdleQuit:           10 * time.Minute,
+		BackgroundInterval: 45 * time.Second,
+		HealthTimeout:      250 * time.Millisecond,
+	}
+	if err := Save(path, want); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{`"refresh_interval": "1.5s"`, `"8080": "api"`, `"idle_quit": "10m0s"`, `"background_interval": "45s"`, `"health_timeout": "250ms"`} {
+		if !strings.Contains(string(data), s) {
+			t.Errorf("saved JSON missing %s:\n%s", s, data)
+		}
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadJSONKeepsDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"labels": {"8080": "web"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.RefreshInterval != defaultRefreshInterval || cfg.PortRange.Max != defaultMaxPort {
+		t.Errorf("missing fields should keep defaults, got %+v", cfg)
+	}
+	if cfg.Labels[8080] != "web" {
+		t.Errorf("Labels[8080] = %q, want web", cfg.Labels[8080])
+	}
+}
+
+func TestLoadJSONDurationForms(t *testing.T) {
+	for _, body := range []string{`{"refresh_interval": "2s"}`, `{"refresh_interval": 2000000000}`} {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := Load(path)
+		if err != nil {
+			t.Fatalf("Load(%s) error = %v", body, err)
+		}
+		if cfg.RefreshInterval != 2*time.Second {
+			t.Errorf("Load(%s) RefreshInterval = %s, want 2s", body, cfg.RefreshInterval)
+		}
+	}
+}
+
+func TestLoadJSONRejectsBadDuration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"refresh_interval": "soon"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load() error = nil, want duration parse error")
+	}
+}
+
+func TestWriteDefaultCommentedJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := WriteDefaultCommented(path); err != nil {
+		t.Fatalf("WriteDefaultCommented() error = %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Errorf("default JSON config loads as %+v, want Default()", cfg)
+	}
+}
+
+// JSON object keys are always strings; encoding/json converts them to and
+// from the int keys of Labels, which these tests pin down.
+func TestJSONLabelKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    map[int]string
+		wantErr bool
+	}{
+		{"numeric keys", `{"labels": {"8080": "web", "3000": "frontend"}}`, map[int]string{8080: "web", 3000: "frontend"}, false},
+		{"non-numeric key", `{"labels": {"web": "8080"}}`, nil, true},
+		{"out of range port", `{"labels": {"70000": "web"}}`, nil, true},
+		{"zero port", `{"labels": {"0": "web"}}`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(path, []byte(tt.body), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := Load(path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Load() = %+v, want error", cfg.Labels)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if !reflect.DeepEqual(cfg.Labels, tt.want) {
+				t.Errorf("Labels = %v, want %v", cfg.Labels, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalJSONLabelKeysAreStrings(t *testing.T) {
+	cfg := Default()
+	cfg.SetLabel(8080, "web")
+	data, err := cfg.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"labels":{"8080":"web"}`) {
+		t.Errorf("MarshalJSON() = %s, want string port keys", data)
+	}
+}
@@ -0,0 +1,69 @@
+package scanner
+
+// Diff is what changed between two scans, such as snapshots taken before and
+// after a deploy. Each set is sorted like a scan.
+type Diff struct {
+	Added   []Server // listening in b only
+	Removed []Server // listening in a only
+	Changed []Change // listening in both, under a different PID or process
+}
+
+// Change is one port whose owner differs between two scans.
+type Change struct {
+	Before, After Server
+}
+
+// Empty reports whether the two scans listed the same ports and owners.
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// diffKey identifies a listener across scans: its port, or the path of a Unix
+// socket, whose port is always zero.
+type diffKey struct {
+	port int
+	path string
+}
+
+// DiffServers compares scan a with the later scan b, matching servers by port.
+// When a scan lists a port more than once, as for binds on several
+// addresses, its first row stands for the port.
+func DiffServers(a, b []Server) Diff {
+	beforeRows, before := firstRows(a)
+	afterRows, after := firstRows(b)
+	var d Diff
+	for _, s := range afterRows {
+		old, ok := before[s.diffKey()]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, s)
+		case old.PID != s.PID || old.Process != s.Process:
+			d.Changed = append(d.Changed, Change{Before: old, After: s})
+		}
+	}
+	for _, s := range beforeRows {
+		if _, ok := after[s.diffKey()]; !ok {
+			d.Removed = append(d.Removed, s)
+		}
+	}
+	return d
+}
+
+func (s Server) diffKey() diffKey {
+	return diffKey{s.Port, s.SocketPath}
+}
+
+// firstRows returns the first row for each listener in servers, sorted by
+// port, and the same rows by key.
+func firstRows(servers []Server) ([]Server, map[diffKey]Server) {
+	var rows []Server
+	index := make(map[diffKey]Server, len(servers))
+	for _, s := range servers {
+		if _, seen := index[s.diffKey()]; !seen {
+			index[s.diffKey()] = s
+			rows = append(rows, s)
+		}
+	}
+	sortByPort(rows)
+	return rows, index
+}
@@ -0,0 +1,54 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffServers(t *testing.T) {
+	before := []Server{
+		{Port: 8080, Addr: "*", PID: 300, Process: "api"},
+		{Port: 3000, Addr: "127.0.0.1", PID: 100, Process: "node"},
+		{Port: 3000, Addr: "[::1]", PID: 100, Process: "node"},
+		{Port: 5432, Addr: "0.0.0.0", PID: 200, Process: "postgres"},
+	}
+	after := []Server{
+		{Port: 3000, Addr: "127.0.0.1", PID: 100, Process: "node"},
+		{Port: 8080, Addr: "*", PID: 310, Process: "api"},
+		{Port: 9000, Addr: "127.0.0.1", PID: 400, Process: "worker"},
+		{Port: 4000, Addr: "127.0.0.1", PID: 500, Process: "vite"},
+	}
+	got := DiffServers(before, after)
+	want := Diff{
+		Added:   []Server{after[3], after[2]},
+		Removed: []Server{before[3]},
+		Changed: []Change{{Before: before[0], After: after[1]}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffServers() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDiffServersProcessChange(t *testing.T) {
+	got := DiffServers(
+		[]Server{{Port: 3000, PID: 100, Process: "node"}},
+		[]Server{{Port: 3000, PID: 100, Process: "bun"}},
+	)
+	if len(got.Changed) != 1 || got.Changed[0].After.Process != "bun" {
+		t.Errorf("DiffServers() = %+v, want the process change", got)
+	}
+}
+
+func TestDiffServersSame(t *testing.T) {
+	servers := []Server{
+		{Port: 3000, PID: 100, Process: "node", Healthy: true},
+		{SocketPath: "/run/app.sock", PID: 200, Process: "app"},
+	}
+	moved := []Server{
+		{Port: 3000, PID: 100, Process: "node", Healthy: false},
+		{SocketPath: "/run/app.sock", PID: 200, Process: "app"},
+	}
+	if d := DiffServers(servers, moved); !d.Empty() {
+		t.Errorf("DiffServers() = %+v, want no changes; health is not compared", d)
+	}
+}
@@ -0,0 +1,32 @@
+package scanner
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"time"
+)
+
+// CheckHealth reports whether a TCP connection to localhost:port succeeds
+// within timeout.
+func CheckHealth(ctx context.Context, port int, timeout time.Duration) bool {
+	d := net.Dialer{Timeout: timeout}
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort("localhost", strconv.Itoa(port)))
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// CheckSocketHealth reports whether a connection to the Unix domain socket
+// at path succeeds within timeout.
+func CheckSocketHealth(ctx context.Context, path string, timeout time.Duration) bool {
+	d := net.Dialer{Timeout: timeout}
+	conn, err := d.DialContext(ctx, "unix", path)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
@@ -0,0 +1,25 @@
+package scanner
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestCheckHealth(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	if !CheckHealth(context.Background(), port, time.Second) {
+		t.Errorf("CheckHealth(%d) = false while listening", port)
+	}
+
+	ln.Close()
+	if CheckHealth(context.Background(), port, 200*time.Millisecond) {
+		t.Errorf("CheckHealth(%d) = true after close", port)
+	}
+}
@@ -0,0 +1,38 @@
+package scanner
+
+import "context"
+
+// MockScanner returns a fixed result from every Scan. It lets the TUI and
+// tests run without touching the host's sockets.
+type MockScanner struct {
+	Servers []Server
+	Err     error
+	// OutsideRange is what Excluded reports.
+	OutsideRange int
+}
+
+// Scan returns a copy of m.Servers, or m.Err if it is set.
+func (m *MockScanner) Scan(ctx context.Context) ([]Server, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	out := make([]Server, len(m.Servers))
+	copy(out, m.Servers)
+	return out, nil
+}
+
+// ResolvePort returns the process fields of the server on proto and port in
+// m.Servers, or ErrUnresolved if there is none or its PID is unknown.
+func (m *MockScanner) ResolvePort(ctx context.Context, proto string, port int) (Server, error) {
+	for _, s := range m.Servers {
+		if s.Port == port && s.Proto() == proto && s.PID > 0 {
+			return Server{Port: port, Protocol: s.Protocol, PID: s.PID, PIDs: s.PIDs, Process: s.Process, Command: s.Command, ExePath: s.ExePath}, nil
+		}
+	}
+	return Server{}, ErrUnresolved
+}
+
+// Excluded returns m.OutsideRange.
+func (m *MockScanner) Excluded() int {
+	return m.OutsideRange
+}
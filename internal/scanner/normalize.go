@@ -0,0 +1,35 @@
+package scanner
+
+import (
+	"path"
+	"strings"
+)
+
+// NormalizeProcessName tidies a process name for display: it drops the
+// " (deleted)" Linux appends once an executable is replaced on disk and trims
+// a path to its last element. With collapseVersion it also strips a trailing
+// version, so "python3.11" and "node-18" become "python" and "node"; a name
+// that is nothing but digits is left alone.
+func NormalizeProcessName(name string, collapseVersion bool) string {
+	name = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(name), "(deleted)"))
+	if strings.Contains(name, "/") {
+		name = path.Base(name)
+	}
+	if collapseVersion {
+		trimmed := strings.TrimRight(name, "0123456789.")
+		trimmed = strings.TrimRight(trimmed, "-_")
+		if trimmed != "" {
+			name = trimmed
+		}
+	}
+	return name
+}
+
+// Name is how the list shows s: DisplayName when normalization set one,
+// else the raw Process.
+func (s Server) Name() string {
+	if s.DisplayName != "" {
+		return s.DisplayName
+	}
+	return s.Process
+}
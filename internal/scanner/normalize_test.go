@@ -0,0 +1,30 @@
+package scanner
+
+import "testing"
+
+func TestNormalizeProcessName(t *testing.T) {
+	tests := []struct {
+		name     string
+		collapse bool
+		want     string
+	}{
+		{"node", false, "node"},
+		{"node (deleted)", false, "node"},
+		{"/usr/local/bin/node", false, "node"},
+		{"/opt/app/bin/api (deleted)", false, "api"},
+		{"python3.11", false, "python3.11"},
+		{"python3.11", true, "python"},
+		{"python3", true, "python"},
+		{"node-18", true, "node"},
+		{"ruby_3.2.2", true, "ruby"},
+		{"/usr/bin/python3.12 (deleted)", true, "python"},
+		{"k3s", true, "k3s"},
+		{"1234", true, "1234"},
+		{"", true, ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeProcessName(tt.name, tt.collapse); got != tt.want {
+			t.Errorf("NormalizeProcessName(%q, %v) = %q, want %q", tt.name, tt.collapse, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package scanner
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// lsofEntry is one listening socket parsed from lsof output.
+type lsofEntry struct {
+	Command string
+	PID     int
+	Addr    string // bind address as lsof prints it: "*", "127.0.0.1", "[::1]"
+	Port    int
+}
+
+// parseLsofOutput extracts listening sockets from the output of
+// `lsof -iTCP -sTCP:LISTEN -nP`. The NAME column looks like "*:3000",
+// "127.0.0.1:8080" or "[::1]:5432", followed by "(LISTEN)".
+func parseLsofOutput(out string) []lsofEntry {
+	var entries []lsofEntry
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 9 || fields[0] == "COMMAND" {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		name := fields[len(fields)-1]
+		if name == "(LISTEN)" {
+			name = fields[len(fields)-2]
+		}
+		idx := strings.LastIndex(name, ":")
+		if idx < 0 {
+			continue
+		}
+		port, err := strconv.Atoi(name[idx+1:])
+		if err != nil {
+			continue
+		}
+		entries = append(entries, lsofEntry{Command: fields[0], PID: pid, Addr: name[:idx], Port: port})
+	}
+	return entries
+}
+
+// lsofUnixEntry is a Unix domain socket with a path parsed from lsof output.
+type lsofUnixEntry struct {
+	Command string
+	PID     int
+	Path    string
+}
+
+// parseLsofUnix extracts sockets bound to a path from the output of
+// `lsof -U -nP`, one entry per path in the order first seen. The NODE
+// column is often blank for Unix sockets, so NAME is found as the first
+// field after SIZE/OFF that starts with "/". Connected ends, whose NAME is
+// "->0x…", are skipped, as is the " type=STREAM" suffix newer lsof
+// versions append.
+func parseLsofUnix(out string) []lsofUnixEntry {
+	var entries []lsofUnixEntry
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 8 || fields[0] == "COMMAND" || fields[4] != "unix" {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		start := slices.IndexFunc(fields[7:], func(f string) bool { return strings.HasPrefix(f, "/") })
+		if start < 0 {
+			continue
+		}
+		name := fields[7+start:]
+		if last := name[len(name)-1]; len(name) > 1 && strings.HasPrefix(last, "type=") {
+			name = name[:len(name)-1]
+		}
+		path := strings.Join(name, " ")
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		entries = append(entries, lsofUnixEntry{Command: fields[0], PID: pid, Path: path})
+	}
+	return entries
+}
+
+// parseLsofCwd extracts the directory from `lsof -a -p PID -d cwd -Fn`
+// output, whose field lines are "p<pid>", "f<fd>" and "n<path>".
+func parseLsofCwd(out string) (string, bool) {
+	for _, line := range strings.Split(out, "\n") {
+		if path, ok := strings.CutPrefix(line, "n"); ok && path != "" {
+			return path, true
+		}
+	}
+	return "", false
+}
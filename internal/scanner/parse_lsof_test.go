@@ -0,0 +1,59 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+const sampleLsofOutput = `COMMAND     PID USER   FD   TYPE             DEVICE SIZE/OFF NODE NAME
+node      12345  dev   23u  IPv6 0x8a1b2c3d4e5f6071      0t0  TCP *:3000 (LISTEN)
+postgres    901  dev    7u  IPv4 0x8a1b2c3d4e5f6072      0t0  TCP 127.0.0.1:5432 (LISTEN)
+postgres    901  dev    8u  IPv6 0x8a1b2c3d4e5f6073      0t0  TCP [::1]:5432 (LISTEN)
+`
+
+func TestParseLsofOutput(t *testing.T) {
+	got := parseLsofOutput(sampleLsofOutput)
+	want := []lsofEntry{
+		{Command: "node", PID: 12345, Addr: "*", Port: 3000},
+		{Command: "postgres", PID: 901, Addr: "127.0.0.1", Port: 5432},
+		{Command: "postgres", PID: 901, Addr: "[::1]", Port: 5432},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseLsofOutput() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseLsofOutputEmpty(t *testing.T) {
+	if got := parseLsofOutput(""); len(got) != 0 {
+		t.Errorf("parseLsofOutput(\"\") = %+v, want no entries", got)
+	}
+}
+
+func TestParseLsofCwd(t *testing.T) {
+	got, ok := parseLsofCwd("p4242\nfcwd\nn/Users/dev/src/web app\n")
+	if !ok || got != "/Users/dev/src/web app" {
+		t.Errorf("parseLsofCwd() = %q, %v; want the n field", got, ok)
+	}
+	if _, ok := parseLsofCwd("p4242\n"); ok {
+		t.Error("parseLsofCwd() without an n field should fail")
+	}
+}
+
+func TestParseLsofUnix(t *testing.T) {
+	out := `COMMAND     PID           USER   FD   TYPE             DEVICE SIZE/OFF NODE NAME
+launchd       1           root    5u  unix 0x1e5a0b3b1f2d6c01      0t0      /private/var/run/syslog
+mDNSRespo   190 _mdnsresponder    3u  unix 0x1e5a0b3b1f2d6c02      0t0      /var/run/mDNSResponder
+mDNSRespo   190 _mdnsresponder    9u  unix 0x1e5a0b3b1f2d6c03      0t0      /var/run/mDNSResponder
+Finder      512            dev    7u  unix 0x1e5a0b3b1f2d6c04      0t0      ->0x1e5a0b3b1f2d6c02
+app        4242            dev    4u  unix 0x1e5a0b3b1f2d6c05      0t0 1234 /tmp/my app.sock type=STREAM
+`
+	got := parseLsofUnix(out)
+	want := []lsofUnixEntry{
+		{Command: "launchd", PID: 1, Path: "/private/var/run/syslog"},
+		{Command: "mDNSRespo", PID: 190, Path: "/var/run/mDNSResponder"},
+		{Command: "app", PID: 4242, Path: "/tmp/my app.sock"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseLsofUnix() = %+v, want %+v", got, want)
+	}
+}
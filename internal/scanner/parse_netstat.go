@@ -0,0 +1,80 @@
+package scanner
+
+import (
+	"encoding/csv"
+	"strconv"
+	"strings"
+)
+
+// netstatEntry is one listening socket parsed from Windows netstat output.
+type netstatEntry struct {
+	Addr string // bind address as netstat prints it: "0.0.0.0", "[::1]"
+	Port int
+	PID  int
+}
+
+// parseNetstatOutput extracts listening TCP sockets from the output of
+// `netstat -ano`. Rows look like
+//
+//	TCP    127.0.0.1:3000    0.0.0.0:0    LISTENING    4242
+//
+// The State column is translated on non-English Windows, so a listener is
+// recognised by its foreign address instead, which only a listening socket
+// leaves at port 0. UDP rows, which have no state, are skipped.
+func parseNetstatOutput(out string) []netstatEntry {
+	var entries []netstatEntry
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 5 || fields[0] != "TCP" || !strings.HasSuffix(fields[2], ":0") {
+			continue
+		}
+		idx := strings.LastIndex(fields[1], ":")
+		if idx < 0 {
+			continue
+		}
+		port, err := strconv.Atoi(fields[1][idx+1:])
+		if err != nil {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[4])
+		if err != nil {
+			continue
+		}
+		entries = append(entries, netstatEntry{Addr: fields[1][:idx], Port: port, PID: pid})
+	}
+	return entries
+}
+
+// parseTasklistOutput maps PIDs to process names from the output of
+// `tasklist /FO CSV /NH`, one quoted row per process:
+//
+//	"node.exe","4242","Console","1","48,512 K"
+//
+// The ".exe" suffix is dropped so names read as they do on other platforms.
+func parseTasklistOutput(out string) map[int]string {
+	r := csv.NewReader(strings.NewReader(out))
+	r.FieldsPerRecord = -1
+	names := make(map[int]string)
+	for {
+		record, err := r.Read()
+		if err != nil {
+			if _, ok := err.(*csv.ParseError); ok {
+				continue
+			}
+			break
+		}
+		if len(record) < 2 {
+			continue
+		}
+		pid, err := strconv.Atoi(record[1])
+		if err != nil {
+			continue
+		}
+		name := record[0]
+		if len(name) > 4 && strings.EqualFold(name[len(name)-4:], ".exe") {
+			name = name[:len(name)-4]
+		}
+		names[pid] = name
+	}
+	return names
+}
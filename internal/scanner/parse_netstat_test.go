@@ -0,0 +1,63 @@
+package scanner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sampleNetstatOutput is `netstat -ano` as Windows prints it, CRLF line
+// endings included.
+var sampleNetstatOutput = strings.ReplaceAll(`
+Active Connections
+
+  Proto  Local Address          Foreign Address        State           PID
+  TCP    0.0.0.0:135            0.0.0.0:0              LISTENING       1024
+  TCP    127.0.0.1:3000         0.0.0.0:0              LISTENING       4242
+  TCP    127.0.0.1:3000         127.0.0.1:52144        ESTABLISHED     4242
+  TCP    192.168.1.20:52311     140.82.112.4:443       ESTABLISHED     7788
+  TCP    [::]:135               [::]:0                 LISTENING       1024
+  TCP    [::1]:5432             [::]:0                 LISTENING       900
+  TCP    [fe80::1c2d:3e4f:5a6b:7c8d%12]:2869  [::]:0   LISTENING       4
+  UDP    0.0.0.0:5353           *:*                                    2100
+  UDP    [::]:5353              *:*                                    2100
+`, "\n", "\r\n")
+
+func TestParseNetstatOutput(t *testing.T) {
+	got := parseNetstatOutput(sampleNetstatOutput)
+	want := []netstatEntry{
+		{Addr: "0.0.0.0", Port: 135, PID: 1024},
+		{Addr: "127.0.0.1", Port: 3000, PID: 4242},
+		{Addr: "[::]", Port: 135, PID: 1024},
+		{Addr: "[::1]", Port: 5432, PID: 900},
+		{Addr: "[fe80::1c2d:3e4f:5a6b:7c8d%12]", Port: 2869, PID: 4},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseNetstatOutput() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseNetstatOutputLocalized(t *testing.T) {
+	// German Windows says ABHÖREN for LISTENING.
+	got := parseNetstatOutput("  TCP    127.0.0.1:8080         0.0.0.0:0              ABHÖREN         5151\r\n")
+	if want := []netstatEntry{{Addr: "127.0.0.1", Port: 8080, PID: 5151}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parseNetstatOutput() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseNetstatOutputEmpty(t *testing.T) {
+	if got := parseNetstatOutput(""); len(got) != 0 {
+		t.Errorf("parseNetstatOutput(\"\") = %+v, want no entries", got)
+	}
+}
+
+func TestParseTasklistOutput(t *testing.T) {
+	out := "\"System\",\"4\",\"Services\",\"0\",\"144 K\"\r\n" +
+		"\"node.exe\",\"4242\",\"Console\",\"1\",\"48,512 K\"\r\n" +
+		"\"POSTGRES.EXE\",\"900\",\"Services\",\"0\",\"12,004 K\"\r\n" +
+		"\"broken\",\"x\"\r\n"
+	want := map[int]string{4: "System", 4242: "node", 900: "POSTGRES"}
+	if got := parseTasklistOutput(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTasklistOutput() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,217 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tcpListen is the hex state code for LISTEN in /proc/net/tcp and
+// /proc/net/tcp6.
+const tcpListen = "0A"
+
+// udpBound is the state /proc/net/udp reports for a bound socket that is not
+// connected to a peer, TCP_CLOSE by the kernel's reuse of the TCP states.
+const udpBound = "07"
+
+// procEntry is one listening socket parsed from /proc/net/tcp, udp, or their
+// IPv6 counterparts.
+type procEntry struct {
+	Addr  string // local address, e.g. "127.0.0.1" or "[::1]"
+	Port  int
+	Inode uint64
+	Proto string // "UDP", or empty for TCP
+}
+
+// parseProcNetTCP extracts listening sockets from the contents of
+// /proc/net/tcp. The header line and malformed rows are skipped.
+func parseProcNetTCP(data []byte) []procEntry {
+	return parseProcNet(data, tcpListen, parseHexIPv4)
+}
+
+// parseProcNetTCP6 is parseProcNetTCP for /proc/net/tcp6, whose addresses
+// are 32 hex characters. They are returned in brackets, as in "[::]".
+func parseProcNetTCP6(data []byte) []procEntry {
+	return parseProcNet(data, tcpListen, parseHexIPv6)
+}
+
+// parseProcNetUDP extracts bound, unconnected sockets from the contents of
+// /proc/net/udp, tagged as UDP. Connected sockets are clients, not servers.
+func parseProcNetUDP(data []byte) []procEntry {
+	return tagUDP(parseProcNet(data, udpBound, parseHexIPv4))
+}
+
+// parseProcNetUDP6 is parseProcNetUDP for /proc/net/udp6.
+func parseProcNetUDP6(data []byte) []procEntry {
+	return tagUDP(parseProcNet(data, udpBound, parseHexIPv6))
+}
+
+func tagUDP(entries []procEntry) []procEntry {
+	for i := range entries {
+		entries[i].Proto = ProtoUDP
+	}
+	return entries
+}
+
+func parseProcNet(data []byte, state string, parseAddr func(string) (string, bool)) []procEntry {
+	var entries []procEntry
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 10 || fields[0] == "sl" {
+			continue
+		}
+		if fields[3] != state {
+			continue
+		}
+		addrHex, portHex, ok := strings.Cut(fields[1], ":")
+		if !ok {
+			continue
+		}
+		addr, ok := parseAddr(addrHex)
+		if !ok {
+			continue
+		}
+		port, err := strconv.ParseUint(portHex, 16, 16)
+		if err != nil {
+			continue
+		}
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, procEntry{Addr: addr, Port: int(port), Inode: inode})
+	}
+	return entries
+}
+
+// parseHexIPv4 decodes the kernel's little-endian hex form of an IPv4
+// address ("0100007F" is 127.0.0.1).
+func parseHexIPv4(h string) (string, bool) {
+	b, err := hex.DecodeString(h)
+	if err != nil || len(b) != 4 {
+		return "", false
+	}
+	return net.IPv4(b[3], b[2], b[1], b[0]).String(), true
+}
+
+// parseHexIPv6 decodes the kernel's hex form of an IPv6 address, four
+// little-endian 32-bit words ("00000000000000000000000001000000" is ::1),
+// and brackets it: "[::1]".
+func parseHexIPv6(h string) (string, bool) {
+	b, err := hex.DecodeString(h)
+	if err != nil || len(b) != 16 {
+		return "", false
+	}
+	var ip [16]byte
+	for w := 0; w < 16; w += 4 {
+		ip[w], ip[w+1], ip[w+2], ip[w+3] = b[w+3], b[w+2], b[w+1], b[w]
+	}
+	return "[" + netip.AddrFrom16(ip).String() + "]", true
+}
+
+// parseSocketInode extracts the inode from a /proc/[pid]/fd symlink target of
+// the form "socket:[12345]".
+func parseSocketInode(link string) (uint64, bool) {
+	rest, ok := strings.CutPrefix(link, "socket:[")
+	if !ok {
+		return 0, false
+	}
+	rest, ok = strings.CutSuffix(rest, "]")
+	if !ok {
+		return 0, false
+	}
+	inode, err := strconv.ParseUint(rest, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return inode, true
+}
+
+// clockTicks is USER_HZ, the unit of /proc/[pid]/stat's times. It is 100 on
+// every Linux architecture Go supports.
+const clockTicks = 100
+
+// parseProcStartTicks extracts starttime, the clock ticks after boot at
+// which the process started, from the contents of /proc/[pid]/stat. The
+// command name in parentheses may contain spaces, so fields are counted
+// from the last ")".
+func parseProcStartTicks(data []byte) (uint64, bool) {
+	i := bytes.LastIndexByte(data, ')')
+	if i < 0 {
+		return 0, false
+	}
+	// The fields after the name start at state, field 3; starttime is 22.
+	fields := strings.Fields(string(data[i+1:]))
+	if len(fields) < 20 {
+		return 0, false
+	}
+	ticks, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return ticks, true
+}
+
+// parseBootTime extracts the boot time, the btime line, from the contents
+// of /proc/stat.
+func parseBootTime(data []byte) (time.Time, bool) {
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		rest, ok := strings.CutPrefix(sc.Text(), "btime ")
+		if !ok {
+			continue
+		}
+		secs, err := strconv.ParseInt(strings.TrimSpace(rest), 10, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.Unix(secs, 0), true
+	}
+	return time.Time{}, false
+}
+
+// unixAcceptCon is __SO_ACCEPTCON in /proc/net/unix's Flags column, set on
+// sockets that are listening.
+const unixAcceptCon = 0x10000
+
+// procUnixEntry is one listening Unix domain socket parsed from
+// /proc/net/unix.
+type procUnixEntry struct {
+	Path  string // filesystem path, or "@name" in the abstract namespace
+	Inode uint64
+}
+
+// parseProcNetUnix extracts listening sockets that have a name from the
+// contents of /proc/net/unix. Unnamed sockets, the header line and
+// malformed rows are skipped. The path is the rest of the line, so it may
+// contain spaces.
+func parseProcNetUnix(data []byte) []procUnixEntry {
+	var entries []procUnixEntry
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 8 || fields[0] == "Num" {
+			continue
+		}
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil || flags&unixAcceptCon == 0 {
+			continue
+		}
+		inode, err := strconv.ParseUint(fields[6], 10, 64)
+		if err != nil {
+			continue
+		}
+		rest := sc.Text()
+		for range 7 {
+			_, rest = nextField(rest)
+		}
+		entries = append(entries, procUnixEntry{Path: strings.TrimSpace(rest), Inode: inode})
+	}
+	return entries
+}
@@ -0,0 +1,177 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+const sampleProcNetTCP = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 0100007F:0BB8 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 41234 1 0000000000000000 100 0 0 10 0
+   1: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 41500 1 0000000000000000 100 0 0 10 0
+   2: 0100007F:0BB8 0100007F:D2F0 01 00000000:00000000 00:00000000 00000000  1000        0 41600 1 0000000000000000 20 4 30 10 -1
+`
+
+func TestParseProcNetTCP(t *testing.T) {
+	got := parseProcNetTCP([]byte(sampleProcNetTCP))
+	want := []procEntry{
+		{Addr: "127.0.0.1", Port: 3000, Inode: 41234},
+		{Addr: "0.0.0.0", Port: 8080, Inode: 41500},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseProcNetTCP() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseProcNetTCPSkipsMalformed(t *testing.T) {
+	data := []byte(`  sl  local_address rem_address   st
+   0: 0100007F:ZZZZ 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 1 1
+   1: garbage
+`)
+	if got := parseProcNetTCP(data); len(got) != 0 {
+		t.Errorf("parseProcNetTCP() = %+v, want no entries", got)
+	}
+}
+
+func TestParseProcNetTCPEmpty(t *testing.T) {
+	if got := parseProcNetTCP(nil); len(got) != 0 {
+		t.Errorf("parseProcNetTCP(nil) = %+v, want no entries", got)
+	}
+}
+
+const sampleProcNetTCP6 = `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000000000000:1F90 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 52311 1 0000000000000000 100 0 0 10 0
+   1: 00000000000000000000000001000000:0BB8 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 52400 1 0000000000000000 100 0 0 10 0
+   2: 0000000000000000FFFF00000100007F:1538 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000   999        0 52455 1 0000000000000000 100 0 0 10 0
+   3: 00000000000000000000000001000000:0BB8 00000000000000000000000001000000:C35A 01 00000000:00000000 02:000A7D3B 00000000  1000        0 52501 2 0000000000000000 20 4 30 10 -1
+`
+
+func TestParseProcNetTCP6(t *testing.T) {
+	got := parseProcNetTCP6([]byte(sampleProcNetTCP6))
+	want := []procEntry{
+		{Addr: "[::]", Port: 8080, Inode: 52311},
+		{Addr: "[::1]", Port: 3000, Inode: 52400},
+		{Addr: "[::ffff:127.0.0.1]", Port: 5432, Inode: 52455},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseProcNetTCP6() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseProcNetTCP6SkipsMalformed(t *testing.T) {
+	data := []byte(`  sl  local_address                         remote_address                        st
+   0: 0100007F:0BB8 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 1 1
+   1: 000000000000000000000000010000ZZ:0BB8 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 2 1
+   2: garbage
+`)
+	if got := parseProcNetTCP6(data); len(got) != 0 {
+		t.Errorf("parseProcNetTCP6() = %+v, want no entries", got)
+	}
+}
+
+func TestParseProcNetTCP6Empty(t *testing.T) {
+	if got := parseProcNetTCP6(nil); len(got) != 0 {
+		t.Errorf("parseProcNetTCP6(nil) = %+v, want no entries", got)
+	}
+}
+
+func TestParseProcNetUDP(t *testing.T) {
+	// DNS stub resolver bound on 127.0.0.53:53, then a connected client
+	// socket, which is not a server.
+	data := []byte(`   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  123: 3500007F:0035 00000000:0000 07 00000000:00000000 00:00000000 00000000   101        0 21345 2 0000000000000000 0
+  456: 0100007F:D431 0100007F:0035 01 00000000:00000000 00:00000000 00000000  1000        0 88123 2 0000000000000000 0
+`)
+	want := []procEntry{{Addr: "127.0.0.53", Port: 53, Inode: 21345, Proto: "UDP"}}
+	if got := parseProcNetUDP(data); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseProcNetUDP() = %+v, want %+v", got, want)
+	}
+
+	data6 := []byte(`   sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  789: 00000000000000000000000000000000:14E9 00000000000000000000000000000000:0000 07 00000000:00000000 00:00000000 00000000   107        0 19876 2 0000000000000000 0
+`)
+	want = []procEntry{{Addr: "[::]", Port: 5353, Inode: 19876, Proto: "UDP"}}
+	if got := parseProcNetUDP6(data6); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseProcNetUDP6() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseHexIPv4(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"0100007F", "127.0.0.1", true},
+		{"00000000", "0.0.0.0", true},
+		{"0101A8C0", "192.168.1.1", true},
+		{"0100007", "", false},
+		{"zz00007F", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := parseHexIPv4(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseHexIPv4(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseSocketInode(t *testing.T) {
+	tests := []struct {
+		link  string
+		inode uint64
+		ok    bool
+	}{
+		{"socket:[41234]", 41234, true},
+		{"pipe:[1234]", 0, false},
+		{"/dev/null", 0, false},
+		{"socket:[abc]", 0, false},
+	}
+	for _, tt := range tests {
+		inode, ok := parseSocketInode(tt.link)
+		if inode != tt.inode || ok != tt.ok {
+			t.Errorf("parseSocketInode(%q) = %d, %v; want %d, %v", tt.link, inode, ok, tt.inode, tt.ok)
+		}
+	}
+}
+
+func TestParseProcStartTicks(t *testing.T) {
+	// The command name may itself contain spaces and parentheses.
+	stat := "4242 (my (odd) srv) S 1 4242 4242 0 -1 4194560 1234 0 0 0 12 3 0 0 20 0 1 0 987654 1048576 512 18446744073709551615\n"
+	if got, ok := parseProcStartTicks([]byte(stat)); !ok || got != 987654 {
+		t.Errorf("parseProcStartTicks() = %d, %v; want 987654", got, ok)
+	}
+	if _, ok := parseProcStartTicks([]byte("4242 (node) S 1 2")); ok {
+		t.Error("a truncated stat line should not parse")
+	}
+}
+
+func TestParseBootTime(t *testing.T) {
+	data := "cpu  10 0 20 300 0 0 0 0 0 0\nintr 1 2 3\nbtime 1717228800\nprocesses 999\n"
+	if got, ok := parseBootTime([]byte(data)); !ok || got.Unix() != 1717228800 {
+		t.Errorf("parseBootTime() = %v, %v; want 1717228800", got.Unix(), ok)
+	}
+	if _, ok := parseBootTime([]byte("cpu 1 2 3\n")); ok {
+		t.Error("parseBootTime() without a btime line should fail")
+	}
+}
+
+func TestParseProcNetUnix(t *testing.T) {
+	data := []byte(`Num       RefCount Protocol Flags    Type St Inode Path
+000000002c8f6326: 00000002 00000000 00010000 0001 01  1138 /var/run/app.sock
+00000000c2f87a41: 00000003 00000000 00000000 0001 03 258783 /var/run/app.sock
+00000000acc7ee2f: 00000003 00000000 00000000 0001 03 258784
+000000009e1d2f10: 00000002 00000000 00010000 0001 01 40467 @/tmp/.X11-unix/X0
+0000000011aa22bb: 00000002 00000000 00010000 0005 01 51234 /run/my app/ctl.sock
+00000000deadbeef: 00000002 00000000 00010000 0001 01 60001
+00000000cafef00d: garbage
+`)
+	got := parseProcNetUnix(data)
+	want := []procUnixEntry{
+		{Path: "/var/run/app.sock", Inode: 1138},
+		{Path: "@/tmp/.X11-unix/X0", Inode: 40467},
+		{Path: "/run/my app/ctl.sock", Inode: 51234},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseProcNetUnix() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,67 @@
+package scanner
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parsePsLine splits a line of `ps -o comm=,args=` output. On macOS comm is
+// the executable path, so it is the first whitespace-separated token.
+func parsePsLine(line string) (comm, args string, ok bool) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return "", "", false
+	}
+	comm, args, _ = strings.Cut(line, " ")
+	return comm, strings.TrimSpace(args), true
+}
+
+// psStartLayout is the fixed format of ps's lstart column, e.g.
+// "Mon Oct  7 09:30:00 2024", once its padding is collapsed.
+const psStartLayout = "Mon Jan 2 15:04:05 2006"
+
+// parsePsStartLine splits a line of `ps -o lstart=,comm=,args=` output: the
+// five-word start time in local time, then comm and args as parsePsLine
+// reads them.
+func parsePsStartLine(line string) (start time.Time, comm, args string, ok bool) {
+	rest := strings.TrimSpace(line)
+	words := make([]string, 0, 5)
+	for range 5 {
+		var word string
+		word, rest, _ = strings.Cut(rest, " ")
+		words = append(words, word)
+		rest = strings.TrimLeft(rest, " ")
+	}
+	start, err := time.ParseInLocation(psStartLayout, strings.Join(words, " "), time.Local)
+	if err != nil {
+		return time.Time{}, "", "", false
+	}
+	comm, args, ok = parsePsLine(rest)
+	return start, comm, args, ok
+}
+
+// psInfo is what ps reports about one process.
+type psInfo struct {
+	Start time.Time
+	Comm  string // executable path on macOS
+	Args  string
+}
+
+// parsePsOutput maps each PID to its details from the output of
+// `ps -p PID,PID,... -o pid=,lstart=,comm=,args=`: the PID, then the rest as
+// parsePsStartLine reads it. Lines that do not parse are skipped.
+func parsePsOutput(out string) map[int]psInfo {
+	infos := make(map[int]psInfo)
+	for _, line := range strings.Split(out, "\n") {
+		pidField, rest, _ := strings.Cut(strings.TrimSpace(line), " ")
+		pid, err := strconv.Atoi(pidField)
+		if err != nil {
+			continue
+		}
+		if start, comm, args, ok := parsePsStartLine(rest); ok {
+			infos[pid] = psInfo{Start: start, Comm: comm, Args: args}
+		}
+	}
+	return infos
+}
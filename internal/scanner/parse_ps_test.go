@@ -0,0 +1,53 @@
+package scanner
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePsLine(t *testing.T) {
+	tests := []struct {
+		line       string
+		comm, args string
+		ok         bool
+	}{
+		{"/usr/local/bin/node node server.js\n", "/usr/local/bin/node", "node server.js", true},
+		{"/opt/homebrew/opt/postgresql@16/bin/postgres   postgres -D /data", "/opt/homebrew/opt/postgresql@16/bin/postgres", "postgres -D /data", true},
+		{"launchd", "launchd", "", true},
+		{"  \n", "", "", false},
+	}
+	for _, tt := range tests {
+		comm, args, ok := parsePsLine(tt.line)
+		if comm != tt.comm || args != tt.args || ok != tt.ok {
+			t.Errorf("parsePsLine(%q) = %q, %q, %v; want %q, %q, %v", tt.line, comm, args, ok, tt.comm, tt.args, tt.ok)
+		}
+	}
+}
+
+func TestParsePsStartLine(t *testing.T) {
+	start, comm, args, ok := parsePsStartLine("Mon Oct  7 09:30:05 2024     /usr/local/bin/node node server.js\n")
+	want := time.Date(2024, 10, 7, 9, 30, 5, 0, time.Local)
+	if !ok || !start.Equal(want) || comm != "/usr/local/bin/node" || args != "node server.js" {
+		t.Errorf("parsePsStartLine() = %v, %q, %q, %v", start, comm, args, ok)
+	}
+	if _, _, _, ok := parsePsStartLine("/usr/local/bin/node node server.js"); ok {
+		t.Error("a line without a start time should not parse")
+	}
+}
+
+func TestParsePsOutput(t *testing.T) {
+	out := "  901 Mon Oct  7 09:30:05 2024     /opt/homebrew/bin/postgres postgres -D /data\n" +
+		"12345 Tue Oct  8 14:02:11 2024     /usr/local/bin/node node server.js\n" +
+		"  777 not a start time\n"
+	got := parsePsOutput(out)
+	if len(got) != 2 {
+		t.Fatalf("parsePsOutput() = %+v, want two processes", got)
+	}
+	pg := got[901]
+	if want := time.Date(2024, 10, 7, 9, 30, 5, 0, time.Local); !pg.Start.Equal(want) || pg.Comm != "/opt/homebrew/bin/postgres" || pg.Args != "postgres -D /data" {
+		t.Errorf("PID 901 = %+v", pg)
+	}
+	if node := got[12345]; node.Comm != "/usr/local/bin/node" || node.Args != "node server.js" {
+		t.Errorf("PID 12345 = %+v", node)
+	}
+}
@@ -0,0 +1,112 @@
+package scanner
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// parseSSOutput maps listening ports to the PIDs holding them from the
+// output of `ss -tlnp`. A port can have several owners when sockets are bound
+// with SO_REUSEPORT; PIDs are listed in the order ss reports them, without
+// repeats. Rows without process information (sockets owned by other users
+// when not running as root) are skipped.
+func parseSSOutput(out string) map[int][]int {
+	// Busy hosts list thousands of sockets, so this walks each line in
+	// place rather than splitting it into fields and joining them back.
+	pids := make(map[int][]int, strings.Count(out, "\n"))
+	for line := range strings.Lines(out) {
+		state, rest := nextField(line)
+		if state != "LISTEN" {
+			continue
+		}
+		_, rest = nextField(rest) // Recv-Q
+		_, rest = nextField(rest) // Send-Q
+		local, rest := nextField(rest)
+		_, rest = nextField(rest) // peer
+		if process, _ := nextField(rest); process == "" {
+			continue
+		}
+		idx := strings.LastIndexByte(local, ':')
+		if idx < 0 {
+			continue
+		}
+		port, err := strconv.Atoi(local[idx+1:])
+		if err != nil {
+			continue
+		}
+		for {
+			start := strings.Index(rest, "pid=")
+			if start < 0 {
+				break
+			}
+			rest = rest[start+len("pid="):]
+			end := 0
+			for end < len(rest) && rest[end] >= '0' && rest[end] <= '9' {
+				end++
+			}
+			pid, err := strconv.Atoi(rest[:end])
+			rest = rest[end:]
+			if err != nil {
+				continue
+			}
+			if !slices.Contains(pids[port], pid) {
+				pids[port] = append(pids[port], pid)
+			}
+		}
+	}
+	return pids
+}
+
+// nextField returns the first whitespace-separated field of s and what
+// follows it, or "" when s holds nothing but whitespace.
+func nextField(s string) (field, rest string) {
+	start := 0
+	for start < len(s) && isSpace(s[start]) {
+		start++
+	}
+	end := start
+	for end < len(s) && !isSpace(s[end]) {
+		end++
+	}
+	return s[start:end], s[end:]
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
+
+// ssQueue is a listening socket's accept queue as ss reports it: Recv-Q is
+// the connections waiting to be accepted, Send-Q the backlog limit.
+type ssQueue struct {
+	backlog int
+	max     int
+}
+
+// parseSSQueues maps listening ports to their accept queues from the output
+// of `ss -tln` or `ss -tlnp`. When several sockets share a port, the one
+// with the longest queue is kept.
+func parseSSQueues(out string) map[int]ssQueue {
+	queues := make(map[int]ssQueue)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[0] != "LISTEN" {
+			continue
+		}
+		local := fields[3]
+		idx := strings.LastIndex(local, ":")
+		if idx < 0 {
+			continue
+		}
+		port, err1 := strconv.Atoi(local[idx+1:])
+		recv, err2 := strconv.Atoi(fields[1])
+		send, err3 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil || err3 != nil {
+			continue
+		}
+		if q, seen := queues[port]; !seen || recv > q.backlog {
+			queues[port] = ssQueue{backlog: recv, max: send}
+		}
+	}
+	return queues
+}
@@ -0,0 +1,108 @@
+package scanner
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sampleSSOutput = `State  Recv-Q Send-Q Local Address:Port  Peer Address:Port Process
+LISTEN 0      511        127.0.0.1:3000       0.0.0.0:*     users:(("node",pid=4242,fd=21))
+LISTEN 0      4096         0.0.0.0:8080       0.0.0.0:*     users:(("api",pid=5151,fd=3),("api",pid=5151,fd=4))
+LISTEN 0      128          0.0.0.0:22         0.0.0.0:*
+LISTEN 0      244            [::1]:5432          [::]:*     users:(("postgres",pid=900,fd=7))
+`
+
+func TestParseSSOutput(t *testing.T) {
+	got := parseSSOutput(sampleSSOutput)
+	want := map[int][]int{3000: {4242}, 8080: {5151}, 5432: {900}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseSSOutput() = %v, want %v", got, want)
+	}
+}
+
+func TestParseSSOutputEmpty(t *testing.T) {
+	if got := parseSSOutput(""); len(got) != 0 {
+		t.Errorf("parseSSOutput(\"\") = %v, want empty", got)
+	}
+}
+
+func TestParseSSOutputReusePort(t *testing.T) {
+	out := `State  Recv-Q Send-Q Local Address:Port  Peer Address:Port Process
+LISTEN 0      1024       0.0.0.0:8080       0.0.0.0:*     users:(("worker",pid=7001,fd=5))
+LISTEN 0      1024       0.0.0.0:8080       0.0.0.0:*     users:(("worker",pid=7002,fd=5))
+LISTEN 0      1024       0.0.0.0:9090       0.0.0.0:*     users:(("nginx",pid=8001,fd=6),("nginx",pid=8002,fd=6),("nginx",pid=8001,fd=7))
+`
+	got := parseSSOutput(out)
+	want := map[int][]int{8080: {7001, 7002}, 9090: {8001, 8002}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseSSOutput() = %v, want %v", got, want)
+	}
+}
+
+// ss filtered with "sport = :PORT" prints the header and at most the one
+// listener, which ResolvePort reads with the same parser.
+func TestParseSSOutputSinglePort(t *testing.T) {
+	out := `State  Recv-Q Send-Q Local Address:Port  Peer Address:Port Process
+LISTEN 0      511          0.0.0.0:9229       0.0.0.0:*     users:(("node",pid=777,fd=18))
+`
+	if got := parseSSOutput(out)[9229]; !reflect.DeepEqual(got, []int{777}) {
+		t.Errorf("parseSSOutput()[9229] = %v, want [777]", got)
+	}
+	headerOnly := "State  Recv-Q Send-Q Local Address:Port  Peer Address:Port Process\n"
+	if got := parseSSOutput(headerOnly)[9229]; got != nil {
+		t.Errorf("header-only output should resolve nothing, got %v", got)
+	}
+}
+
+func TestParseSSQueues(t *testing.T) {
+	out := `State  Recv-Q Send-Q Local Address:Port  Peer Address:Port Process
+LISTEN 0      511        127.0.0.1:3000       0.0.0.0:*     users:(("node",pid=4242,fd=21))
+LISTEN 97     128          0.0.0.0:8080       0.0.0.0:*
+LISTEN 3      128             [::]:8080          [::]:*
+LISTEN x      128          0.0.0.0:9000       0.0.0.0:*
+`
+	got := parseSSQueues(out)
+	want := map[int]ssQueue{3000: {backlog: 0, max: 511}, 8080: {backlog: 97, max: 128}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseSSQueues() = %+v, want %+v", got, want)
+	}
+}
+
+// largeSSOutput builds ss output for n listeners, most with two sockets
+// owned by one process, like a busy host running many workers.
+func largeSSOutput(n int) string {
+	var b strings.Builder
+	b.WriteString("State  Recv-Q Send-Q Local Address:Port  Peer Address:Port Process\n")
+	for i := range n {
+		port, pid := 10000+i, 20000+i
+		switch i % 4 {
+		case 0:
+			fmt.Fprintf(&b, "LISTEN 0      128          0.0.0.0:%d         0.0.0.0:*\n", port)
+		default:
+			fmt.Fprintf(&b, "LISTEN 0      4096         0.0.0.0:%d       0.0.0.0:*     users:((\"worker\",pid=%d,fd=3),(\"worker\",pid=%d,fd=4))\n", port, pid, pid)
+		}
+	}
+	return b.String()
+}
+
+func TestParseSSOutputLarge(t *testing.T) {
+	got := parseSSOutput(largeSSOutput(400))
+	if len(got) != 300 {
+		t.Fatalf("parsed %d ports, want 300 (one in four has no process)", len(got))
+	}
+	for port, pids := range got {
+		if want := []int{port + 10000}; !reflect.DeepEqual(pids, want) {
+			t.Errorf("port %d: pids = %v, want %v", port, pids, want)
+		}
+	}
+}
+
+func BenchmarkParseSSOutput(b *testing.B) {
+	out := largeSSOutput(5000)
+	b.ReportAllocs()
+	for b.Loop() {
+		parseSSOutput(out)
+	}
+}
@@ -0,0 +1,415 @@
+// Package scanner discovers TCP servers listening on localhost, and on Linux
+// bound UDP sockets, and resolves the processes that own them.
+package scanner
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server describes a single listening socket and the process that owns it.
+type Server struct {
+	Port    int    `json:"port"`               // TCP port number
+	Addr    string `json:"addr,omitempty"`     // Bind address (e.g. "127.0.0.1", "0.0.0.0", "*", "[::1]")
+	PID     int    `json:"pid"`                // OS process ID, 0 if it could not be resolved
+	PIDs    []int  `json:"pids,omitempty"`     // Every PID listening on Port (SO_REUSEPORT); PIDs[0] == PID
+	Process string `json:"process"`            // Short process name (e.g. "node", "python3")
+	Command string `json:"command"`            // Full command line (e.g. "node server.js")
+	ExePath string `json:"exe_path,omitempty"` // Resolved executable path, empty if it could not be read
+	State   string `json:"state"`              // TCP state, typically "LISTEN"
+	Label   string `json:"label,omitempty"`    // User-assigned label from config
+	Healthy bool   `json:"healthy"`            // True if the port accepts a TCP connection
+
+	// Addrs lists every bind address when rows for the same port and PID
+	// have been merged; Addr is then the first of them.
+	Addrs []string `json:"addrs,omitempty"`
+
+	// DisplayName is Process tidied by NormalizeProcessName, set when it
+	// differs; Name picks whichever applies.
+	DisplayName string `json:"display_name,omitempty"`
+
+	// Protocol is "UDP" for a bound UDP socket, which Options.UDP adds to
+	// the scan; empty means TCP. Proto reads it with the default applied.
+	Protocol string `json:"protocol,omitempty"`
+
+	// SocketPath is set, and Port is 0, for a Unix domain socket, which
+	// Options.Unix adds to the scan.
+	SocketPath string `json:"socket_path,omitempty"`
+
+	// Accept queue of the listening socket; both are 0 when unknown. Only
+	// the Linux scanner fills them in.
+	Backlog    int `json:"backlog,omitempty"`     // Connections waiting to be accepted
+	MaxBacklog int `json:"max_backlog,omitempty"` // Listen backlog limit
+
+	// StartTime is when the owning process started, zero if unknown.
+	StartTime time.Time `json:"start_time,omitzero"`
+
+	// PIDStale is set when PIDs came from an earlier scan's lookup because
+	// the current one failed, so the owner may since have changed.
+	PIDStale bool `json:"pid_stale,omitempty"`
+}
+
+// Protocol values other than the default TCP.
+const ProtoUDP = "UDP"
+
+// Proto is the server's protocol: "TCP", "UDP", or "unix" for a Unix domain
+// socket.
+func (s Server) Proto() string {
+	if s.IsSocket() {
+		return "unix"
+	}
+	return cmp.Or(s.Protocol, "TCP")
+}
+
+// AllPIDs returns every PID listening on the server's port, falling back to
+// PID alone when PIDs was not filled in.
+func (s Server) AllPIDs() []int {
+	if len(s.PIDs) > 0 {
+		return s.PIDs
+	}
+	if s.PID > 0 {
+		return []int{s.PID}
+	}
+	return nil
+}
+
+// IsSocket reports whether s is a Unix domain socket rather than a TCP
+// port.
+func (s Server) IsSocket() bool {
+	return s.SocketPath != ""
+}
+
+// BindAddrs returns every address the server is bound to: Addrs for a
+// merged server, otherwise Addr alone.
+func (s Server) BindAddrs() []string {
+	if len(s.Addrs) > 0 {
+		return s.Addrs
+	}
+	return []string{s.Addr}
+}
+
+// Exposed reports whether the server is bound to an address reachable from
+// other machines, i.e. anything but loopback. An unknown address is not
+// counted as exposed. A merged server is exposed if any of its Addrs is.
+func (s Server) Exposed() bool {
+	if len(s.Addrs) > 0 {
+		return slices.ContainsFunc(s.Addrs, exposedAddr)
+	}
+	return exposedAddr(s.Addr)
+}
+
+// IsIPv4 reports whether the server listens on an IPv4 address. A merged
+// dual-stack server is both IPv4 and IPv6, as is lsof's "*" wildcard. Unix
+// sockets and unknown addresses are neither.
+func (s Server) IsIPv4() bool {
+	return !s.IsSocket() && slices.ContainsFunc(s.BindAddrs(), func(addr string) bool {
+		return addr == "*" || addr != "" && !ipv6Addr(addr)
+	})
+}
+
+// IsIPv6 reports whether the server listens on an IPv6 address; see IsIPv4.
+func (s Server) IsIPv6() bool {
+	return !s.IsSocket() && slices.ContainsFunc(s.BindAddrs(), func(addr string) bool {
+		return addr == "*" || ipv6Addr(addr)
+	})
+}
+
+func exposedAddr(addr string) bool {
+	addr = strings.Trim(addr, "[]")
+	if addr == "" || addr == "localhost" {
+		return false
+	}
+	if addr == "*" {
+		return true
+	}
+	ip := net.ParseIP(addr)
+	return ip != nil && !ip.IsLoopback()
+}
+
+// Scanner discovers listening servers. Implementations are platform-specific;
+// callers obtain one with New. Scan may return servers together with
+// ErrDegraded, which callers should treat as a warning rather than a failure.
+type Scanner interface {
+	Scan(ctx context.Context) ([]Server, error)
+}
+
+// PortResolver is implemented by scanners that can look up the owner of a
+// single port without a full scan, for rows whose PID the last scan missed.
+type PortResolver interface {
+	// ResolvePort returns the process details for port under proto, a
+	// Server.Proto value, with only Protocol and the process fields (PID,
+	// PIDs, Process, Command, ExePath) filled in. It returns ErrUnresolved
+	// if no owner can be found.
+	ResolvePort(ctx context.Context, proto string, port int) (Server, error)
+}
+
+// ExcludedCounter is implemented by scanners that count the listeners their
+// port range or allow-list leaves out, so callers can say how many exist
+// beyond it without scanning every port.
+type ExcludedCounter interface {
+	// Excluded returns how many listening ports the last Scan left out.
+	Excluded() int
+}
+
+// ErrUnresolved reports that no owning process was found for a port.
+var ErrUnresolved = errors.New("owning process not found")
+
+// ErrDegraded is returned by Scan together with the servers it found when no
+// owning process could be resolved for any of them, typically because ss is
+// missing or portview cannot see other users' sockets. The port list itself
+// is still accurate.
+var ErrDegraded = errors.New("process info unavailable — run with ss installed or elevated")
+
+// degraded returns ErrDegraded if servers is non-empty and none of them has
+// a known PID.
+func degraded(servers []Server) error {
+	for _, s := range servers {
+		if s.PID > 0 {
+			return nil
+		}
+	}
+	if len(servers) == 0 {
+		return nil
+	}
+	return ErrDegraded
+}
+
+// Options configures a platform scanner.
+type Options struct {
+	MinPort int // lowest port reported, inclusive
+	MaxPort int // highest port reported, inclusive
+	// Ports, if set, is an allow-list reported in place of the range.
+	Ports []int
+	// Unix adds listening Unix domain sockets with a path, which no port
+	// range or allow-list applies to.
+	Unix bool
+	// UDP adds bound, unconnected UDP sockets. Only the Linux scanner
+	// supports it.
+	UDP bool
+	// HealthTimeout bounds each health-check dial; zero means one second.
+	HealthTimeout time.Duration
+	// LsofPath and LsofArgs replace "lsof" and its listening-socket
+	// arguments on macOS, for machines where lsof lives elsewhere or needs
+	// other flags. Whatever the arguments, lsof must print its default
+	// columns with numeric addresses, as -nP does.
+	LsofPath string
+	LsofArgs []string
+}
+
+// defaultLsofArgs list the listening TCP sockets, with numeric hosts and
+// ports.
+var defaultLsofArgs = []string{"-iTCP", "-sTCP:LISTEN", "-nP"}
+
+// lsof is the lsof binary to run.
+func (o Options) lsof() string {
+	return cmp.Or(o.LsofPath, "lsof")
+}
+
+// lsofListenArgs are the lsof arguments for a full scan.
+func (o Options) lsofListenArgs() []string {
+	if len(o.LsofArgs) > 0 {
+		return o.LsofArgs
+	}
+	return defaultLsofArgs
+}
+
+// wants reports whether a server on port belongs in the scan result.
+func (o Options) wants(port int) bool {
+	if len(o.Ports) > 0 {
+		return slices.Contains(o.Ports, port)
+	}
+	return port >= o.MinPort && port <= o.MaxPort
+}
+
+// defaultHealthTimeout bounds each health-check dial made during a scan
+// when Options.HealthTimeout is unset.
+const defaultHealthTimeout = time.Second
+
+func (o Options) healthTimeout() time.Duration {
+	return cmp.Or(o.HealthTimeout, defaultHealthTimeout)
+}
+
+// checkAll runs CheckHealth for every server concurrently, each dial bounded
+// by timeout, and records the result in Healthy.
+func checkAll(ctx context.Context, servers []Server, timeout time.Duration) {
+	var wg sync.WaitGroup
+	for i := range servers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if path := servers[i].SocketPath; path != "" {
+				servers[i].Healthy = CheckSocketHealth(ctx, path, timeout)
+				return
+			}
+			// UDP has no handshake to probe; a bound socket is as healthy
+			// as it can be shown to be.
+			if servers[i].Protocol == ProtoUDP {
+				servers[i].Healthy = true
+				return
+			}
+			servers[i].Healthy = CheckHealth(ctx, servers[i].Port, timeout)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// sortByPort orders servers by port, with Unix sockets after every port in
+// path order.
+func sortByPort(servers []Server) {
+	sort.SliceStable(servers, func(i, j int) bool {
+		a, b := servers[i], servers[j]
+		if a.IsSocket() != b.IsSocket() {
+			return b.IsSocket()
+		}
+		if a.IsSocket() {
+			return a.SocketPath < b.SocketPath
+		}
+		return a.Port < b.Port
+	})
+}
+
+// mergeDuplicates folds rows that describe the same listener, as happens when
+// two sources report one socket with different completeness: rows with the
+// same address and port, and unresolved rows (no PID) on a port another row
+// has resolved. The row with a PID is kept and takes any details it lacks;
+// the addresses it absorbed are recorded in Addrs. A dual-stack server, with
+// an IPv4 and an IPv6 row owned by the same PIDs, is folded the same way.
+// Other resolved rows on different addresses stay separate.
+func mergeDuplicates(servers []Server) []Server {
+	type bind struct {
+		addr  string
+		port  int
+		proto string
+	}
+	index := make(map[bind]int)
+	var out []Server
+	for _, s := range servers {
+		key := bind{s.Addr, s.Port, s.Protocol}
+		i, seen := index[key]
+		if !seen {
+			index[key] = len(out)
+			out = append(out, s)
+			continue
+		}
+		out[i] = mergeServer(out[i], s)
+	}
+	out = mergeDualStack(out)
+
+	resolved := make(map[protoPort]bool)
+	for _, s := range out {
+		if s.PID > 0 {
+			resolved[s.protoPort()] = true
+		}
+	}
+	merged := make([]Server, 0, len(out))
+	owner := make(map[protoPort]int)           // port → its first resolved row in merged
+	unresolved := make(map[protoPort][]Server) // port → rows to fold into that row
+	for _, s := range out {
+		if s.PID == 0 && resolved[s.protoPort()] {
+			unresolved[s.protoPort()] = append(unresolved[s.protoPort()], s)
+			continue
+		}
+		if _, ok := owner[s.protoPort()]; !ok && s.PID > 0 {
+			owner[s.protoPort()] = len(merged)
+		}
+		merged = append(merged, s)
+	}
+	for port, rows := range unresolved {
+		o := &merged[owner[port]]
+		addrs := slices.Clone(o.BindAddrs())
+		for _, u := range rows {
+			o.Healthy = o.Healthy || u.Healthy
+			for _, addr := range u.BindAddrs() {
+				if !slices.Contains(addrs, addr) {
+					addrs = append(addrs, addr)
+				}
+			}
+		}
+		o.Addrs = addrs
+	}
+	return merged
+}
+
+// protoPort is a port number together with its protocol, since TCP and UDP
+// port 53 are different listeners.
+type protoPort struct {
+	port  int
+	proto string
+}
+
+func (s Server) protoPort() protoPort {
+	return protoPort{s.Port, s.Protocol}
+}
+
+// mergeDualStack folds each IPv6 row into the IPv4 row on the same port with
+// the same owners, or the other way round, recording both addresses.
+func mergeDualStack(servers []Server) []Server {
+	type owner struct {
+		port protoPort
+		pids string
+	}
+	index := make(map[owner]int)
+	var out []Server
+	for _, s := range servers {
+		if len(s.PIDs) == 0 {
+			out = append(out, s)
+			continue
+		}
+		key := owner{s.protoPort(), fmt.Sprint(slices.Sorted(slices.Values(s.PIDs)))}
+		i, seen := index[key]
+		if !seen || ipv6Addr(out[i].Addr) == ipv6Addr(s.Addr) {
+			index[key] = len(out)
+			out = append(out, s)
+			continue
+		}
+		addrs := slices.Clone(out[i].BindAddrs())
+		for _, addr := range s.BindAddrs() {
+			if !slices.Contains(addrs, addr) {
+				addrs = append(addrs, addr)
+			}
+		}
+		out[i] = mergeServer(out[i], s)
+		out[i].Addrs = addrs
+	}
+	return out
+}
+
+// ipv6Addr reports whether addr, as a scan records it, is an IPv6 address
+// such as "[::]".
+func ipv6Addr(addr string) bool {
+	return strings.Contains(addr, ":")
+}
+
+// mergeServer combines two reports of one socket, preferring a's fields and
+// filling the ones it lacks from b.
+func mergeServer(a, b Server) Server {
+	if a.PID == 0 && b.PID > 0 {
+		a, b = b, a
+	}
+	a.PIDs = slices.Clone(a.PIDs)
+	for _, pid := range b.PIDs {
+		if !slices.Contains(a.PIDs, pid) {
+			a.PIDs = append(a.PIDs, pid)
+		}
+	}
+	if a.Process == "" {
+		a.Process, a.Command = b.Process, b.Command
+	}
+	if a.ExePath == "" {
+		a.ExePath = b.ExePath
+	}
+	if a.StartTime.IsZero() {
+		a.StartTime = b.StartTime
+	}
+	a.PIDStale = a.PIDStale || b.PIDStale
+	a.Healthy = a.Healthy || b.Healthy
+	return a
+}
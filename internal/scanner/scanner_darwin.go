@@ -0,0 +1,177 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type darwinScanner struct {
+	opts Options
+	run  func(ctx context.Context, name string, args ...string) ([]byte, error) // runCommand; faked in tests
+
+	mu       sync.Mutex
+	excluded int // ports the last Scan left out, for Excluded
+}
+
+// New returns the macOS scanner, which shells out to lsof for listening
+// sockets and ps for process details.
+func New(opts Options) Scanner {
+	return &darwinScanner{opts: opts, run: runCommand}
+}
+
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).Output()
+}
+
+func (s *darwinScanner) Scan(ctx context.Context) ([]Server, error) {
+	out, err := s.run(ctx, s.opts.lsof(), s.opts.lsofListenArgs()...)
+	if err != nil {
+		// lsof exits 1 when nothing matches.
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && len(out) == 0 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to run lsof: %w", err)
+	}
+
+	// lsof prints a line per socket; sockets sharing an address and port
+	// (SO_REUSEPORT) are one server with several owners.
+	type bind struct {
+		addr string
+		port int
+	}
+	index := make(map[bind]int)
+	var servers []Server
+	skipped := make(map[int]bool)
+	for _, e := range parseLsofOutput(string(out)) {
+		if !s.opts.wants(e.Port) {
+			skipped[e.Port] = true
+			continue
+		}
+		key := bind{e.Addr, e.Port}
+		if i, seen := index[key]; seen {
+			if !slices.Contains(servers[i].PIDs, e.PID) {
+				servers[i].PIDs = append(servers[i].PIDs, e.PID)
+			}
+			continue
+		}
+		index[key] = len(servers)
+		servers = append(servers, Server{Port: e.Port, Addr: e.Addr, PID: e.PID, PIDs: []int{e.PID}, Process: e.Command, State: "LISTEN"})
+	}
+	s.mu.Lock()
+	s.excluded = len(skipped)
+	s.mu.Unlock()
+
+	if s.opts.Unix {
+		servers = append(servers, s.unixServers(ctx)...)
+	}
+	s.fillProcesses(ctx, servers)
+
+	checkAll(ctx, servers, s.opts.healthTimeout())
+	sortByPort(servers)
+	return servers, nil
+}
+
+// Excluded returns how many listening ports the last Scan left out of its
+// range or allow-list.
+func (s *darwinScanner) Excluded() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.excluded
+}
+
+// unixServers lists the Unix domain sockets lsof reports with a path. lsof
+// does not mark which are listening, so a path counts once, owned by the
+// first process seen with it.
+func (s *darwinScanner) unixServers(ctx context.Context) []Server {
+	out, err := s.run(ctx, s.opts.lsof(), "-U", "-nP")
+	if err != nil && len(out) == 0 {
+		return nil
+	}
+	var servers []Server
+	for _, e := range parseLsofUnix(string(out)) {
+		servers = append(servers, Server{SocketPath: e.Path, PID: e.PID, PIDs: []int{e.PID}, Process: e.Command, State: "LISTEN"})
+	}
+	return servers
+}
+
+// ResolvePort asks lsof about a TCP port alone. UDP is not scanned on macOS,
+// so it has no owners to find.
+func (s *darwinScanner) ResolvePort(ctx context.Context, proto string, port int) (Server, error) {
+	if proto == ProtoUDP {
+		return Server{}, ErrUnresolved
+	}
+	out, err := s.run(ctx, s.opts.lsof(), "-iTCP:"+strconv.Itoa(port), "-sTCP:LISTEN", "-nP")
+	if err != nil && len(out) == 0 {
+		return Server{}, ErrUnresolved
+	}
+	srv := Server{Port: port}
+	for _, e := range parseLsofOutput(string(out)) {
+		if e.Port != port || slices.Contains(srv.PIDs, e.PID) {
+			continue
+		}
+		if len(srv.PIDs) == 0 {
+			srv.PID, srv.Process = e.PID, e.Command
+		}
+		srv.PIDs = append(srv.PIDs, e.PID)
+	}
+	if srv.PID == 0 {
+		return Server{}, ErrUnresolved
+	}
+	servers := []Server{srv}
+	s.fillProcesses(ctx, servers)
+	return servers[0], nil
+}
+
+// fillProcesses sets each server's start time, executable and arguments
+// from a single ps run over all their PIDs, rather than one per server. A
+// server ps has nothing on keeps the name lsof gave it.
+func (s *darwinScanner) fillProcesses(ctx context.Context, servers []Server) {
+	var pids []string
+	for _, srv := range servers {
+		if pid := strconv.Itoa(srv.PID); srv.PID > 0 && !slices.Contains(pids, pid) {
+			pids = append(pids, pid)
+		}
+	}
+	if len(pids) == 0 {
+		return
+	}
+	// ps exits 1 when any PID has gone, but still lists the rest.
+	out, err := s.run(ctx, "ps", "-p", strings.Join(pids, ","), "-o", "pid=,lstart=,comm=,args=")
+	if err != nil && len(out) == 0 {
+		return
+	}
+	infos := parsePsOutput(string(out))
+	for i := range servers {
+		info, ok := infos[servers[i].PID]
+		if !ok {
+			continue
+		}
+		servers[i].Process = filepath.Base(info.Comm)
+		servers[i].Command = info.Args
+		servers[i].StartTime = info.Start
+		if filepath.IsAbs(info.Comm) {
+			servers[i].ExePath = info.Comm
+		}
+	}
+}
+
+// ProcessCwd returns the working directory of pid, as reported by lsof.
+func ProcessCwd(ctx context.Context, pid int) (string, error) {
+	out, err := exec.CommandContext(ctx, "lsof", "-a", "-p", strconv.Itoa(pid), "-d", "cwd", "-Fn").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run lsof: %w", err)
+	}
+	cwd, ok := parseLsofCwd(string(out))
+	if !ok {
+		return "", errors.New("lsof reported no cwd")
+	}
+	return cwd, nil
+}
@@ -0,0 +1,97 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeRunner records the commands a darwinScanner runs, answering lsof with
+// out and failing everything else.
+type fakeRunner struct {
+	out   string
+	calls [][]string
+}
+
+func (f *fakeRunner) run(_ context.Context, name string, args ...string) ([]byte, error) {
+	f.calls = append(f.calls, append([]string{name}, args...))
+	if name == "ps" {
+		return nil, errors.New("no ps in tests")
+	}
+	return []byte(f.out), nil
+}
+
+func TestDarwinScanUsesConfiguredLsof(t *testing.T) {
+	f := &fakeRunner{out: sampleLsofOutput}
+	s := &darwinScanner{
+		opts: Options{MinPort: 1, MaxPort: 65535, LsofPath: "/opt/bin/lsof", LsofArgs: []string{"-iTCP", "-sTCP:LISTEN", "-nP", "-w"}},
+		run:  f.run,
+	}
+	servers, err := s.Scan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/opt/bin/lsof", "-iTCP", "-sTCP:LISTEN", "-nP", "-w"}; len(f.calls) == 0 || !slices.Equal(f.calls[0], want) {
+		t.Errorf("first command = %q, want %q", f.calls[0], want)
+	}
+	if len(servers) != 3 {
+		t.Errorf("Scan() = %+v, want the three sockets lsof listed", servers)
+	}
+
+	f = &fakeRunner{}
+	s = &darwinScanner{opts: Options{MinPort: 1, MaxPort: 65535}, run: f.run}
+	if _, err := s.Scan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"lsof", "-iTCP", "-sTCP:LISTEN", "-nP"}; !slices.Equal(f.calls[0], want) {
+		t.Errorf("default command = %q, want %q", f.calls[0], want)
+	}
+}
+
+func TestDarwinScanCountsExcluded(t *testing.T) {
+	f := &fakeRunner{out: sampleLsofOutput}
+	s := &darwinScanner{opts: Options{MinPort: 3000, MaxPort: 3999}, run: f.run}
+	servers, err := s.Scan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 5432 is listed twice, on IPv4 and IPv6, but is one port.
+	if len(servers) != 1 || s.Excluded() != 1 {
+		t.Errorf("Scan() = %+v, Excluded() = %d; want :3000 and one excluded port", servers, s.Excluded())
+	}
+}
+
+func TestDarwinScanWrapsLsofError(t *testing.T) {
+	run := func(context.Context, string, ...string) ([]byte, error) {
+		return nil, &exec.Error{Name: "lsof", Err: exec.ErrNotFound}
+	}
+	s := &darwinScanner{opts: Options{MinPort: 1, MaxPort: 65535}, run: run}
+	_, err := s.Scan(context.Background())
+	if !errors.Is(err, exec.ErrNotFound) {
+		t.Fatalf("Scan() = %v, want it to unwrap to exec.ErrNotFound", err)
+	}
+	if !strings.HasPrefix(err.Error(), "failed to run lsof: ") {
+		t.Errorf("Scan() = %q, want the operation named", err)
+	}
+}
+
+func TestDarwinScanRunsPsOnce(t *testing.T) {
+	f := &fakeRunner{out: sampleLsofOutput}
+	s := &darwinScanner{opts: Options{MinPort: 1, MaxPort: 65535}, run: f.run}
+	if _, err := s.Scan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var ps [][]string
+	for _, call := range f.calls {
+		if call[0] == "ps" {
+			ps = append(ps, call)
+		}
+	}
+	want := []string{"ps", "-p", "12345,901", "-o", "pid=,lstart=,comm=,args="}
+	if len(ps) != 1 || !slices.Equal(ps[0], want) {
+		t.Errorf("ps calls = %q, want one %q covering every PID", ps, want)
+	}
+}
@@ -0,0 +1,366 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type linuxScanner struct {
+	opts     Options
+	runSS    func(ctx context.Context) ([]byte, error)
+	ssPort   func(ctx context.Context, port int) ([]byte, error) // ss for one port, in ResolvePort
+	readFile func(name string) ([]byte, error)                   // os.ReadFile if nil; faked in tests
+	check    func(context.Context, []Server, time.Duration)      // checkAll if nil; faked in tests
+
+	mu       sync.Mutex
+	lastSS   ssResult // last result ss produced, reused when it fails
+	ssErr    error    // why the last ss run failed; nil after a success
+	excluded int      // ports the last Scan left out, for Excluded
+}
+
+// ssResult is what one `ss -tlnp` run says about the listening ports.
+type ssResult struct {
+	pids   map[int][]int
+	queues map[int]ssQueue
+	stale  bool // reused from an earlier run because this one failed
+}
+
+// New returns the Linux scanner, which reads /proc/net/tcp and tcp6, and with
+// Options.UDP udp and udp6, and resolves owning processes via ss, falling
+// back to walking /proc/[pid]/fd.
+func New(opts Options) Scanner {
+	return &linuxScanner{opts: opts, runSS: runSS, ssPort: runSSPort}
+}
+
+func runSS(ctx context.Context) ([]byte, error) {
+	return exec.CommandContext(ctx, "ss", "-tlnp").Output()
+}
+
+func runSSPort(ctx context.Context, port int) ([]byte, error) {
+	return exec.CommandContext(ctx, "ss", ssPortArgs(port)...).Output()
+}
+
+func (s *linuxScanner) Scan(ctx context.Context) ([]Server, error) {
+	data, err := s.read("/proc/net/tcp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read listening sockets: %w", err)
+	}
+	entries := parseProcNetTCP(data)
+	// tcp6 is missing when IPv6 is disabled; the IPv4 list still stands.
+	if data, err := s.read("/proc/net/tcp6"); err == nil {
+		entries = append(entries, parseProcNetTCP6(data)...)
+	}
+	if s.opts.UDP {
+		entries = append(entries, s.udpEntries()...)
+	}
+
+	ss := s.resolveSS(ctx)
+	pids := ss.pids
+	var inodePIDs map[uint64]int
+
+	// Sockets sharing an address and port (SO_REUSEPORT) are one server with
+	// several owners.
+	type bind struct {
+		addr  string
+		port  int
+		proto string
+	}
+	index := make(map[bind]int)
+	var servers []Server
+	skipped := make(map[int]bool)
+	for _, e := range entries {
+		if !s.opts.wants(e.Port) {
+			skipped[e.Port] = true
+			continue
+		}
+		// ss -t only knows TCP; UDP owners come from the inode walk.
+		ssPIDs := pids[e.Port]
+		if e.Proto == ProtoUDP {
+			ssPIDs = nil
+		}
+		key := bind{e.Addr, e.Port, e.Proto}
+		i, seen := index[key]
+		if !seen {
+			i = len(servers)
+			index[key] = i
+			if e.Proto == ProtoUDP {
+				servers = append(servers, Server{Port: e.Port, Addr: e.Addr, Protocol: ProtoUDP, State: "UNCONN"})
+			} else {
+				q := ss.queues[e.Port]
+				servers = append(servers, Server{Port: e.Port, Addr: e.Addr, PIDs: slices.Clone(ssPIDs), State: "LISTEN", Backlog: q.backlog, MaxBacklog: q.max, PIDStale: ss.stale && len(ssPIDs) > 0})
+			}
+		}
+		if len(ssPIDs) == 0 {
+			if inodePIDs == nil {
+				inodePIDs = socketInodePIDs()
+			}
+			if pid := inodePIDs[e.Inode]; pid > 0 && !slices.Contains(servers[i].PIDs, pid) {
+				servers[i].PIDs = append(servers[i].PIDs, pid)
+			}
+		}
+	}
+	s.mu.Lock()
+	s.excluded = len(skipped)
+	s.mu.Unlock()
+	for i := range servers {
+		fillProcess(&servers[i])
+	}
+	servers = mergeDuplicates(servers)
+	if s.opts.Unix {
+		if inodePIDs == nil {
+			inodePIDs = socketInodePIDs()
+		}
+		servers = append(servers, unixServers(inodePIDs)...)
+	}
+
+	s.checkHealth(ctx, servers)
+	sortByPort(servers)
+	err = degraded(servers)
+	s.mu.Lock()
+	if err != nil && s.ssErr != nil {
+		err = fmt.Errorf("%w: failed to run ss: %w", err, s.ssErr)
+	}
+	s.mu.Unlock()
+	return servers, err
+}
+
+// read is os.ReadFile, or the fake a test installed.
+func (s *linuxScanner) read(name string) ([]byte, error) {
+	if s.readFile != nil {
+		return s.readFile(name)
+	}
+	return os.ReadFile(name)
+}
+
+// checkHealth health-checks servers with the configured timeout.
+func (s *linuxScanner) checkHealth(ctx context.Context, servers []Server) {
+	if s.check != nil {
+		s.check(ctx, servers, s.opts.healthTimeout())
+		return
+	}
+	checkAll(ctx, servers, s.opts.healthTimeout())
+}
+
+// udpEntries reads the bound UDP sockets in /proc/net/udp and udp6. Either
+// file may be missing, such as udp6 with IPv6 disabled; what was read stands.
+func (s *linuxScanner) udpEntries() []procEntry {
+	var entries []procEntry
+	if data, err := s.read("/proc/net/udp"); err == nil {
+		entries = parseProcNetUDP(data)
+	}
+	if data, err := s.read("/proc/net/udp6"); err == nil {
+		entries = append(entries, parseProcNetUDP6(data)...)
+	}
+	return entries
+}
+
+// Excluded returns how many listening ports the last Scan left out of its
+// range or allow-list.
+func (s *linuxScanner) Excluded() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.excluded
+}
+
+// unixServers lists the named Unix domain sockets listening in
+// /proc/net/unix, their owners found through inodePIDs.
+func unixServers(inodePIDs map[uint64]int) []Server {
+	data, err := os.ReadFile("/proc/net/unix")
+	if err != nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var servers []Server
+	for _, e := range parseProcNetUnix(data) {
+		if seen[e.Path] {
+			continue
+		}
+		seen[e.Path] = true
+		srv := Server{SocketPath: e.Path, State: "LISTEN"}
+		if pid := inodePIDs[e.Inode]; pid > 0 {
+			srv.PIDs = []int{pid}
+		}
+		fillProcess(&srv)
+		servers = append(servers, srv)
+	}
+	return servers
+}
+
+// ResolvePort asks ss about a TCP port alone, falling back to matching its
+// socket inodes against /proc/[pid]/fd. UDP owners always come from the
+// inode walk, as in Scan.
+func (s *linuxScanner) ResolvePort(ctx context.Context, proto string, port int) (Server, error) {
+	srv := Server{Port: port}
+	if proto == ProtoUDP {
+		srv.Protocol = ProtoUDP
+		srv.PIDs = inodeOwners(s.udpEntries(), port)
+	} else {
+		if out, err := s.ssPort(ctx, port); err == nil {
+			srv.PIDs = parseSSOutput(string(out))[port]
+		}
+		if len(srv.PIDs) == 0 {
+			data, err := s.read("/proc/net/tcp")
+			if err != nil {
+				return Server{}, fmt.Errorf("failed to read listening sockets: %w", err)
+			}
+			entries := parseProcNetTCP(data)
+			// As in Scan, tcp6 may be missing; a listener bound only to
+			// [::] is in it when it is there.
+			if data, err := s.read("/proc/net/tcp6"); err == nil {
+				entries = append(entries, parseProcNetTCP6(data)...)
+			}
+			srv.PIDs = inodeOwners(entries, port)
+		}
+	}
+	if len(srv.PIDs) == 0 {
+		return Server{}, ErrUnresolved
+	}
+	fillProcess(&srv)
+	return srv, nil
+}
+
+// inodeOwners returns the PIDs holding the sockets in entries on port.
+func inodeOwners(entries []procEntry, port int) []int {
+	inodePIDs := socketInodePIDs()
+	var pids []int
+	for _, e := range entries {
+		if pid := inodePIDs[e.Inode]; e.Port == port && pid > 0 && !slices.Contains(pids, pid) {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// ssPortArgs are the ss arguments listing only the listener on port.
+func ssPortArgs(port int) []string {
+	return []string{"-tlnp", "sport", "=", ":" + strconv.Itoa(port)}
+}
+
+// fillProcess sets PID and the process details from srv.PIDs[0].
+func fillProcess(srv *Server) {
+	if len(srv.PIDs) == 0 {
+		return
+	}
+	srv.PID = srv.PIDs[0]
+	srv.Process, srv.Command = readProcInfo(srv.PID)
+	srv.ExePath = readExePath(srv.PID)
+	srv.StartTime = readStartTime(srv.PID)
+}
+
+// resolveSS asks ss for the owning PIDs and accept queues of each listening
+// port. ss fails transiently on loaded systems, so a failure is retried once
+// and then answered with the last good result, which keeps rows from
+// flickering to PID 0; such a result is marked stale. Its maps are nil only
+// if ss has never succeeded.
+func (s *linuxScanner) resolveSS(ctx context.Context) ssResult {
+	out, err := s.runSS(ctx)
+	if err != nil && ctx.Err() == nil {
+		out, err = s.runSS(ctx)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		stale := s.lastSS
+		stale.stale = stale.pids != nil
+		s.ssErr = err
+		return stale
+	}
+	s.ssErr = nil
+	s.lastSS = ssResult{pids: parseSSOutput(string(out)), queues: parseSSQueues(string(out))}
+	return s.lastSS
+}
+
+// socketInodePIDs maps socket inodes to the PID holding them by walking
+// /proc/[pid]/fd. Processes we are not allowed to inspect are skipped.
+func socketInodePIDs() map[uint64]int {
+	result := make(map[uint64]int)
+	procs, err := os.ReadDir("/proc")
+	if err != nil {
+		return result
+	}
+	for _, p := range procs {
+		pid, err := strconv.Atoi(p.Name())
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join("/proc", p.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil {
+				continue
+			}
+			if inode, ok := parseSocketInode(link); ok {
+				result[inode] = pid
+			}
+		}
+	}
+	return result
+}
+
+// readProcInfo returns the short name and full command line of pid.
+func readProcInfo(pid int) (process, command string) {
+	dir := filepath.Join("/proc", strconv.Itoa(pid))
+	if comm, err := os.ReadFile(filepath.Join(dir, "comm")); err == nil {
+		process = strings.TrimSpace(string(comm))
+	}
+	if cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+		command = strings.TrimSpace(strings.ReplaceAll(string(cmdline), "\x00", " "))
+	}
+	return process, command
+}
+
+// bootTime is when the system booted, which /proc/[pid]/stat start times
+// count from. It is zero if /proc/stat cannot be read.
+var bootTime = sync.OnceValue(func() time.Time {
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return time.Time{}
+	}
+	t, _ := parseBootTime(data)
+	return t
+})
+
+// readStartTime returns when pid started, or the zero time if that cannot
+// be read.
+func readStartTime(pid int) time.Time {
+	boot := bootTime()
+	if boot.IsZero() {
+		return time.Time{}
+	}
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return time.Time{}
+	}
+	ticks, ok := parseProcStartTicks(data)
+	if !ok {
+		return time.Time{}
+	}
+	return boot.Add(time.Duration(ticks) * time.Second / clockTicks)
+}
+
+// readExePath resolves /proc/[pid]/exe. Processes owned by other users
+// usually deny this, in which case the path is left empty.
+func readExePath(pid int) string {
+	path, err := os.Readlink(filepath.Join("/proc", strconv.Itoa(pid), "exe"))
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// ProcessCwd returns the working directory of pid.
+func ProcessCwd(ctx context.Context, pid int) (string, error) {
+	return os.Readlink(filepath.Join("/proc", strconv.Itoa(pid), "cwd"))
+}
@@ -0,0 +1,250 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"os/exec"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSS returns the queued results of successive ss runs.
+type fakeSS struct {
+	outputs []string
+	errs    []error
+	calls   int
+}
+
+func (f *fakeSS) run(context.Context) ([]byte, error) {
+	i := f.calls
+	f.calls++
+	return []byte(f.outputs[i]), f.errs[i]
+}
+
+func TestResolveSSRetriesOnce(t *testing.T) {
+	ss := &fakeSS{
+		outputs: []string{"", sampleSSOutput},
+		errs:    []error{errors.New("ss: netlink busy"), nil},
+	}
+	s := &linuxScanner{runSS: ss.run}
+	res := s.resolveSS(context.Background())
+	if ss.calls != 2 || len(res.pids) == 0 {
+		t.Fatalf("after one failure: calls = %d, pids = %v, want a retry that succeeds", ss.calls, res.pids)
+	}
+}
+
+func TestResolveSSFallsBackToLastResult(t *testing.T) {
+	fail := errors.New("ss: netlink busy")
+	ss := &fakeSS{
+		outputs: []string{sampleSSOutput, "", ""},
+		errs:    []error{nil, fail, fail},
+	}
+	s := &linuxScanner{runSS: ss.run}
+	want := s.resolveSS(context.Background())
+	if len(want.pids) == 0 {
+		t.Fatal("first run should parse the sample output")
+	}
+	got := s.resolveSS(context.Background())
+	if ss.calls != 3 {
+		t.Errorf("calls = %d, want 3 (one success, a failure and its retry)", ss.calls)
+	}
+	if !got.stale {
+		t.Error("a reused result should be marked stale")
+	}
+	if got.stale = false; !reflect.DeepEqual(got, want) {
+		t.Errorf("after a failed run got %+v, want the cached %+v", got, want)
+	}
+}
+
+func TestScanMarksCachedPIDsStale(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("cannot listen:", err)
+	}
+	defer ln.Close()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	// A PID that cannot exist, so nothing real is looked up.
+	out := fmt.Sprintf("State  Recv-Q Send-Q Local Address:Port  Peer Address:Port Process\n"+
+		"LISTEN 0      128        127.0.0.1:%d       0.0.0.0:*     users:((\"fake\",pid=9000001,fd=3))\n", port)
+	fail := errors.New("ss: netlink busy")
+	ss := &fakeSS{outputs: []string{out, "", ""}, errs: []error{nil, fail, fail}}
+	s := &linuxScanner{opts: Options{Ports: []int{port}}, runSS: ss.run}
+
+	for i, wantStale := range []bool{false, true} {
+		servers, _ := s.Scan(context.Background())
+		if len(servers) != 1 || servers[0].PID != 9000001 {
+			t.Fatalf("scan %d = %+v, want the one listener owned by the fake PID", i, servers)
+		}
+		if servers[0].PIDStale != wantStale {
+			t.Errorf("scan %d: PIDStale = %v, want %v", i, servers[0].PIDStale, wantStale)
+		}
+	}
+}
+
+func TestScanCountsExcluded(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("cannot listen:", err)
+	}
+	defer ln.Close()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	ss := &fakeSS{outputs: []string{""}, errs: []error{nil}}
+	s := &linuxScanner{opts: Options{Ports: []int{port + 1}}, runSS: ss.run}
+	if _, err := s.Scan(context.Background()); err != nil && !errors.Is(err, ErrDegraded) {
+		t.Fatal(err)
+	}
+	if s.Excluded() < 1 {
+		t.Errorf("Excluded() = %d, want at least the listener on :%d", s.Excluded(), port)
+	}
+}
+
+func TestScanUsesHealthTimeout(t *testing.T) {
+	for _, tc := range []struct {
+		opt, want time.Duration
+	}{
+		{0, time.Second},
+		{250 * time.Millisecond, 250 * time.Millisecond},
+	} {
+		var got time.Duration
+		s := &linuxScanner{
+			opts:  Options{HealthTimeout: tc.opt},
+			runSS: (&fakeSS{outputs: []string{""}, errs: []error{nil}}).run,
+			readFile: func(name string) ([]byte, error) {
+				if name == "/proc/net/tcp" {
+					return []byte(sampleProcNetTCP), nil
+				}
+				return nil, fs.ErrNotExist
+			},
+			check: func(_ context.Context, _ []Server, timeout time.Duration) { got = timeout },
+		}
+		if _, err := s.Scan(context.Background()); err != nil && !errors.Is(err, ErrDegraded) {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("HealthTimeout %s: checkAll timeout = %s, want %s", tc.opt, got, tc.want)
+		}
+	}
+}
+
+func TestScanMergesDualStack(t *testing.T) {
+	ln4, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("cannot listen:", err)
+	}
+	defer ln4.Close()
+	port := ln4.Addr().(*net.TCPAddr).Port
+	ln6, err := net.Listen("tcp6", fmt.Sprintf("[::1]:%d", port))
+	if err != nil {
+		t.Skip("no IPv6 loopback:", err)
+	}
+	defer ln6.Close()
+
+	// A PID that cannot exist, so nothing real is looked up.
+	out := fmt.Sprintf("State  Recv-Q Send-Q Local Address:Port  Peer Address:Port Process\n"+
+		"LISTEN 0      128        127.0.0.1:%d       0.0.0.0:*     users:((\"fake\",pid=9000001,fd=3))\n"+
+		"LISTEN 0      128            [::1]:%d          [::]:*     users:((\"fake\",pid=9000001,fd=4))\n", port, port)
+	ss := &fakeSS{outputs: []string{out}, errs: []error{nil}}
+	s := &linuxScanner{opts: Options{Ports: []int{port}}, runSS: ss.run}
+	servers, _ := s.Scan(context.Background())
+	if len(servers) != 1 {
+		t.Fatalf("Scan() = %+v, want one row for the dual-stack port", servers)
+	}
+	if want := []string{"127.0.0.1", "[::1]"}; !slices.Equal(servers[0].Addrs, want) {
+		t.Errorf("Addrs = %v, want %v", servers[0].Addrs, want)
+	}
+}
+
+func TestScanWrapsReadError(t *testing.T) {
+	s := &linuxScanner{
+		runSS:    (&fakeSS{}).run,
+		readFile: func(string) ([]byte, error) { return nil, fs.ErrPermission },
+	}
+	_, err := s.Scan(context.Background())
+	if !errors.Is(err, fs.ErrPermission) {
+		t.Fatalf("Scan() = %v, want it to unwrap to fs.ErrPermission", err)
+	}
+	if !strings.Contains(err.Error(), "failed to read listening sockets") {
+		t.Errorf("Scan() = %q, want the operation named", err)
+	}
+}
+
+func TestScanDegradedNamesSSFailure(t *testing.T) {
+	// One listener on port 1 whose inode no process holds, so nothing
+	// resolves once ss fails.
+	tcp := "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n" +
+		"   0: 0100007F:0001 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 4000000000 1 0000000000000000 100 0 0 10 0\n"
+	ss := &fakeSS{outputs: []string{"", ""}, errs: []error{exec.ErrNotFound, exec.ErrNotFound}}
+	s := &linuxScanner{
+		opts:  Options{Ports: []int{1}},
+		runSS: ss.run,
+		readFile: func(name string) ([]byte, error) {
+			if name == "/proc/net/tcp" {
+				return []byte(tcp), nil
+			}
+			return nil, fs.ErrNotExist
+		},
+	}
+	servers, err := s.Scan(context.Background())
+	if len(servers) != 1 || !errors.Is(err, ErrDegraded) || !errors.Is(err, exec.ErrNotFound) {
+		t.Fatalf("Scan() = %+v, %v; want the listener with ErrDegraded wrapping exec.ErrNotFound", servers, err)
+	}
+	if !strings.Contains(err.Error(), "failed to run ss") {
+		t.Errorf("Scan() = %q, want the ss failure named", err)
+	}
+}
+
+func TestResolvePortWithoutSSFindsIPv6Listener(t *testing.T) {
+	ln, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		t.Skip("no IPv6 loopback:", err)
+	}
+	defer ln.Close()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	noSS := func(context.Context, int) ([]byte, error) { return nil, exec.ErrNotFound }
+	s := &linuxScanner{ssPort: noSS}
+	srv, err := s.ResolvePort(context.Background(), "TCP", port)
+	if err != nil {
+		t.Fatalf("ResolvePort(%d) error = %v", port, err)
+	}
+	if !slices.Contains(srv.PIDs, os.Getpid()) {
+		t.Errorf("ResolvePort(%d) PIDs = %v, want this test's PID %d", port, srv.PIDs, os.Getpid())
+	}
+}
+
+func TestResolvePortUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("cannot bind UDP:", err)
+	}
+	defer conn.Close()
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+
+	noSS := func(context.Context, int) ([]byte, error) { return nil, exec.ErrNotFound }
+	s := &linuxScanner{ssPort: noSS}
+	srv, err := s.ResolvePort(context.Background(), ProtoUDP, port)
+	if err != nil {
+		t.Fatalf("ResolvePort(UDP, %d) error = %v", port, err)
+	}
+	if srv.Protocol != ProtoUDP || !slices.Contains(srv.PIDs, os.Getpid()) {
+		t.Errorf("ResolvePort(UDP, %d) = %+v, want UDP owned by PID %d", port, srv, os.Getpid())
+	}
+	if _, err := s.ResolvePort(context.Background(), "TCP", port); !errors.Is(err, ErrUnresolved) {
+		t.Errorf("ResolvePort(TCP, %d) error = %v, want ErrUnresolved for a UDP-only port", port, err)
+	}
+}
+
+func TestSSPortArgs(t *testing.T) {
+	if got, want := ssPortArgs(8080), []string{"-tlnp", "sport", "=", ":8080"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ssPortArgs(8080) = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,130 @@
+package scanner
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestServerExposed(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"127.0.0.1", false},
+		{"127.0.0.53", false},
+		{"[::1]", false},
+		{"localhost", false},
+		{"", false},
+		{"0.0.0.0", true},
+		{"*", true},
+		{"[::]", true},
+		{"192.168.1.20", true},
+	}
+	for _, tt := range tests {
+		if got := (Server{Addr: tt.addr}).Exposed(); got != tt.want {
+			t.Errorf("Server{Addr: %q}.Exposed() = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestOptionsWants(t *testing.T) {
+	ranged := Options{MinPort: 1024, MaxPort: 9000}
+	listed := Options{MinPort: 1024, MaxPort: 9000, Ports: []int{22, 8080}}
+	tests := []struct {
+		opts Options
+		port int
+		want bool
+	}{
+		{ranged, 1024, true},
+		{ranged, 9000, true},
+		{ranged, 22, false},
+		{ranged, 9001, false},
+		{listed, 22, true},
+		{listed, 8080, true},
+		{listed, 3000, false},
+	}
+	for _, tt := range tests {
+		if got := tt.opts.wants(tt.port); got != tt.want {
+			t.Errorf("%+v.wants(%d) = %v, want %v", tt.opts, tt.port, got, tt.want)
+		}
+	}
+}
+
+func TestOptionsLsof(t *testing.T) {
+	var o Options
+	if o.lsof() != "lsof" || !slices.Equal(o.lsofListenArgs(), []string{"-iTCP", "-sTCP:LISTEN", "-nP"}) {
+		t.Errorf("defaults = %s %q", o.lsof(), o.lsofListenArgs())
+	}
+	o = Options{LsofPath: "/usr/local/bin/lsof", LsofArgs: []string{"-iTCP", "-sTCP:LISTEN", "-nP", "-w"}}
+	if o.lsof() != "/usr/local/bin/lsof" || !slices.Equal(o.lsofListenArgs(), o.LsofArgs) {
+		t.Errorf("overrides = %s %q", o.lsof(), o.lsofListenArgs())
+	}
+}
+
+func TestDegraded(t *testing.T) {
+	if err := degraded(nil); err != nil {
+		t.Errorf("degraded(nil) = %v, want nil", err)
+	}
+	if err := degraded([]Server{{Port: 22}, {Port: 80, PID: 1}}); err != nil {
+		t.Errorf("one resolved PID: degraded = %v, want nil", err)
+	}
+	if err := degraded([]Server{{Port: 22}, {Port: 80}}); err != ErrDegraded {
+		t.Errorf("no resolved PIDs: degraded = %v, want ErrDegraded", err)
+	}
+}
+
+func TestServerExposedMergedAddrs(t *testing.T) {
+	s := Server{Addr: "127.0.0.1", Addrs: []string{"127.0.0.1", "[::1]"}}
+	if s.Exposed() {
+		t.Error("loopback-only merged server reported as exposed")
+	}
+	s.Addrs = append(s.Addrs, "0.0.0.0")
+	if !s.Exposed() {
+		t.Error("merged server with a wildcard address should be exposed")
+	}
+}
+
+func TestMergeDuplicates(t *testing.T) {
+	got := mergeDuplicates([]Server{
+		{Port: 8080, Addr: "0.0.0.0"},
+		{Port: 8080, Addr: "0.0.0.0", PID: 300, PIDs: []int{300}, Process: "api", Healthy: true},
+		{Port: 8080, Addr: "[::]"},
+		{Port: 3000, Addr: "127.0.0.1", PID: 100, PIDs: []int{100}, Process: "node"},
+		{Port: 3000, Addr: "10.0.0.5", PID: 101, PIDs: []int{101}, Process: "node"},
+		{Port: 9000, Addr: "127.0.0.1"},
+	})
+	if len(got) != 4 {
+		t.Fatalf("mergeDuplicates() = %d rows, want 4: %+v", len(got), got)
+	}
+	api := got[0]
+	if api.PID != 300 || api.Process != "api" || !api.Healthy {
+		t.Errorf("merged :8080 = %+v, want the resolved row's PID and details", api)
+	}
+	if want := []string{"0.0.0.0", "[::]"}; !slices.Equal(api.Addrs, want) {
+		t.Errorf("merged :8080 Addrs = %v, want %v", api.Addrs, want)
+	}
+	if got[1].PID != 100 || got[2].PID != 101 {
+		t.Errorf("resolved rows on different addresses should stay apart: %+v", got[1:3])
+	}
+	if got[3].Port != 9000 || got[3].Addrs != nil {
+		t.Errorf("a lone unresolved row should be left alone: %+v", got[3])
+	}
+}
+
+func TestMergeDuplicatesDualStack(t *testing.T) {
+	got := mergeDuplicates([]Server{
+		{Port: 8080, Addr: "0.0.0.0", PID: 300, PIDs: []int{300}, Process: "api"},
+		{Port: 8080, Addr: "[::]", PID: 300, PIDs: []int{300}, Process: "api", Healthy: true},
+		{Port: 3000, Addr: "127.0.0.1", PID: 100, PIDs: []int{100}, Process: "node"},
+		{Port: 3000, Addr: "[::1]", PID: 200, PIDs: []int{200}, Process: "bun"},
+	})
+	if len(got) != 3 {
+		t.Fatalf("mergeDuplicates() = %d rows, want 3: %+v", len(got), got)
+	}
+	if want := []string{"0.0.0.0", "[::]"}; !slices.Equal(got[0].Addrs, want) || !got[0].Healthy {
+		t.Errorf("dual-stack :8080 = %+v, want one healthy row on %v", got[0], want)
+	}
+	if got[1].PID != 100 || got[2].PID != 200 {
+		t.Errorf("IPv4 and IPv6 rows with different owners should stay apart: %+v", got[1:])
+	}
+}
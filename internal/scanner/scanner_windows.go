@@ -0,0 +1,127 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"sync"
+)
+
+type windowsScanner struct {
+	opts Options
+	run  func(ctx context.Context, name string, args ...string) ([]byte, error) // runCommand; faked in tests
+
+	mu       sync.Mutex
+	excluded int // ports the last Scan left out, for Excluded
+}
+
+// New returns the Windows scanner, which reads listening sockets and their
+// PIDs from netstat and process names from tasklist. Command lines are not
+// available, and there are no Unix domain sockets to list.
+func New(opts Options) Scanner {
+	return &windowsScanner{opts: opts, run: runCommand}
+}
+
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).Output()
+}
+
+func (s *windowsScanner) Scan(ctx context.Context) ([]Server, error) {
+	entries, err := s.listening(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := s.processNames(ctx)
+
+	// Sockets sharing an address and port are one server with several
+	// owners.
+	type bind struct {
+		addr string
+		port int
+	}
+	index := make(map[bind]int)
+	var servers []Server
+	skipped := make(map[int]bool)
+	for _, e := range entries {
+		if !s.opts.wants(e.Port) {
+			skipped[e.Port] = true
+			continue
+		}
+		key := bind{e.Addr, e.Port}
+		if i, seen := index[key]; seen {
+			if !slices.Contains(servers[i].PIDs, e.PID) {
+				servers[i].PIDs = append(servers[i].PIDs, e.PID)
+			}
+			continue
+		}
+		index[key] = len(servers)
+		servers = append(servers, Server{Port: e.Port, Addr: e.Addr, PID: e.PID, PIDs: []int{e.PID}, Process: names[e.PID], State: "LISTEN"})
+	}
+	s.mu.Lock()
+	s.excluded = len(skipped)
+	s.mu.Unlock()
+
+	servers = mergeDuplicates(servers)
+	checkAll(ctx, servers, s.opts.healthTimeout())
+	sortByPort(servers)
+	return servers, degraded(servers)
+}
+
+// Excluded returns how many listening ports the last Scan left out of its
+// range or allow-list.
+func (s *windowsScanner) Excluded() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.excluded
+}
+
+// listening runs netstat for the listening TCP sockets.
+func (s *windowsScanner) listening(ctx context.Context) ([]netstatEntry, error) {
+	out, err := s.run(ctx, "netstat", "-ano")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run netstat: %w", err)
+	}
+	return parseNetstatOutput(string(out)), nil
+}
+
+// processNames asks tasklist for every process's name. A failure leaves the
+// rows unnamed rather than failing the scan.
+func (s *windowsScanner) processNames(ctx context.Context) map[int]string {
+	out, err := s.run(ctx, "tasklist", "/FO", "CSV", "/NH")
+	if err != nil {
+		return nil
+	}
+	return parseTasklistOutput(string(out))
+}
+
+// ResolvePort asks netstat about a TCP port alone. UDP is not scanned on
+// Windows, so it has no owners to find.
+func (s *windowsScanner) ResolvePort(ctx context.Context, proto string, port int) (Server, error) {
+	if proto == ProtoUDP {
+		return Server{}, ErrUnresolved
+	}
+	entries, err := s.listening(ctx)
+	if err != nil {
+		return Server{}, err
+	}
+	srv := Server{Port: port}
+	for _, e := range entries {
+		if e.Port == port && e.PID > 0 && !slices.Contains(srv.PIDs, e.PID) {
+			srv.PIDs = append(srv.PIDs, e.PID)
+		}
+	}
+	if len(srv.PIDs) == 0 {
+		return Server{}, ErrUnresolved
+	}
+	srv.PID = srv.PIDs[0]
+	srv.Process = s.processNames(ctx)[srv.PID]
+	return srv, nil
+}
+
+// ProcessCwd is not supported on Windows, where reading another process's
+// working directory needs its memory.
+func ProcessCwd(context.Context, int) (string, error) {
+	return "", fmt.Errorf("reading a process's working directory: %w", errors.ErrUnsupported)
+}
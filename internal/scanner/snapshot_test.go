@@ -0,0 +1,36 @@
+package scanner
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSnapshotRoundTripThroughMock(t *testing.T) {
+	want := []Server{
+		{Port: 3000, Addr: "127.0.0.1", PID: 100, PIDs: []int{100}, Process: "node", Command: "node server.js", State: "LISTEN", Healthy: true},
+		{Port: 8080, Addr: "*", PID: 300, PIDs: []int{300, 301}, Process: "api", ExePath: "/usr/local/bin/api", State: "LISTEN", Label: "web-api"},
+	}
+	path := filepath.Join(t.TempDir(), "snapshots", "before.json")
+	if err := SaveSnapshot(path, want); err != nil {
+		t.Fatalf("SaveSnapshot() error = %v", err)
+	}
+	loaded, err := LoadSnapshot(path)
+	if err != nil {
+		t.Fatalf("LoadSnapshot() error = %v", err)
+	}
+	got, err := (&MockScanner{Servers: loaded}).Scan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("snapshot round trip = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadSnapshotMissing(t *testing.T) {
+	if _, err := LoadSnapshot(filepath.Join(t.TempDir(), "nope.json")); err == nil {
+		t.Error("LoadSnapshot() of a missing file should fail")
+	}
+}
@@ -0,0 +1,48 @@
+package tui
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/exp/teatest"
+
+	"github.com/jeramiahgcoffey/portview/internal/config"
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+func TestPrintQuitChoosesSelection(t *testing.T) {
+	m := New(&scanner.MockScanner{Servers: testServers}, config.Default(), Options{})
+	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(100, 20))
+	teatest.WaitFor(t, tm.Output(), func(out []byte) bool {
+		return bytes.Contains(out, []byte("postgres"))
+	}, teatest.WithDuration(2*time.Second))
+
+	tm.Send(tea.KeyMsg{Type: tea.KeyDown})
+	tm.Send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("P")})
+	final := tm.FinalModel(t, teatest.WithFinalTimeout(2*time.Second)).(Model)
+	s, ok := final.Chosen()
+	if !ok || s.Port != 5432 {
+		t.Errorf("Chosen() = :%d, %v; want :5432, true", s.Port, ok)
+	}
+}
+
+func TestQuitChoosesNothing(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m, cmd := press(t, m, "q")
+	if cmd == nil {
+		t.Fatal("q should quit")
+	}
+	if _, ok := m.Chosen(); ok {
+		t.Error("a plain quit should not choose a server")
+	}
+}
+
+func TestPrintQuitWithNothingSelected(t *testing.T) {
+	m := newTestModel(t, nil)
+	m, cmd := press(t, m, "P")
+	if cmd != nil || m.status != "nothing selected" {
+		t.Errorf("P on an empty list: status = %q, cmd = %v", m.status, cmd)
+	}
+}
@@ -0,0 +1,215 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jeramiahgcoffey/portview/internal/config"
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+// exCommand is a parsed ":" command line.
+type exCommand struct {
+	verb  string // "goto", "kill", "label", "unlabel", "hide", "unhide" or "reset-config"
+	row   int    // 1-based row, for "goto"
+	port  int    // target port; 0 when pid is set
+	pid   int    // target PID, for "kill pid N"
+	label string // new label, for "label"
+}
+
+// commandUsage lists the accepted command forms, for error messages.
+const commandUsage = "ROW | kill PORT | kill pid PID | label PORT NAME | unlabel PORT | hide PORT | unhide PORT | reset-config"
+
+// parseCommand parses the text typed after ":". A bare number goes to that
+// row.
+func parseCommand(input string) (exCommand, error) {
+	fields := strings.Fields(input)
+	if len(fields) == 0 {
+		return exCommand{}, fmt.Errorf("empty command")
+	}
+	verb, args := fields[0], fields[1:]
+	if row, err := strconv.Atoi(verb); err == nil && len(args) == 0 {
+		if row < 1 {
+			return exCommand{}, fmt.Errorf("invalid row %d", row)
+		}
+		return exCommand{verb: "goto", row: row}, nil
+	}
+	switch verb {
+	case "kill":
+		if len(args) == 2 && args[0] == "pid" {
+			pid, err := strconv.Atoi(args[1])
+			if err != nil || pid < 1 {
+				return exCommand{}, fmt.Errorf("kill: invalid PID %q", args[1])
+			}
+			return exCommand{verb: verb, pid: pid}, nil
+		}
+		if len(args) != 1 {
+			return exCommand{}, fmt.Errorf("usage: kill PORT | kill pid PID")
+		}
+	case "label":
+		if len(args) < 2 {
+			return exCommand{}, fmt.Errorf("usage: label PORT NAME")
+		}
+		port, err := parseCommandPort(verb, args[0])
+		if err != nil {
+			return exCommand{}, err
+		}
+		return exCommand{verb: verb, port: port, label: strings.Join(args[1:], " ")}, nil
+	case "reset-config":
+		if len(args) != 0 {
+			return exCommand{}, fmt.Errorf("usage: reset-config")
+		}
+		return exCommand{verb: verb}, nil
+	case "unlabel", "hide", "unhide":
+		if len(args) != 1 {
+			return exCommand{}, fmt.Errorf("usage: %s PORT", verb)
+		}
+	default:
+		return exCommand{}, fmt.Errorf("unknown command %q; try %s", verb, commandUsage)
+	}
+	port, err := parseCommandPort(verb, args[0])
+	if err != nil {
+		return exCommand{}, err
+	}
+	return exCommand{verb: verb, port: port}, nil
+}
+
+func parseCommandPort(verb, arg string) (int, error) {
+	port, err := strconv.Atoi(arg)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("%s: invalid port %q", verb, arg)
+	}
+	return port, nil
+}
+
+// target returns the listed server c refers to: the one on c.port, or the
+// first one c.pid owns.
+func (c exCommand) target(servers []scanner.Server) (scanner.Server, bool) {
+	for _, s := range servers {
+		if c.port != 0 && s.Port == c.port {
+			return s, true
+		}
+		if c.pid != 0 && slices.Contains(s.AllPIDs(), c.pid) {
+			return s, true
+		}
+	}
+	return scanner.Server{}, false
+}
+
+// action is the disabled_actions name that covers c.
+func (c exCommand) action() string {
+	switch c.verb {
+	case "unlabel":
+		return "label"
+	case "unhide":
+		return "hide"
+	case "reset-config":
+		return "edit_config"
+	}
+	return c.verb
+}
+
+// describe names c's target for status messages.
+func (c exCommand) describe() string {
+	if c.pid != 0 {
+		return fmt.Sprintf("PID %d", c.pid)
+	}
+	return fmt.Sprintf(":%d", c.port)
+}
+
+// runCommand executes c. Kills go through the normal confirm prompt, with
+// the cursor moved to the target row, and reset-config asks first too; label
+// and hide edit the config directly, whether or not the port is listening.
+func (m Model) runCommand(c exCommand) (Model, tea.Cmd) {
+	if c.verb == "goto" {
+		m.goToRow(c.row)
+		return m, nil
+	}
+	if m.snapshot != "" {
+		m.status = "read-only snapshot"
+		return m, nil
+	}
+	if a := c.action(); m.config.ActionDisabled(a) {
+		m.status = a + " is disabled in the config"
+		return m, nil
+	}
+	switch c.verb {
+	case "kill":
+		return m.runKillCommand(c), nil
+	case "reset-config":
+		m.mode = modeConfirmReset
+		return m, nil
+	case "label":
+		m.config.SetLabel(c.port, c.label)
+		m.status = fmt.Sprintf("labelled :%d %q", c.port, c.label)
+	case "unlabel":
+		if _, ok := m.config.Labels[c.port]; !ok {
+			m.status = fmt.Sprintf(":%d has no label", c.port)
+			return m, nil
+		}
+		m.config.RemoveLabel(c.port)
+		m.status = fmt.Sprintf("unlabelled :%d", c.port)
+	case "hide":
+		if m.config.IsHidden(c.port) {
+			m.status = fmt.Sprintf("port %d is already hidden", c.port)
+			return m, nil
+		}
+		m.config.ToggleHidden(c.port)
+		m.status = fmt.Sprintf("hid port %d", c.port)
+	case "unhide":
+		if !m.config.IsHidden(c.port) {
+			m.status = fmt.Sprintf("port %d is not hidden", c.port)
+			return m, nil
+		}
+		m.config.ToggleHidden(c.port)
+		m.status = fmt.Sprintf("unhid port %d", c.port)
+	}
+	m.applyPipeline()
+	save := m.scheduleSave()
+	return m, save
+}
+
+// runKillCommand opens the kill prompt for c's target.
+func (m Model) runKillCommand(c exCommand) Model {
+	s, ok := c.target(m.servers)
+	if !ok {
+		m.status = "no listening server for " + c.describe()
+		return m
+	}
+	if s.PID == 0 {
+		m.status = fmt.Sprintf("no PID known for port %d", s.Port)
+		return m
+	}
+	if !m.selectPort(s.Port) {
+		m.status = fmt.Sprintf(":%d is not in the list", s.Port)
+		return m
+	}
+	m.confirmPIDs = s.AllPIDs()
+	if c.pid != 0 {
+		m.confirmPIDs = []int{c.pid}
+	}
+	m.mode = modeConfirmKill
+	return m
+}
+
+// selectPort moves the cursor to port's row, clearing the filter, port range
+// and favorites-only view if they hide it.
+func (m *Model) selectPort(port int) bool {
+	for pass := 0; pass < 2; pass++ {
+		for i, s := range m.filtered {
+			if s.Port == port {
+				m.cursor = i
+				return true
+			}
+		}
+		m.filterText = ""
+		m.portFilter = config.PortRange{}
+		m.favorites = false
+		m.applyFilter()
+	}
+	return false
+}
@@ -0,0 +1,320 @@
+package tui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jeramiahgcoffey/portview/internal/config"
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    exCommand
+		wantErr bool
+	}{
+		{in: "kill 8080", want: exCommand{verb: "kill", port: 8080}},
+		{in: "  kill   3000 ", want: exCommand{verb: "kill", port: 3000}},
+		{in: "kill pid 1234", want: exCommand{verb: "kill", pid: 1234}},
+		{in: "kill", wantErr: true},
+		{in: "kill abc", wantErr: true},
+		{in: "kill 70000", wantErr: true},
+		{in: "kill pid", wantErr: true},
+		{in: "kill pid -1", wantErr: true},
+		{in: "kill 80 81", wantErr: true},
+		{in: "label 8080 web-api", want: exCommand{verb: "label", port: 8080, label: "web-api"}},
+		{in: "label 8080 web  api", want: exCommand{verb: "label", port: 8080, label: "web api"}},
+		{in: "unlabel 8080", want: exCommand{verb: "unlabel", port: 8080}},
+		{in: "hide 22", want: exCommand{verb: "hide", port: 22}},
+		{in: "unhide 22", want: exCommand{verb: "unhide", port: 22}},
+		{in: "label 8080", wantErr: true},
+		{in: "label x web", wantErr: true},
+		{in: "unlabel", wantErr: true},
+		{in: "hide 0", wantErr: true},
+		{in: "unhide 22 23", wantErr: true},
+		{in: "reset-config", want: exCommand{verb: "reset-config"}},
+		{in: "reset-config now", wantErr: true},
+		{in: "42", want: exCommand{verb: "goto", row: 42}},
+		{in: "0", wantErr: true},
+		{in: "42 43", wantErr: true},
+		{in: "stop 80", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseCommand(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseCommand(%q) err = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseCommand(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCommandKillPortConfirms(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m, _ = press(t, m, ":")
+	if m.mode != modeCommand {
+		t.Fatalf("mode = %v, want modeCommand", m.mode)
+	}
+	m = typeText(t, m, "kill 8080")
+	if !strings.Contains(m.View(), ":kill 8080") {
+		t.Errorf("command line missing from view:\n%s", m.View())
+	}
+	m, cmd := press(t, m, "enter")
+	if m.mode != modeConfirmKill || cmd != nil {
+		t.Fatalf("mode = %v, want modeConfirmKill with no command yet", m.mode)
+	}
+	if s, _ := m.selected(); s.Port != 8080 {
+		t.Errorf("cursor on :%d, want :8080", s.Port)
+	}
+	if !strings.Contains(m.View(), "Kill PID 300") {
+		t.Errorf("confirm prompt missing from view:\n%s", m.View())
+	}
+	m, _ = press(t, m, "n")
+	if m.mode != modeNormal {
+		t.Errorf("n should cancel, mode = %v", m.mode)
+	}
+}
+
+func TestCommandKillPIDConfirmsOnlyThatPID(t *testing.T) {
+	m := newTestModel(t, []scanner.Server{
+		{Port: 3000, PID: 100, Process: "node"},
+		{Port: 4000, PID: 200, PIDs: []int{200, 201}, Process: "gunicorn"},
+	})
+	m, _ = press(t, m, ":")
+	m = typeText(t, m, "kill pid 201")
+	m, _ = press(t, m, "enter")
+	if m.mode != modeConfirmKill {
+		t.Fatalf("mode = %v, want modeConfirmKill", m.mode)
+	}
+	if s, _ := m.selected(); s.Port != 4000 {
+		t.Errorf("cursor on :%d, want :4000", s.Port)
+	}
+	if len(m.confirmPIDs) != 1 || m.confirmPIDs[0] != 201 {
+		t.Errorf("confirmPIDs = %v, want [201]", m.confirmPIDs)
+	}
+}
+
+func TestCommandKillClearsFilterHidingTarget(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m, _ = press(t, m, "/")
+	m = typeText(t, m, "postgres")
+	m, _ = press(t, m, "enter")
+	m, _ = press(t, m, ":")
+	m = typeText(t, m, "kill 3000")
+	m, _ = press(t, m, "enter")
+	if m.mode != modeConfirmKill {
+		t.Fatalf("mode = %v, want modeConfirmKill", m.mode)
+	}
+	if m.filterText != "" {
+		t.Errorf("filterText = %q, want it cleared to show the target", m.filterText)
+	}
+	if s, _ := m.selected(); s.Port != 3000 {
+		t.Errorf("cursor on :%d, want :3000", s.Port)
+	}
+}
+
+func TestCommandKillLeavesFavoritesView(t *testing.T) {
+	cfg := config.Default()
+	cfg.Favorites = []int{5432}
+	m := New(&scanner.MockScanner{Servers: testServers}, cfg, Options{Favorites: true})
+	m = update(t, m, scanResultMsg{servers: testServers})
+	m, _ = press(t, m, ":")
+	m = typeText(t, m, "kill 3000")
+	m, _ = press(t, m, "enter")
+	if m.mode != modeConfirmKill {
+		t.Fatalf("mode = %v, status = %q; want modeConfirmKill", m.mode, m.status)
+	}
+	if m.favorites {
+		t.Error("favorites-only view should be left to show the target")
+	}
+	if s, _ := m.selected(); s.Port != 3000 {
+		t.Errorf("cursor on :%d, want :3000", s.Port)
+	}
+}
+
+func TestCommandKillRejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		servers []scanner.Server
+		input   string
+		status  string
+	}{
+		{"unknown port", testServers, "kill 9999", "no listening server for :9999"},
+		{"unknown pid", testServers, "kill pid 999", "no listening server for PID 999"},
+		{"no pid", []scanner.Server{{Port: 9000}}, "kill 9000", "no PID known for port 9000"},
+		{"bad syntax", testServers, "kill", "usage"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestModel(t, tt.servers)
+			m, _ = press(t, m, ":")
+			m = typeText(t, m, tt.input)
+			m, _ = press(t, m, "enter")
+			if m.mode != modeNormal {
+				t.Errorf("mode = %v, want modeNormal", m.mode)
+			}
+			if !strings.Contains(m.status, tt.status) {
+				t.Errorf("status = %q, want it to contain %q", m.status, tt.status)
+			}
+		})
+	}
+}
+
+// runTyped enters input on the command line and returns the model and the
+// command it produced.
+func runTyped(t *testing.T, m Model, input string) (Model, tea.Cmd) {
+	t.Helper()
+	m, _ = press(t, m, ":")
+	m = typeText(t, m, input)
+	return press(t, m, "enter")
+}
+
+func TestCommandLabelAndUnlabel(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m, cmd := runTyped(t, m, "label 8080 web-api")
+	if m.config.Labels[8080] != "web-api" {
+		t.Errorf("Labels[8080] = %q, want %q", m.config.Labels[8080], "web-api")
+	}
+	if s := findPort(m.filtered, 8080); s.Label != "web-api" {
+		t.Errorf("row label = %q, want it merged", s.Label)
+	}
+	assertSaved(t, m, cmd)
+
+	// A port that is not listening can be labelled ahead of time.
+	m, cmd = runTyped(t, m, "label 9999 later")
+	if m.config.Labels[9999] != "later" {
+		t.Errorf("Labels[9999] = %q, want %q", m.config.Labels[9999], "later")
+	}
+	assertSaved(t, m, cmd)
+
+	m, cmd = runTyped(t, m, "unlabel 8080")
+	if _, ok := m.config.Labels[8080]; ok {
+		t.Error("unlabel should remove the label")
+	}
+	assertSaved(t, m, cmd)
+
+	m, cmd = runTyped(t, m, "unlabel 8080")
+	if cmd != nil || !strings.Contains(m.status, "no label") {
+		t.Errorf("unlabelling an unlabelled port: status = %q, cmd = %v", m.status, cmd)
+	}
+}
+
+func TestCommandHideAndUnhide(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m, cmd := runTyped(t, m, "hide 5432")
+	if !m.config.IsHidden(5432) {
+		t.Error("hide should add the port to the hidden list")
+	}
+	if findPort(m.filtered, 5432).Port != 0 {
+		t.Error("hidden port still listed")
+	}
+	assertSaved(t, m, cmd)
+
+	// Repeating it must not toggle the port back.
+	m, cmd = runTyped(t, m, "hide 5432")
+	if !m.config.IsHidden(5432) || cmd != nil {
+		t.Errorf("second hide changed the config: hidden = %v", m.config.Hidden)
+	}
+
+	m, cmd = runTyped(t, m, "unhide 5432")
+	if m.config.IsHidden(5432) {
+		t.Error("unhide should remove the port from the hidden list")
+	}
+	assertSaved(t, m, cmd)
+
+	m, cmd = runTyped(t, m, "unhide 5432")
+	if m.config.IsHidden(5432) || cmd != nil {
+		t.Errorf("second unhide changed the config: hidden = %v", m.config.Hidden)
+	}
+}
+
+func TestCommandReadOnlyInSnapshot(t *testing.T) {
+	m := New(&scanner.MockScanner{Servers: testServers}, config.Default(), Options{Snapshot: "old"})
+	m = update(t, m, scanResultMsg{servers: testServers})
+	m, cmd := runTyped(t, m, "hide 3000")
+	if cmd != nil || m.config.IsHidden(3000) || m.status != "read-only snapshot" {
+		t.Errorf("snapshot should refuse edits: status = %q, hidden = %v", m.status, m.config.Hidden)
+	}
+}
+
+func TestCommandResetConfig(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m.config.SetLabel(3000, "web")
+	m.config.ToggleHidden(5432)
+	m.config.Notify = true
+	m.applyPipeline()
+
+	m, cmd := runTyped(t, m, "reset-config")
+	if m.mode != modeConfirmReset || cmd != nil {
+		t.Fatalf("mode = %v, want modeConfirmReset with no command yet", m.mode)
+	}
+	if !strings.Contains(m.View(), "Reset the config to defaults?") {
+		t.Errorf("confirm prompt missing from view:\n%s", m.View())
+	}
+	m, cmd = press(t, m, "n")
+	if cmd != nil || m.config.Labels[3000] != "web" {
+		t.Fatalf("n should leave the config alone: %+v", m.config)
+	}
+
+	m, _ = runTyped(t, m, "reset-config")
+	m, cmd = press(t, m, "y")
+	if !reflect.DeepEqual(m.config, config.Default()) {
+		t.Errorf("config = %+v, want Default()", m.config)
+	}
+	if findPort(m.filtered, 5432).Port == 0 || findPort(m.filtered, 3000).Label != "" {
+		t.Error("list should be re-filtered with the default config")
+	}
+	assertSaved(t, m, cmd)
+	saved, err := config.Load(m.configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Labels) != 0 || len(saved.Hidden) != 0 || saved.Notify {
+		t.Errorf("saved config = %+v, want the defaults", saved)
+	}
+}
+
+func findPort(servers []scanner.Server, port int) scanner.Server {
+	for _, s := range servers {
+		if s.Port == port {
+			return s
+		}
+	}
+	return scanner.Server{}
+}
+
+func assertSaved(t *testing.T, m Model, cmd tea.Cmd) {
+	t.Helper()
+	if cmd == nil {
+		t.Fatal("expected a save command")
+	}
+	if msg := flushSave(t, m, cmd); msg.err != nil {
+		t.Errorf("save returned %#v", msg)
+	}
+}
+
+func TestOwnsAll(t *testing.T) {
+	tests := []struct {
+		current, confirmed []int
+		want               bool
+	}{
+		{[]int{1, 2}, []int{2, 1}, true},
+		{[]int{1, 2}, []int{2}, true},
+		{[]int{1}, []int{1, 2}, false},
+		{[]int{3}, []int{1}, false},
+		{nil, []int{1}, false},
+		{[]int{1}, nil, false},
+	}
+	for _, tt := range tests {
+		if got := ownsAll(tt.current, tt.confirmed); got != tt.want {
+			t.Errorf("ownsAll(%v, %v) = %v, want %v", tt.current, tt.confirmed, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jeramiahgcoffey/portview/internal/config"
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+// scanTimeout bounds a single scan, including health checks.
+const scanTimeout = 10 * time.Second
+
+type tickMsg time.Time
+
+type scanResultMsg struct {
+	servers     []scanner.Server
+	startedAt   time.Time // when doScan was called, not when the scan ran
+	completedAt time.Time
+	excluded    int // listeners outside the range, if the scanner counts them
+	err         error
+}
+
+type killResultMsg struct {
+	pids   []int
+	denied []int // PIDs the kill was not permitted on
+	err    error
+}
+
+type rangeCheckMsg struct {
+	ports []int
+}
+
+type resolvedPortMsg struct {
+	key    portKey
+	server scanner.Server
+	err    error
+}
+
+// killStaleMsg reports a confirmed kill that was skipped because the port's
+// owners changed after the prompt was shown.
+type killStaleMsg struct {
+	port      int
+	confirmed []int
+	current   []int
+}
+
+type openResultMsg struct {
+	port int
+	err  error
+}
+
+type configSavedMsg struct {
+	path string
+	err  error
+}
+
+type configLoadedMsg struct {
+	cfg config.Config
+	err error
+}
+
+type copyResultMsg struct {
+	what string
+	err  error
+}
+
+type snapshotSavedMsg struct {
+	path string
+	err  error
+}
+
+type editorClosedMsg struct {
+	err error
+}
+
+// doScan runs one scan off the update loop, stamping the result with now
+// both when the command is created and when the scan finishes.
+func doScan(s scanner.Scanner, now func() time.Time) tea.Cmd {
+	startedAt := now()
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), scanTimeout)
+		defer cancel()
+		servers, err := safeScan(ctx, s)
+		msg := scanResultMsg{servers: servers, startedAt: startedAt, completedAt: now(), err: err}
+		if c, ok := s.(scanner.ExcludedCounter); ok {
+			msg.excluded = c.Excluded()
+		}
+		return msg
+	}
+}
+
+// safeScan runs s.Scan, turning a panic, say from a parser edge case, into
+// an error so one bad scan does not bring down the whole program.
+func safeScan(ctx context.Context, s scanner.Scanner) (servers []scanner.Server, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			servers, err = nil, fmt.Errorf("scanner panicked: %v", r)
+		}
+	}()
+	return s.Scan(ctx)
+}
+
+// doRangeCheck scans every port with s and reports the common ports that r
+// leaves out. A failed scan reports nothing; the hint is only a courtesy.
+func doRangeCheck(s scanner.Scanner, r config.PortRange) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), scanTimeout)
+		defer cancel()
+		servers, err := safeScan(ctx, s)
+		if err != nil && !errors.Is(err, scanner.ErrDegraded) {
+			return rangeCheckMsg{}
+		}
+		return rangeCheckMsg{ports: rangeExcludedListeners(servers, r)}
+	}
+}
+
+// doResolvePort looks up the owner of k's port alone, for scanners that
+// support it.
+func doResolvePort(s scanner.Scanner, k portKey) tea.Cmd {
+	return func() tea.Msg {
+		r, ok := s.(scanner.PortResolver)
+		if !ok {
+			return resolvedPortMsg{key: k, err: errors.New("not supported by this scanner")}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), scanTimeout)
+		defer cancel()
+		srv, err := r.ResolvePort(ctx, k.proto, k.port)
+		return resolvedPortMsg{key: k, server: srv, err: err}
+	}
+}
+
+// doTick schedules the next periodic scan. It schedules nothing for a
+// non-positive interval.
+func doTick(interval time.Duration) tea.Cmd {
+	if interval <= 0 {
+		return nil
+	}
+	return tea.Tick(interval, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
+}
+
+// doKill asks every pid to exit, with SIGTERM on Unix, reporting all
+// failures together.
+func doKill(pids []int) tea.Cmd {
+	return func() tea.Msg {
+		var errs []error
+		var denied []int
+		for _, pid := range pids {
+			if err := terminate(pid); err != nil {
+				errs = append(errs, fmt.Errorf("PID %d: %w", pid, err))
+				if errors.Is(err, os.ErrPermission) {
+					denied = append(denied, pid)
+				}
+			}
+		}
+		return killResultMsg{pids: pids, denied: denied, err: errors.Join(errs...)}
+	}
+}
+
+// doKillChecked kills confirmed, the PIDs the user agreed to kill on k's
+// port, but only if they still own it. The owners are re-read with a
+// targeted lookup when s supports one, or a full scan otherwise.
+func doKillChecked(s scanner.Scanner, k portKey, confirmed []int) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), scanTimeout)
+		defer cancel()
+		current, err := currentPIDs(ctx, s, k)
+		if err != nil {
+			return killResultMsg{err: fmt.Errorf("re-checking %s: %w", k, err)}
+		}
+		if !ownsAll(current, confirmed) {
+			return killStaleMsg{port: k.port, confirmed: confirmed, current: current}
+		}
+		return doKill(confirmed)()
+	}
+}
+
+// currentPIDs returns the PIDs listening on k's port and protocol right now,
+// or nil if nothing is.
+func currentPIDs(ctx context.Context, s scanner.Scanner, k portKey) ([]int, error) {
+	if r, ok := s.(scanner.PortResolver); ok {
+		srv, err := r.ResolvePort(ctx, k.proto, k.port)
+		if errors.Is(err, scanner.ErrUnresolved) {
+			return nil, nil
+		}
+		return srv.AllPIDs(), err
+	}
+	servers, err := safeScan(ctx, s)
+	if err != nil && !errors.Is(err, scanner.ErrDegraded) {
+		return nil, err
+	}
+	for _, srv := range servers {
+		if !srv.IsSocket() && portKeyOf(srv) == k {
+			return srv.AllPIDs(), nil
+		}
+	}
+	return nil, nil
+}
+
+// ownsAll reports whether every PID in confirmed is among current. A kill
+// by PID confirms one of several owners, so extra owners are not a change.
+func ownsAll(current, confirmed []int) bool {
+	if len(confirmed) == 0 {
+		return false
+	}
+	for _, pid := range confirmed {
+		if !slices.Contains(current, pid) {
+			return false
+		}
+	}
+	return true
+}
+
+// doOpen opens http://localhost:port in the default browser.
+func doOpen(port int) tea.Cmd {
+	return func() tea.Msg {
+		url := fmt.Sprintf("http://localhost:%d", port)
+		return openResultMsg{port: port, err: openCommand(url).Start()}
+	}
+}
+
+// openStagger spaces out the launches of doOpenAll, since browsers asked to
+// open many URLs at the same instant tend to drop some of them.
+const openStagger = 200 * time.Millisecond
+
+// doOpenAll opens each port as doOpen does, one every openStagger.
+func doOpenAll(ports []int) tea.Cmd {
+	cmds := make([]tea.Cmd, len(ports))
+	for i, port := range ports {
+		open := doOpen(port)
+		cmds[i] = open
+		if i > 0 {
+			cmds[i] = tea.Tick(time.Duration(i)*openStagger, func(time.Time) tea.Msg { return open() })
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+func openCommand(url string) *exec.Cmd {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", url)
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	}
+	return exec.Command("xdg-open", url)
+}
+
+// doSaveConfig persists cfg to path. The config is cloned before the command
+// runs so later edits on the update loop cannot race with the write.
+func doSaveConfig(path string, cfg config.Config) tea.Cmd {
+	snapshot := cfg.Clone()
+	return func() tea.Msg {
+		return configSavedMsg{path: path, err: config.Save(path, snapshot)}
+	}
+}
+
+// doSaveSnapshot writes servers to path.
+func doSaveSnapshot(path string, servers []scanner.Server) tea.Cmd {
+	return func() tea.Msg {
+		return snapshotSavedMsg{path: path, err: scanner.SaveSnapshot(path, servers)}
+	}
+}
+
+// doReloadConfig rereads the config file at path.
+func doReloadConfig(path string) tea.Cmd {
+	return func() tea.Msg {
+		cfg, err := config.Load(path)
+		return configLoadedMsg{cfg: cfg, err: err}
+	}
+}
+
+// doCopy puts text on the system clipboard. what names it in the status bar.
+func doCopy(what, text string) tea.Cmd {
+	return func() tea.Msg {
+		return copyResultMsg{what: what, err: clipboard.WriteAll(text)}
+	}
+}
+
+// doEditConfig suspends the TUI and opens path in the user's editor. The
+// config is reloaded once the editor exits.
+func doEditConfig(path string) tea.Cmd {
+	return tea.ExecProcess(editorCommand(path), editorClosed)
+}
+
+func editorClosed(err error) tea.Msg {
+	return editorClosedMsg{err: err}
+}
+
+// editorCommand builds the command that edits path, using $EDITOR (which may
+// carry its own arguments, e.g. "code -w") and falling back to vi.
+func editorCommand(path string) *exec.Cmd {
+	args := strings.Fields(os.Getenv("EDITOR"))
+	if len(args) == 0 {
+		args = []string{"vi"}
+	}
+	return exec.Command(args[0], append(args[1:], path)...)
+}
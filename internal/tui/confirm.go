@@ -0,0 +1,98 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// confirmOverlay reports whether a kill or restart prompt should be shown as
+// a box over the dimmed list, rather than in the status bar.
+func (m Model) confirmOverlay() bool {
+	if m.config.QuickConfirm {
+		return false
+	}
+	switch m.mode {
+	case modeConfirmKill, modeConfirmRootKill, modeConfirmRestart:
+		return true
+	}
+	return false
+}
+
+// confirmLines spells out the action awaiting an answer and the server it
+// would hit.
+func (m Model) confirmLines() []string {
+	s, _ := m.selected()
+	if m.mode == modeConfirmRestart {
+		s = m.restarting
+	}
+	target := fmt.Sprintf("%s on :%d", s.Name(), s.Port)
+	command := s.Command
+	if w := m.detailValueWidth(); w > 0 {
+		command = truncate(command, w)
+	}
+	switch m.mode {
+	case modeConfirmRootKill:
+		return []string{
+			fmt.Sprintf("Running as root: %s may belong to another user.", formatPIDs(m.confirmPIDs)),
+			"",
+			target,
+			"Kill anyway?",
+		}
+	case modeConfirmRestart:
+		return []string{
+			fmt.Sprintf("Restart :%d?", s.Port),
+			"",
+			fmt.Sprintf("Stops %s and runs:", formatPIDs(s.AllPIDs())),
+			command,
+		}
+	}
+	return []string{
+		fmt.Sprintf("Kill %s?", formatPIDs(m.confirmPIDs)),
+		"",
+		target,
+		command,
+	}
+}
+
+// confirmView boxes the pending prompt and draws it over list.
+func (m Model) confirmView(list string) string {
+	lines := append(m.confirmLines(), "", "y: yes   any other key: cancel")
+	return m.floatOver(list, helpOverlayStyle.Render(strings.Join(lines, "\n")))
+}
+
+// floatOver draws box centered over list, which is dimmed so the box stands
+// out.
+func (m Model) floatOver(list, box string) string {
+	if m.width == 0 || m.height == 0 {
+		return box
+	}
+
+	bg := strings.Split(ansi.Strip(list), "\n")
+	for len(bg) < m.height {
+		bg = append(bg, "")
+	}
+	boxLines := strings.Split(box, "\n")
+	boxW := lipgloss.Width(box)
+	x := max((m.width-boxW)/2, 0)
+	y := max((len(bg)-len(boxLines))/2, 0)
+
+	var b strings.Builder
+	for i, line := range bg {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if i < y || i >= y+len(boxLines) {
+			b.WriteString(unhealthyStyle.Render(line))
+			continue
+		}
+		left := ansi.Cut(line, 0, x)
+		left += strings.Repeat(" ", x-ansi.StringWidth(left))
+		b.WriteString(unhealthyStyle.Render(left))
+		b.WriteString(boxLines[i-y])
+		b.WriteString(unhealthyStyle.Render(ansi.Cut(line, x+boxW, m.width)))
+	}
+	return b.String()
+}
@@ -0,0 +1,45 @@
+package tui
+
+import (
+	"slices"
+
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+// dedupeByPort collapses servers that share a protocol, port and PID, such as
+// one process bound to both 127.0.0.1 and [::], into the first such row. The
+// merged row lists every bind address in Addrs and is healthy if any of
+// its sockets answered.
+func dedupeByPort(servers []scanner.Server) []scanner.Server {
+	type key struct {
+		proto     string
+		port, pid int
+	}
+	index := make(map[key]int)
+	out := make([]scanner.Server, 0, len(servers))
+	for _, s := range servers {
+		if s.IsSocket() {
+			out = append(out, s)
+			continue
+		}
+		k := key{s.Proto(), s.Port, s.PID}
+		i, seen := index[k]
+		if !seen {
+			index[k] = len(out)
+			s.Addrs = slices.Clone(s.Addrs) // appended to below; keep the scan's own
+			out = append(out, s)
+			continue
+		}
+		merged := &out[i]
+		if len(merged.Addrs) == 0 {
+			merged.Addrs = []string{merged.Addr}
+		}
+		for _, addr := range s.BindAddrs() {
+			if !slices.Contains(merged.Addrs, addr) {
+				merged.Addrs = append(merged.Addrs, addr)
+			}
+		}
+		merged.Healthy = merged.Healthy || s.Healthy
+	}
+	return out
+}
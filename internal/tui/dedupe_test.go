@@ -0,0 +1,70 @@
+package tui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+func TestDedupeByPort(t *testing.T) {
+	servers := []scanner.Server{
+		{Port: 8080, Addr: "127.0.0.1", PID: 300, Process: "api"},
+		{Port: 8080, Addr: "[::]", PID: 300, Process: "api", Healthy: true},
+		{Port: 8080, Addr: "0.0.0.0", PID: 301, Process: "other"},
+		{Port: 3000, Addr: "127.0.0.1", PID: 100, Process: "node"},
+	}
+	got := dedupeByPort(servers)
+	if len(got) != 3 {
+		t.Fatalf("dedupeByPort() = %d rows, want 3: %+v", len(got), got)
+	}
+	merged := got[0]
+	if want := []string{"127.0.0.1", "[::]"}; !reflect.DeepEqual(merged.Addrs, want) {
+		t.Errorf("merged Addrs = %v, want %v", merged.Addrs, want)
+	}
+	if !merged.Healthy || !merged.Exposed() {
+		t.Errorf("merged row should be healthy and exposed via [::]: %+v", merged)
+	}
+	if got[1].PID != 301 || got[1].Addrs != nil {
+		t.Errorf("a different PID on the same port must stay separate: %+v", got[1])
+	}
+}
+
+func TestDedupeByPortKeepsProtocolsApart(t *testing.T) {
+	servers := []scanner.Server{
+		{Port: 5353, Addr: "0.0.0.0", PID: 400, Process: "mdns"},
+		{Port: 5353, Addr: "0.0.0.0", PID: 400, Process: "mdns", Protocol: scanner.ProtoUDP},
+		{Port: 5353, Addr: "[::]", PID: 400, Process: "mdns", Protocol: scanner.ProtoUDP},
+	}
+	got := dedupeByPort(servers)
+	if len(got) != 2 || got[0].Proto() != "TCP" || got[1].Proto() != scanner.ProtoUDP {
+		t.Fatalf("dedupeByPort() = %+v, want one TCP and one UDP row", got)
+	}
+	if got[0].Addrs != nil {
+		t.Errorf("the TCP row must not absorb UDP addresses: %v", got[0].Addrs)
+	}
+	if want := []string{"0.0.0.0", "[::]"}; !reflect.DeepEqual(got[1].Addrs, want) {
+		t.Errorf("UDP Addrs = %v, want %v", got[1].Addrs, want)
+	}
+}
+
+func TestDedupeByPortIsOptIn(t *testing.T) {
+	dual := []scanner.Server{
+		{Port: 8080, Addr: "127.0.0.1", PID: 300, Process: "api"},
+		{Port: 8080, Addr: "[::1]", PID: 300, Process: "api"},
+	}
+	m := newTestModel(t, dual)
+	if len(m.filtered) != 2 {
+		t.Fatalf("rows = %d, want 2 with dedupe_by_port off", len(m.filtered))
+	}
+	m.config.DedupeByPort = true
+	m.applyPipeline()
+	if len(m.filtered) != 1 {
+		t.Fatalf("rows = %d, want 1 with dedupe_by_port on", len(m.filtered))
+	}
+	m, _ = press(t, m, "i")
+	if !strings.Contains(m.View(), "Bind     127.0.0.1, [::1]") {
+		t.Errorf("detail overlay should list both addresses:\n%s", m.View())
+	}
+}
@@ -0,0 +1,68 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+// exposedServers returns the listed servers bound to a non-loopback address,
+// in list order.
+func (m Model) exposedServers() []scanner.Server {
+	var out []scanner.Server
+	for _, s := range m.servers {
+		if s.Exposed() {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// showExposedBanner opens the startup banner after the first scan when
+// exposed_banner is set and something listens beyond loopback. It is not
+// shown for snapshots, which are not live, or over another mode such as a
+// -filter-mode start.
+func (m *Model) showExposedBanner() {
+	if !m.config.ExposedBanner || m.snapshot != "" || m.mode != modeNormal {
+		return
+	}
+	if len(m.exposedServers()) > 0 {
+		m.mode = modeExposed
+	}
+}
+
+func (m Model) handleExposedKey(tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.mode = modeNormal
+	return m, nil
+}
+
+// exposedView lists the exposed servers in a box over the list, one line per
+// server with its bind addresses, as in ":8080  0.0.0.0  api".
+func (m Model) exposedView(list string) string {
+	servers := m.exposedServers()
+	noun := "ports listen"
+	if len(servers) == 1 {
+		noun = "port listens"
+	}
+	rows := make([][3]string, len(servers))
+	var portW, addrW int
+	for i, s := range servers {
+		rows[i] = [3]string{endpoint(s), strings.Join(s.BindAddrs(), ", "), s.Name()}
+		if s.Label != "" {
+			rows[i][2] += " (" + s.Label + ")"
+		}
+		portW = max(portW, utf8.RuneCountInString(rows[i][0]))
+		addrW = max(addrW, utf8.RuneCountInString(rows[i][1]))
+	}
+
+	lines := []string{titleStyle.Render(fmt.Sprintf("%d %s beyond loopback:", len(servers), noun)), ""}
+	for _, r := range rows {
+		lines = append(lines, fmt.Sprintf("%-*s  %-*s  %s", portW, r[0], addrW, r[1], r[2]))
+	}
+	lines = append(lines, "", "any key: dismiss")
+	return m.floatOver(list, helpOverlayStyle.Render(strings.Join(lines, "\n")))
+}
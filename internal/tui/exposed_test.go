@@ -0,0 +1,51 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jeramiahgcoffey/portview/internal/config"
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+func TestExposedBanner(t *testing.T) {
+	servers := []scanner.Server{
+		{Port: 3000, Addr: "127.0.0.1", PID: 100, Process: "node"},
+		{Port: 5432, Addr: "0.0.0.0", PID: 200, Process: "postgres"},
+	}
+	cfg := config.Default()
+	cfg.ExposedBanner = true
+	m := New(&scanner.MockScanner{Servers: servers}, cfg, Options{})
+	m = update(t, m, tea.WindowSizeMsg{Width: 80, Height: 20})
+	m = update(t, m, scanResultMsg{servers: servers})
+	if m.mode != modeExposed {
+		t.Fatalf("mode = %v after the first scan, want the exposed banner", m.mode)
+	}
+	view := m.View()
+	for _, want := range []string{"1 port listens beyond loopback", ":5432  0.0.0.0  postgres", "any key: dismiss"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("banner missing %q:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, ":3000  127.0.0.1") {
+		t.Errorf("banner should not list loopback servers:\n%s", view)
+	}
+
+	// Any key closes it without acting on the list.
+	m, cmd := press(t, m, "x")
+	if m.mode != modeNormal || cmd != nil {
+		t.Fatalf("x on the banner: mode = %v, cmd = %v; want it dismissed", m.mode, cmd)
+	}
+	m = update(t, m, scanResultMsg{servers: servers})
+	if m.mode != modeNormal {
+		t.Error("the banner should only open after the first scan")
+	}
+
+	m = New(&scanner.MockScanner{Servers: servers}, config.Default(), Options{})
+	m = update(t, m, scanResultMsg{servers: servers})
+	if m.mode != modeNormal {
+		t.Error("the banner should be off unless exposed_banner is set")
+	}
+}
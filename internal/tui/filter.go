@@ -0,0 +1,166 @@
+package tui
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jeramiahgcoffey/portview/internal/config"
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+// applyFilter narrows servers to those within portFilter and matching
+// filterText, and orders them for the current view mode. filterText is split
+// on whitespace and a server must match every term; a term starting with "!"
+// must not match, "since:5m" keeps only servers whose process started within
+// that long, and "/ipv4" or "/ipv6" keeps one address family. In the
+// favorites-only view only favorite ports are considered at all, whatever the
+// filter text. The cursor follows the selected server's port when it is still
+// listed, and is otherwise kept in bounds.
+func (m *Model) applyFilter() {
+	prev, hadPrev := m.selected()
+	terms, since := splitSince(strings.Fields(strings.ToLower(m.filterText)))
+	now := m.now()
+	m.filtered = nil
+	ranged := m.portFilter != (config.PortRange{})
+	for _, s := range m.servers {
+		if m.favorites && (s.IsSocket() || !m.config.IsFavorite(s.Port)) {
+			continue
+		}
+		if ranged && !m.portFilter.Contains(s.Port) {
+			continue
+		}
+		if since > 0 && (s.StartTime.IsZero() || now.Sub(s.StartTime) > since) {
+			continue
+		}
+		if matchesAll(s, terms) {
+			m.filtered = append(m.filtered, s)
+		}
+	}
+	m.viewMode.sortForGroups(m.filtered)
+	m.filtered = placeFrozen(m.filtered, m.frozen)
+	if hadPrev {
+		for i, s := range m.filtered {
+			if s.Port == prev.Port && s.SocketPath == prev.SocketPath {
+				m.cursor = i
+				break
+			}
+		}
+	}
+	if m.cursor >= len(m.filtered) {
+		m.cursor = len(m.filtered) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+}
+
+// splitSince removes "since:DURATION" terms from terms and returns the
+// last valid duration, or 0 if there is none. A term whose duration does
+// not parse, such as "since:5" while the unit is still being typed, is
+// dropped without filtering.
+func splitSince(terms []string) ([]string, time.Duration) {
+	var since time.Duration
+	rest := terms[:0]
+	for _, term := range terms {
+		v, ok := strings.CutPrefix(term, "since:")
+		if !ok {
+			rest = append(rest, term)
+			continue
+		}
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			since = d
+		}
+	}
+	return rest, since
+}
+
+// placeFrozen moves each frozen server to its recorded row, clamped to the
+// list length, leaving the others in order around them. Lower slots are
+// placed first so they land where they were frozen.
+func placeFrozen(servers []scanner.Server, frozen map[int]int) []scanner.Server {
+	if len(frozen) == 0 {
+		return servers
+	}
+	type pinned struct {
+		slot int
+		s    scanner.Server
+	}
+	var pins []pinned
+	rest := make([]scanner.Server, 0, len(servers))
+	for _, s := range servers {
+		if slot, ok := frozen[s.Port]; ok {
+			pins = append(pins, pinned{slot, s})
+		} else {
+			rest = append(rest, s)
+		}
+	}
+	sort.SliceStable(pins, func(i, j int) bool { return pins[i].slot < pins[j].slot })
+	for _, p := range pins {
+		i := min(p.slot, len(rest))
+		rest = slices.Insert(rest, i, p.s)
+	}
+	return rest
+}
+
+// matchesAll reports whether every term matches some field of s, with
+// "!"-prefixed terms inverted. A bare "!" is ignored so the filter does not
+// empty out while the user is still typing a negation.
+func matchesAll(s scanner.Server, terms []string) bool {
+	for _, term := range terms {
+		negate := false
+		if rest, ok := strings.CutPrefix(term, "!"); ok {
+			if rest == "" {
+				continue
+			}
+			term, negate = rest, true
+		}
+		if matchesFilter(s, term) == negate {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesFilter reports whether query (already lower-cased) appears in the
+// server's port or socket path, its raw or display process name, or its
+// label. A query ending in "/" names a label namespace instead; see
+// matchesNamespace. The tokens "/ipv4" and "/ipv6" match servers listening
+// on that address family. A query naming a protocol in full, "tcp", "udp" or
+// "unix", also matches the servers using it.
+func matchesFilter(s scanner.Server, query string) bool {
+	switch query {
+	case "/ipv4":
+		return s.IsIPv4()
+	case "/ipv6":
+		return s.IsIPv6()
+	}
+	if query == strings.ToLower(s.Proto()) {
+		return true
+	}
+	if strings.HasSuffix(query, "/") {
+		return matchesNamespace(strings.ToLower(s.Label), query)
+	}
+	port := strconv.Itoa(s.Port)
+	if s.IsSocket() {
+		port = strings.ToLower(s.SocketPath)
+	}
+	return strings.Contains(port, query) ||
+		strings.Contains(strings.ToLower(s.Process), query) ||
+		strings.Contains(strings.ToLower(s.DisplayName), query) ||
+		strings.Contains(strings.ToLower(s.Label), query)
+}
+
+// matchesNamespace reports whether label sits under the namespace ns, e.g.
+// "frontend/" for "frontend/web". The namespace must start the label or
+// follow a "/", so "ops/" does not match "devops/ci". A leading "/" on ns is
+// ignored, letting "/frontend/" be typed as written.
+func matchesNamespace(label, ns string) bool {
+	ns = strings.TrimPrefix(ns, "/")
+	if ns == "" {
+		return false
+	}
+	return strings.HasPrefix(label, ns) || strings.Contains(label, "/"+ns)
+}
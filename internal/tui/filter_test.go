@@ -0,0 +1,423 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeramiahgcoffey/portview/internal/config"
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+var filterServers = []scanner.Server{
+	{Port: 3000, PID: 10, Process: "node", Label: "frontend"},
+	{Port: 8080, PID: 20, Process: "node", Label: "api"},
+	{Port: 8081, PID: 30, Process: "python3", Label: "test-runner"},
+	{Port: 9229, PID: 40, Process: "node", Label: "test-debug"},
+}
+
+func filterPorts(t *testing.T, text string) []int {
+	t.Helper()
+	m := newTestModel(t, filterServers)
+	for _, s := range filterServers {
+		m.config.SetLabel(s.Port, s.Label)
+	}
+	m.applyPipeline()
+	m.filterText = text
+	m.applyFilter()
+	ports := make([]int, len(m.filtered))
+	for i, s := range m.filtered {
+		ports[i] = s.Port
+	}
+	return ports
+}
+
+func assertPorts(t *testing.T, text string, want ...int) {
+	t.Helper()
+	got := filterPorts(t, text)
+	if len(got) != len(want) {
+		t.Fatalf("filter %q = %v, want %v", text, got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("filter %q = %v, want %v", text, got, want)
+		}
+	}
+}
+
+func TestFilterSingleTerm(t *testing.T) {
+	assertPorts(t, "node", 3000, 8080, 9229)
+	assertPorts(t, "808", 8080, 8081)
+}
+
+func TestFilterTermsAreANDed(t *testing.T) {
+	assertPorts(t, "node 8080", 8080)
+	assertPorts(t, "  node   test ", 9229)
+}
+
+func TestFilterTermMatchingNothing(t *testing.T) {
+	assertPorts(t, "node ruby")
+}
+
+func TestFilterNegatedTerm(t *testing.T) {
+	assertPorts(t, "!node", 8081)
+	assertPorts(t, "!", 3000, 8080, 8081, 9229)
+}
+
+func TestFilterMixedPositiveAndNegatedTerms(t *testing.T) {
+	assertPorts(t, "node !test", 3000, 8080)
+	assertPorts(t, "test !python", 9229)
+}
+
+func TestSameProcessFilterToggles(t *testing.T) {
+	m := newTestModel(t, filterServers)
+	m, _ = press(t, m, "j")
+	m, _ = press(t, m, "p")
+	if m.mode != modeNormal || m.filterText != "node" {
+		t.Fatalf("after p: mode = %v, filterText = %q, want normal mode filtering node", m.mode, m.filterText)
+	}
+	if len(m.filtered) != 3 || m.filtered[m.cursor].Port != 8080 {
+		t.Fatalf("after p: filtered = %v, cursor = %d, want the three node ports with 8080 selected", m.filtered, m.cursor)
+	}
+	m, _ = press(t, m, "p")
+	if m.filterText != "" || len(m.filtered) != len(filterServers) {
+		t.Errorf("second p should restore the list, got filterText %q and %d rows", m.filterText, len(m.filtered))
+	}
+}
+
+func TestPortRangeFilterIsEphemeral(t *testing.T) {
+	m := newTestModel(t, filterServers)
+	m, _ = press(t, m, "f")
+	if m.mode != modeRange {
+		t.Fatalf("f: mode = %v, want range prompt", m.mode)
+	}
+	m = typeText(t, m, "8000-8999")
+	m, cmd := press(t, m, "enter")
+	if cmd != nil {
+		t.Error("a range filter should not save the config")
+	}
+	if got := portsOf(m.filtered); !slices.Equal(got, []int{8080, 8081}) {
+		t.Fatalf("range 8000-8999 = %v, want [8080 8081]", got)
+	}
+	if m.config.PortRange != config.Default().PortRange {
+		t.Error("range filter must not touch the configured port range")
+	}
+
+	// It layers under the text filter, and esc peels the two off in turn.
+	m, _ = press(t, m, "/")
+	m = typeText(t, m, "python")
+	m, _ = press(t, m, "enter")
+	if got := portsOf(m.filtered); !slices.Equal(got, []int{8081}) {
+		t.Fatalf("range plus text filter = %v, want [8081]", got)
+	}
+	m, _ = press(t, m, "esc")
+	if got := portsOf(m.filtered); !slices.Equal(got, []int{8080, 8081}) {
+		t.Fatalf("first esc should drop the text filter, got %v", got)
+	}
+	m, _ = press(t, m, "esc")
+	if len(m.filtered) != len(filterServers) {
+		t.Errorf("second esc should clear the range, got %v", portsOf(m.filtered))
+	}
+}
+
+func TestPortRangeFilterRejectsBadInput(t *testing.T) {
+	m := newTestModel(t, filterServers)
+	m, _ = press(t, m, "f")
+	m = typeText(t, m, "9-1")
+	m, _ = press(t, m, "enter")
+	if m.status == "" || len(m.filtered) != len(filterServers) {
+		t.Errorf("bad range: status = %q, %d rows; want an error and the full list", m.status, len(m.filtered))
+	}
+}
+
+func portsOf(servers []scanner.Server) []int {
+	var ports []int
+	for _, s := range servers {
+		ports = append(ports, s.Port)
+	}
+	return ports
+}
+
+func TestFrozenRowKeepsPosition(t *testing.T) {
+	cfg := config.Default()
+	cfg.DefaultSort = "pid"
+	m := New(&scanner.MockScanner{}, cfg, Options{})
+	m = update(t, m, scanResultMsg{servers: []scanner.Server{
+		{Port: 3000, PID: 1}, {Port: 5432, PID: 2}, {Port: 8080, PID: 3},
+	}})
+	m, _ = press(t, m, "j")
+	m, _ = press(t, m, "z")
+	if m.status != "froze :5432 at row 2" {
+		t.Fatalf("status = %q", m.status)
+	}
+
+	// Restarts reshuffle the PID order; 5432 stays on row 2.
+	m = update(t, m, scanResultMsg{servers: []scanner.Server{
+		{Port: 3000, PID: 9}, {Port: 5432, PID: 1}, {Port: 8080, PID: 4}, {Port: 9000, PID: 5},
+	}})
+	if got, want := portsOf(m.filtered), []int{8080, 5432, 9000, 3000}; !slices.Equal(got, want) {
+		t.Fatalf("order after reshuffle = %v, want %v", got, want)
+	}
+	if m.filtered[m.cursor].Port != 5432 {
+		t.Errorf("cursor on :%d, want it to stay on the frozen row", m.filtered[m.cursor].Port)
+	}
+	if !strings.Contains(m.View(), ">*5432") {
+		t.Errorf("frozen row should carry a marker:\n%s", m.View())
+	}
+
+	m, _ = press(t, m, "z")
+	if got, want := portsOf(m.filtered), []int{5432, 8080, 9000, 3000}; !slices.Equal(got, want) {
+		t.Errorf("order after unfreezing = %v, want %v", got, want)
+	}
+}
+
+func TestPlaceFrozenClampsSlot(t *testing.T) {
+	servers := []scanner.Server{{Port: 1}, {Port: 2}, {Port: 3}}
+	got := portsOf(placeFrozen(servers, map[int]int{1: 10}))
+	if want := []int{2, 3, 1}; !slices.Equal(got, want) {
+		t.Errorf("placeFrozen() = %v, want %v", got, want)
+	}
+}
+
+func TestDisplayNames(t *testing.T) {
+	cfg := config.Default()
+	cfg.CollapseVersions = true
+	m := New(&scanner.MockScanner{}, cfg, Options{})
+	m = update(t, m, scanResultMsg{servers: []scanner.Server{
+		{Port: 3000, PID: 100, Process: "node (deleted)"},
+		{Port: 8000, PID: 200, Process: "python3.11"},
+	}})
+	if row := m.renderRow(m.filtered[1], false); !strings.Contains(row, "python ") || strings.Contains(row, "3.11") {
+		t.Errorf("row = %q, want the collapsed name", row)
+	}
+	if row := m.renderRow(m.filtered[0], false); strings.Contains(row, "deleted") {
+		t.Errorf("row = %q, want (deleted) dropped", row)
+	}
+	if m.filtered[1].Process != "python3.11" {
+		t.Errorf("Process = %q, want the raw name kept", m.filtered[1].Process)
+	}
+
+	for _, text := range []string{"python3.11", "python"} {
+		m.filterText = text
+		m.applyFilter()
+		if len(m.filtered) != 1 || m.filtered[0].Port != 8000 {
+			t.Errorf("filter %q = %+v, want :8000 by raw or display name", text, m.filtered)
+		}
+	}
+}
+
+func TestFilterLabelNamespace(t *testing.T) {
+	m := newTestModel(t, []scanner.Server{
+		{Port: 3000, Process: "node"},
+		{Port: 3001, Process: "node"},
+		{Port: 8080, Process: "go"},
+		{Port: 8081, Process: "go"},
+	})
+	m.config.SetLabel(3000, "frontend/web")
+	m.config.SetLabel(3001, "frontend/admin")
+	m.config.SetLabel(8080, "api/users")
+	m.config.SetLabel(8081, "api/frontend-proxy")
+	m.applyPipeline()
+
+	for _, tt := range []struct {
+		text string
+		want []int
+	}{
+		{"/frontend/", []int{3000, 3001}},
+		{"frontend/", []int{3000, 3001}},
+		{"api/", []int{8080, 8081}},
+		{"front", []int{3000, 3001, 8081}},
+		{"!frontend/", []int{8080, 8081}},
+	} {
+		m.filterText = tt.text
+		m.applyFilter()
+		var got []int
+		for _, s := range m.filtered {
+			got = append(got, s.Port)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("filter %q = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestMatchesNamespace(t *testing.T) {
+	tests := []struct {
+		label, ns string
+		want      bool
+	}{
+		{"frontend/web", "frontend/", true},
+		{"team/frontend/web", "frontend/", true},
+		{"frontend", "frontend/", false},
+		{"devops/ci", "ops/", false},
+		{"frontend/web", "/", false},
+	}
+	for _, tt := range tests {
+		if got := matchesNamespace(tt.label, tt.ns); got != tt.want {
+			t.Errorf("matchesNamespace(%q, %q) = %v, want %v", tt.label, tt.ns, got, tt.want)
+		}
+	}
+}
+
+func TestFilterBarShowsMatchCount(t *testing.T) {
+	m := newTestModel(t, filterServers)
+	m, _ = press(t, m, "/")
+	if strings.Contains(m.View(), "match") {
+		t.Errorf("an empty filter should not show a count:\n%s", m.View())
+	}
+	for _, tt := range []struct{ typed, want string }{
+		{"nod", "Filter: nod▏ (3 matches)"},
+		{"e 9", "Filter: node 9▏ (1 match)"},
+		{"x", "Filter: node 9x▏ (0 matches)"},
+	} {
+		m = typeText(t, m, tt.typed)
+		if !strings.Contains(m.View(), tt.want) {
+			t.Errorf("after typing %q, filter bar missing %q:\n%s", tt.typed, tt.want, m.View())
+		}
+	}
+	m, _ = press(t, m, "backspace")
+	m, _ = press(t, m, "enter")
+	if !strings.Contains(m.View(), "Filter: node 9 (1 match)") {
+		t.Errorf("an applied filter should keep its count:\n%s", m.View())
+	}
+}
+
+func TestFilterSince(t *testing.T) {
+	clock := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	servers := []scanner.Server{
+		{Port: 3000, Process: "node", StartTime: clock.Add(-2 * time.Minute)},
+		{Port: 5432, Process: "postgres", StartTime: clock.Add(-3 * time.Hour)},
+		{Port: 8080, Process: "node", StartTime: clock.Add(-4 * time.Minute)},
+		{Port: 9000, Process: "node"}, // start time unknown
+	}
+	m := newTestModel(t, servers)
+	m.now = fixedClock(&clock)
+	ports := func(text string) []int {
+		m.filterText = text
+		m.applyFilter()
+		var out []int
+		for _, s := range m.filtered {
+			out = append(out, s.Port)
+		}
+		return out
+	}
+	tests := []struct {
+		text string
+		want []int
+	}{
+		{"since:5m", []int{3000, 8080}},
+		{"since:3m", []int{3000}},
+		{"since:5m 8080", []int{8080}},
+		{"SINCE:4h", []int{3000, 5432, 8080}},
+		{"since:5", []int{3000, 5432, 8080, 9000}}, // unit not typed yet
+	}
+	for _, tc := range tests {
+		if got := ports(tc.text); !slices.Equal(got, tc.want) {
+			t.Errorf("filter %q = %v, want %v", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestFilterAddressFamily(t *testing.T) {
+	servers := []scanner.Server{
+		{Port: 3000, Addr: "127.0.0.1", Process: "node"},
+		{Port: 5173, Addr: "[::1]", Process: "vite"},
+		{Port: 8080, Addr: "0.0.0.0", Addrs: []string{"0.0.0.0", "[::]"}, Process: "api"},
+		{Port: 9000, Addr: "*", Process: "php"},
+		{SocketPath: "/run/ipv6.sock", Process: "app"},
+	}
+	m := newTestModel(t, servers)
+	tests := []struct {
+		text string
+		want []int
+	}{
+		{"/ipv4", []int{3000, 8080, 9000}},
+		{"/ipv6", []int{5173, 8080, 9000}},
+		{"/IPv6 !/ipv4", []int{5173}},
+		{"/ipv4 /ipv6", []int{8080, 9000}},
+		{"/ipv6 vite", []int{5173}},
+	}
+	for _, tc := range tests {
+		m.filterText = tc.text
+		m.applyFilter()
+		if got := portsOf(m.filtered); !slices.Equal(got, tc.want) {
+			t.Errorf("filter %q = %v, want %v", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestFilterProtocol(t *testing.T) {
+	servers := []scanner.Server{
+		{Port: 53, Addr: "127.0.0.53", Protocol: scanner.ProtoUDP, Process: "systemd-resolve"},
+		{Port: 53, Addr: "127.0.0.53", Process: "systemd-resolve"},
+		{Port: 8080, Addr: "127.0.0.1", Process: "updater"},
+	}
+	m := newTestModel(t, servers)
+	tests := []struct {
+		text string
+		want []int
+	}{
+		{"udp", []int{53}},
+		{"tcp", []int{53, 8080}},
+		{"!udp", []int{53, 8080}},
+		{"up", []int{8080}}, // a partial protocol is just text
+	}
+	for _, tc := range tests {
+		m.filterText = tc.text
+		m.applyFilter()
+		if got := portsOf(m.filtered); !slices.Equal(got, tc.want) {
+			t.Errorf("filter %q = %v, want %v", tc.text, got, tc.want)
+		}
+	}
+	m.filterText = "udp"
+	m.applyFilter()
+	if len(m.filtered) != 1 || m.filtered[0].Protocol != scanner.ProtoUDP {
+		t.Errorf("filter udp = %+v, want only the UDP socket", m.filtered)
+	}
+}
+
+func TestFavoritesOnly(t *testing.T) {
+	servers := []scanner.Server{
+		{Port: 3000, Process: "node"},
+		{Port: 5432, Process: "postgres"},
+		{Port: 8080, Process: "node"},
+		{SocketPath: "/run/node.sock", Process: "node"},
+	}
+	cfg := config.Default()
+	cfg.Favorites = []int{3000, 8080}
+	m := New(&scanner.MockScanner{Servers: servers}, cfg, Options{Favorites: true})
+	m = update(t, m, scanResultMsg{servers: servers})
+	for _, text := range []string{"", "node", "postgres", "!node", "tcp"} {
+		m.filterText = text
+		m.applyFilter()
+		for _, s := range m.filtered {
+			if !cfg.IsFavorite(s.Port) || s.IsSocket() {
+				t.Errorf("filter %q listed %+v in the favorites-only view", text, s)
+			}
+		}
+	}
+	m.filterText = ""
+	m.applyFilter()
+	if got := portsOf(m.filtered); !slices.Equal(got, []int{3000, 8080}) {
+		t.Fatalf("favorites-only list = %v, want [3000 8080]", got)
+	}
+
+	m, _ = press(t, m, "F")
+	if got := portsOf(m.filtered); len(got) != len(servers) {
+		t.Errorf("F should show the full list, got %v", got)
+	}
+	m.cursor = 1 // 5432
+	m, _ = press(t, m, "m")
+	m, _ = press(t, m, "F")
+	if got := portsOf(m.filtered); !slices.Equal(got, []int{3000, 5432, 8080}) {
+		t.Errorf("after marking 5432, favorites-only list = %v", got)
+	}
+
+	cfg.StartFavorites = true
+	m = New(&scanner.MockScanner{Servers: servers}, cfg, Options{})
+	m = update(t, m, scanResultMsg{servers: servers})
+	if len(m.filtered) != 2 {
+		t.Errorf("start_favorites should open the favorites-only view, got %v", portsOf(m.filtered))
+	}
+}
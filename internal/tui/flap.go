@@ -0,0 +1,86 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+// A port is flapping once it has appeared or disappeared more than
+// flapThreshold times within flapWindow, as a crash-looping service does.
+const (
+	flapThreshold = 3
+	flapWindow    = 2 * time.Minute
+)
+
+var flappingStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("208"))
+
+// recordFlaps notes each port that appeared or disappeared between the scans
+// prev and m.scanned, forgetting changes older than flapWindow. The first
+// scan only starts the record, since every port would look new.
+func (m *Model) recordFlaps(prev []scanner.Server) {
+	if m.flaps == nil {
+		m.flaps = make(map[portKey][]time.Time)
+		return
+	}
+	now := m.lastRefresh
+	before, after := listeningPorts(prev), listeningPorts(m.scanned)
+	for k := range before {
+		if !after[k] {
+			m.flaps[k] = append(m.flaps[k], now)
+		}
+	}
+	for k := range after {
+		if !before[k] {
+			m.flaps[k] = append(m.flaps[k], now)
+		}
+	}
+	for k, times := range m.flaps {
+		times = slices.DeleteFunc(times, func(t time.Time) bool { return now.Sub(t) > flapWindow })
+		if len(times) == 0 {
+			delete(m.flaps, k)
+			continue
+		}
+		m.flaps[k] = times
+	}
+}
+
+func listeningPorts(servers []scanner.Server) map[portKey]bool {
+	ports := make(map[portKey]bool, len(servers))
+	for _, s := range servers {
+		if !s.IsSocket() {
+			ports[portKeyOf(s)] = true
+		}
+	}
+	return ports
+}
+
+// flapping reports whether k has come and gone often enough lately to look
+// like a crash loop.
+func (m Model) flapping(k portKey) bool {
+	return len(m.flaps[k]) > flapThreshold
+}
+
+// flapNote names the flapping ports for the status bar, e.g.
+// "flapping: :3000, :8080", or returns "" if none are.
+func (m Model) flapNote() string {
+	var keys []portKey
+	for k := range m.flaps {
+		if m.flapping(k) {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	slices.SortFunc(keys, comparePortKeys)
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = k.String()
+	}
+	return "flapping: " + strings.Join(names, ", ")
+}
@@ -0,0 +1,45 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeramiahgcoffey/portview/internal/config"
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+func TestFlappingPort(t *testing.T) {
+	up := []scanner.Server{{Port: 3000, PID: 100, Process: "node"}, {Port: 8080, PID: 300, Process: "go"}}
+	down := []scanner.Server{{Port: 8080, PID: 300, Process: "go"}}
+	seq := &sequenceScanner{results: [][]scanner.Server{up, down, up, down, up, up}}
+	clock := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	m := New(seq, config.Default(), Options{})
+	m.now = fixedClock(&clock)
+
+	// The first scan only starts the record; each later one toggles :3000.
+	for i := range 5 {
+		if m.flapping(portKey{"TCP", 3000}) {
+			t.Fatalf("flapping after %d scans, want it only past %d changes", i, flapThreshold)
+		}
+		clock = clock.Add(10 * time.Second)
+		m = update(t, m, doScan(seq, m.now)())
+	}
+	if !m.flapping(portKey{"TCP", 3000}) || m.flapping(portKey{"TCP", 8080}) {
+		t.Fatalf("after four changes: flapping(3000) = %v, flapping(8080) = %v", m.flapping(portKey{"TCP", 3000}), m.flapping(portKey{"TCP", 8080}))
+	}
+	view := m.View()
+	if !strings.Contains(view, "flapping: :3000") {
+		t.Errorf("status bar should name the flapping port:\n%s", view)
+	}
+	if row := m.renderRow(m.filtered[0], false); !strings.Contains(row, "flapping") {
+		t.Errorf("row = %q, want it marked flapping", row)
+	}
+
+	// Once the changes age out of the window, the port settles.
+	clock = clock.Add(flapWindow)
+	m = update(t, m, doScan(seq, m.now)())
+	if m.flapping(portKey{"TCP", 3000}) || strings.Contains(m.View(), "flapping") {
+		t.Errorf("a port steady for %s should no longer be flapping", flapWindow)
+	}
+}
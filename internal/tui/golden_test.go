@@ -0,0 +1,68 @@
+package tui
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+
+	"github.com/jeramiahgcoffey/portview/internal/config"
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+// updateGolden reports whether -update was passed. The flag itself is
+// registered by x/exp/golden, which teatest pulls in; defining it here too
+// would panic at init.
+func updateGolden() bool {
+	f := flag.Lookup("update")
+	return f != nil && f.Value.String() == "true"
+}
+
+// assertGolden compares got with testdata/name.golden, rewriting the file
+// instead when -update is set.
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if updateGolden() {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -update to create it): %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s mismatch (run with -update to accept):\n--- got ---\n%s\n--- want ---\n%s", path, got, want)
+	}
+}
+
+// fixedClock returns a now func reading *t, so tests can move time forward.
+func fixedClock(t *time.Time) func() time.Time {
+	return func() time.Time { return *t }
+}
+
+func TestViewGolden(t *testing.T) {
+	lipgloss.SetColorProfile(termenv.Ascii)
+
+	clock := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	cfg := config.Default()
+	cfg.SetLabel(3000, "frontend")
+	m := New(&scanner.MockScanner{}, cfg, Options{})
+	m.now = fixedClock(&clock)
+	m = update(t, m, tea.WindowSizeMsg{Width: 100, Height: 20})
+	m = update(t, m, scanResultMsg{startedAt: clock.Add(-85 * time.Millisecond), completedAt: clock, servers: []scanner.Server{
+		{Port: 3000, Addr: "127.0.0.1", PID: 100, Process: "node", Command: "node server.js", Healthy: true},
+		{Port: 5432, Addr: "0.0.0.0", PID: 200, Process: "postgres", Command: "postgres -D /var/lib/postgresql", Healthy: true},
+		{Port: 8080, Addr: "*", PID: 300, PIDs: []int{300, 301}, Process: "api", Command: "./api --listen :8080", Healthy: false},
+	}})
+	clock = clock.Add(2500 * time.Millisecond)
+
+	assertGolden(t, "view", m.View())
+}
@@ -0,0 +1,55 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// goneServer is a labelled port that was seen earlier in the session but is
+// not listening now.
+type goneServer struct {
+	port  int
+	label string
+	since time.Time // last scan it was seen in
+}
+
+// recordSeen stamps every port in the last scan with the scan time.
+func (m *Model) recordSeen() {
+	if m.lastSeen == nil {
+		m.lastSeen = make(map[int]time.Time)
+	}
+	for _, s := range m.scanned {
+		if s.IsSocket() {
+			continue
+		}
+		m.lastSeen[s.Port] = m.lastRefresh
+	}
+}
+
+// gone returns the labelled, unhidden ports that have dropped out of the
+// scan since they were last seen, in port order.
+func (m Model) gone() []goneServer {
+	var out []goneServer
+	for port, label := range m.config.Labels {
+		seen, ok := m.lastSeen[port]
+		if !ok || !seen.Before(m.lastRefresh) || m.config.IsHidden(port) {
+			continue
+		}
+		out = append(out, goneServer{port: port, label: label, since: seen})
+	}
+	slices.SortFunc(out, func(a, b goneServer) int { return a.port - b.port })
+	return out
+}
+
+// formatDown renders how long a port has been gone, e.g. "45s", "3m" or
+// "2h5m".
+func formatDown(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+}
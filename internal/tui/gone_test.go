@@ -0,0 +1,62 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeramiahgcoffey/portview/internal/config"
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+func TestGoneLabelledPortShowsDownDuration(t *testing.T) {
+	seq := &sequenceScanner{results: [][]scanner.Server{
+		{{Port: 3000, PID: 100, Process: "node"}, {Port: 8080, PID: 300, Process: "go"}},
+		{{Port: 8080, PID: 300, Process: "go"}},
+	}}
+	cfg := config.Default()
+	cfg.SetLabel(3000, "web")
+	cfg.SetLabel(9999, "never-seen")
+	clock := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	m := New(seq, cfg, Options{})
+	m.now = fixedClock(&clock)
+
+	m = update(t, m, doScan(seq, time.Now)())
+	if len(m.gone()) != 0 {
+		t.Fatalf("nothing should be gone after the first scan, got %+v", m.gone())
+	}
+
+	var prev time.Duration
+	for _, step := range []time.Duration{time.Minute, 2 * time.Minute} {
+		clock = clock.Add(step)
+		m = update(t, m, doScan(seq, time.Now)())
+		gone := m.gone()
+		if len(gone) != 1 || gone[0].port != 3000 {
+			t.Fatalf("gone = %+v, want only :3000", gone)
+		}
+		down := m.now().Sub(gone[0].since)
+		if down <= prev {
+			t.Errorf("down duration %s did not grow past %s", down, prev)
+		}
+		prev = down
+	}
+	if view := m.View(); !strings.Contains(view, "down for 3m") {
+		t.Errorf("view missing the down duration:\n%s", view)
+	}
+}
+
+func TestFormatDown(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{45 * time.Second, "45s"},
+		{3*time.Minute + 20*time.Second, "3m"},
+		{2*time.Hour + 5*time.Minute, "2h5m"},
+	}
+	for _, tt := range tests {
+		if got := formatDown(tt.d); got != tt.want {
+			t.Errorf("formatDown(%s) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+// viewMode is how the server list is laid out.
+type viewMode int
+
+const (
+	viewFlat    viewMode = iota // one row per server, by port
+	viewByPID                   // grouped under the owning process
+	viewByLabel                 // grouped under the label; unlabelled last
+)
+
+var viewModeNames = []string{"flat", "pid", "label"}
+
+func (v viewMode) String() string {
+	return viewModeNames[v]
+}
+
+// parseViewMode maps a config view_mode to a viewMode. Unknown or empty
+// values give viewFlat.
+func parseViewMode(s string) viewMode {
+	for i, name := range viewModeNames {
+		if s == name {
+			return viewMode(i)
+		}
+	}
+	return viewFlat
+}
+
+// next cycles flat → pid → label → flat.
+func (v viewMode) next() viewMode {
+	return (v + 1) % viewMode(len(viewModeNames))
+}
+
+// groupHeading names the group s belongs to, or "" in flat mode.
+func (v viewMode) groupHeading(s scanner.Server) string {
+	switch v {
+	case viewByPID:
+		if s.PID == 0 {
+			return "unknown process"
+		}
+		return fmt.Sprintf("PID %d · %s", s.PID, s.Name())
+	case viewByLabel:
+		if s.Label == "" {
+			return "unlabelled"
+		}
+		return s.Label
+	}
+	return ""
+}
+
+// sortForGroups orders servers so each group is contiguous, keeping port
+// order within a group. Flat mode leaves the scanner's port order alone.
+func (v viewMode) sortForGroups(servers []scanner.Server) {
+	switch v {
+	case viewByPID:
+		sort.SliceStable(servers, func(i, j int) bool {
+			a, b := servers[i].PID, servers[j].PID
+			// Unresolved PIDs sort last.
+			if (a == 0) != (b == 0) {
+				return b == 0
+			}
+			return a < b
+		})
+	case viewByLabel:
+		sort.SliceStable(servers, func(i, j int) bool {
+			a, b := servers[i].Label, servers[j].Label
+			if (a == "") != (b == "") {
+				return b == ""
+			}
+			return a < b
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jeramiahgcoffey/portview/internal/config"
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+func TestNewSeedsViewModeFromConfig(t *testing.T) {
+	for mode, want := range map[string]viewMode{"pid": viewByPID, "label": viewByLabel, "flat": viewFlat, "": viewFlat, "tree": viewFlat} {
+		cfg := config.Default()
+		cfg.ViewMode = mode
+		if got := New(&scanner.MockScanner{}, cfg, Options{}).viewMode; got != want {
+			t.Errorf("view_mode %q: viewMode = %v, want %v", mode, got, want)
+		}
+	}
+}
+
+func TestToggleViewModePersists(t *testing.T) {
+	m := newTestModel(t, filterServers)
+	m, cmd := press(t, m, "g")
+	if m.viewMode != viewByPID || m.config.ViewMode != "pid" || cmd == nil {
+		t.Fatalf("g: viewMode = %v, config = %q, want pid and a save", m.viewMode, m.config.ViewMode)
+	}
+	flushSave(t, m, cmd)
+	saved, err := config.Load(m.configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.ViewMode != "pid" {
+		t.Errorf("saved view_mode = %q, want pid", saved.ViewMode)
+	}
+}
+
+func TestGroupByLabelView(t *testing.T) {
+	servers := []scanner.Server{
+		{Port: 3000, PID: 1, Process: "node", Label: "web"},
+		{Port: 5432, PID: 2, Process: "postgres"},
+		{Port: 8080, PID: 3, Process: "api", Label: "api"},
+		{Port: 9229, PID: 1, Process: "node", Label: "web"},
+	}
+	m := newTestModel(t, servers)
+	for _, s := range servers {
+		m.config.SetLabel(s.Port, s.Label)
+	}
+	m.viewMode = viewByLabel
+	m.applyPipeline()
+
+	var ports []int
+	for _, s := range m.filtered {
+		ports = append(ports, s.Port)
+	}
+	if want := []int{8080, 3000, 9229, 5432}; !slices.Equal(ports, want) {
+		t.Fatalf("label order = %v, want %v", ports, want)
+	}
+	view := m.View()
+	api, web, none := strings.Index(view, "── api"), strings.Index(view, "── web"), strings.Index(view, "── unlabelled")
+	if api < 0 || web < api || none < web {
+		t.Errorf("group headings missing or out of order:\n%s", view)
+	}
+}
+
+func TestGroupedViewFitsHeight(t *testing.T) {
+	m := newTestModel(t, filterServers)
+	m.viewMode = viewByPID
+	m.applyFilter()
+	m = update(t, m, tea.WindowSizeMsg{Width: 80, Height: chromeLines + 3})
+	m.cursor = len(m.filtered) - 1
+	lines, cursorLine := m.listLines()
+	start, end := m.visibleRange(len(lines), cursorLine)
+	if end-start != 3 || cursorLine < start || cursorLine >= end {
+		t.Errorf("window [%d,%d) of %d lines should hold 3 lines including the cursor at %d", start, end, len(lines), cursorLine)
+	}
+	if got := strings.Count(m.View(), "\n") + 1; got > chromeLines+3 {
+		t.Errorf("view is %d lines, want at most %d", got, chromeLines+3)
+	}
+}
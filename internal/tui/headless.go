@@ -0,0 +1,243 @@
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jeramiahgcoffey/portview/internal/config"
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+// ScanOnce runs a single scan and applies the same hidden-port and label
+// pipeline as the interactive view. It backs the non-interactive flags. Like
+// Scan, it returns the servers along with scanner.ErrDegraded when no
+// process could be resolved.
+func ScanOnce(ctx context.Context, s scanner.Scanner, cfg config.Config) ([]scanner.Server, error) {
+	servers, err := safeScan(ctx, s)
+	if err != nil && !errors.Is(err, scanner.ErrDegraded) {
+		return nil, err
+	}
+	if cfg.DedupeByPort {
+		servers = dedupeByPort(servers)
+	}
+	servers = mergeLabels(filterHidden(servers, cfg), cfg)
+	normalizeNames(servers, cfg)
+	return servers, err
+}
+
+// CountListening returns how many servers ScanOnce would report, along with
+// its scanner.ErrDegraded warning if any.
+func CountListening(ctx context.Context, s scanner.Scanner, cfg config.Config) (int, error) {
+	servers, err := ScanOnce(ctx, s, cfg)
+	if err != nil && !errors.Is(err, scanner.ErrDegraded) {
+		return 0, err
+	}
+	return len(servers), err
+}
+
+// FormatSummary renders one aligned line per server, sorted by port:
+//
+//	:8080  node  web-api  ✓
+func FormatSummary(servers []scanner.Server) string {
+	sorted := make([]scanner.Server, len(servers))
+	copy(sorted, servers)
+	sort.SliceStable(sorted, func(i, j int) bool { return portLess(sorted[i], sorted[j]) })
+
+	var portW, procW, labelW int
+	for _, s := range sorted {
+		portW = max(portW, utf8.RuneCountInString(endpoint(s)))
+		procW = max(procW, utf8.RuneCountInString(s.Process))
+		labelW = max(labelW, utf8.RuneCountInString(s.Label))
+	}
+
+	var b strings.Builder
+	for _, s := range sorted {
+		mark := "✗"
+		if s.Healthy {
+			mark = "✓"
+		}
+		fmt.Fprintf(&b, "%-*s  %-*s  %-*s  %s\n",
+			portW, endpoint(s),
+			procW, s.Process,
+			labelW, s.Label,
+			mark)
+	}
+	return b.String()
+}
+
+// Suspicious returns the servers whose executable lies under one of cfg's
+// suspicious_paths, for --audit.
+func Suspicious(servers []scanner.Server, cfg config.Config) []scanner.Server {
+	var out []scanner.Server
+	for _, s := range servers {
+		if cfg.SuspiciousExe(s.ExePath) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// FormatAudit renders one aligned line per flagged server, sorted by port:
+//
+//	:4444  PID 812  /tmp/.x/miner
+func FormatAudit(servers []scanner.Server) string {
+	sorted := slices.Clone(servers)
+	sort.SliceStable(sorted, func(i, j int) bool { return portLess(sorted[i], sorted[j]) })
+
+	var portW, pidW int
+	for _, s := range sorted {
+		portW = max(portW, utf8.RuneCountInString(endpoint(s)))
+		pidW = max(pidW, len(fmt.Sprintf("PID %d", s.PID)))
+	}
+
+	var b strings.Builder
+	for _, s := range sorted {
+		fmt.Fprintf(&b, "%-*s  %-*s  %s\n",
+			portW, endpoint(s),
+			pidW, fmt.Sprintf("PID %d", s.PID),
+			s.ExePath)
+	}
+	return b.String()
+}
+
+// FormatLabels renders each labelled port as "name  localhost:port", sorted
+// by name and aligned, for routing notes or proxy configs:
+//
+//	api  localhost:8080
+//	web  localhost:3000
+func FormatLabels(labels map[int]string) string {
+	ports := make([]int, 0, len(labels))
+	nameW := 0
+	for port, name := range labels {
+		ports = append(ports, port)
+		nameW = max(nameW, utf8.RuneCountInString(name))
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		a, b := labels[ports[i]], labels[ports[j]]
+		if a != b {
+			return a < b
+		}
+		return ports[i] < ports[j]
+	})
+
+	var b strings.Builder
+	for _, port := range ports {
+		fmt.Fprintf(&b, "%-*s  localhost:%d\n", nameW, labels[port], port)
+	}
+	return b.String()
+}
+
+// FormatDiff renders d one aligned line per port: added ports marked "+",
+// removed ones "-", and changed ones "~" with the old and new owner, as in
+// "~ :8080  api (PID 300) → api (PID 310)". An empty diff is "no changes".
+func FormatDiff(d scanner.Diff) string {
+	if d.Empty() {
+		return "no changes\n"
+	}
+	portW := 0
+	for _, s := range slices.Concat(d.Added, d.Removed) {
+		portW = max(portW, utf8.RuneCountInString(endpoint(s)))
+	}
+	for _, c := range d.Changed {
+		portW = max(portW, utf8.RuneCountInString(endpoint(c.After)))
+	}
+	owner := func(s scanner.Server) string { return fmt.Sprintf("%s (PID %d)", s.Process, s.PID) }
+
+	var b strings.Builder
+	for _, s := range d.Added {
+		fmt.Fprintf(&b, "+ %-*s  %s\n", portW, endpoint(s), owner(s))
+	}
+	for _, s := range d.Removed {
+		fmt.Fprintf(&b, "- %-*s  %s\n", portW, endpoint(s), owner(s))
+	}
+	for _, c := range d.Changed {
+		fmt.Fprintf(&b, "~ %-*s  %s → %s\n", portW, endpoint(c.After), owner(c.Before), owner(c.After))
+	}
+	return b.String()
+}
+
+// jsonFields maps the names --json-fields accepts, the same as the keys of a
+// full --json record, to the value each one reads.
+var jsonFields = map[string]func(scanner.Server) any{
+	"port":         func(s scanner.Server) any { return s.Port },
+	"addr":         func(s scanner.Server) any { return s.Addr },
+	"addrs":        func(s scanner.Server) any { return s.Addrs },
+	"protocol":     func(s scanner.Server) any { return s.Proto() },
+	"pid":          func(s scanner.Server) any { return s.PID },
+	"pids":         func(s scanner.Server) any { return s.AllPIDs() },
+	"process":      func(s scanner.Server) any { return s.Process },
+	"command":      func(s scanner.Server) any { return s.Command },
+	"exe_path":     func(s scanner.Server) any { return s.ExePath },
+	"state":        func(s scanner.Server) any { return s.State },
+	"label":        func(s scanner.Server) any { return s.Label },
+	"healthy":      func(s scanner.Server) any { return s.Healthy },
+	"backlog":      func(s scanner.Server) any { return s.Backlog },
+	"max_backlog":  func(s scanner.Server) any { return s.MaxBacklog },
+	"start_time":   func(s scanner.Server) any { return s.StartTime },
+	"socket_path":  func(s scanner.Server) any { return s.SocketPath },
+	"pid_stale":    func(s scanner.Server) any { return s.PIDStale },
+	"display_name": func(s scanner.Server) any { return s.DisplayName },
+}
+
+// ParseJSONFields parses a --json-fields value such as "port,pid,label".
+func ParseJSONFields(list string) ([]string, error) {
+	var fields []string
+	for _, f := range strings.Split(list, ",") {
+		f = strings.TrimSpace(f)
+		if _, ok := jsonFields[f]; !ok {
+			valid := make([]string, 0, len(jsonFields))
+			for name := range jsonFields {
+				valid = append(valid, name)
+			}
+			slices.Sort(valid)
+			return nil, fmt.Errorf("unknown JSON field %q; valid fields: %s", f, strings.Join(valid, ", "))
+		}
+		if !slices.Contains(fields, f) {
+			fields = append(fields, f)
+		}
+	}
+	return fields, nil
+}
+
+// endpoint is how headless output names a server: ":8080", or the path of
+// a Unix socket.
+func endpoint(s scanner.Server) string {
+	if s.IsSocket() {
+		return s.SocketPath
+	}
+	return fmt.Sprintf(":%d", s.Port)
+}
+
+// FormatJSON renders servers as a JSON array on one line, sorted by port.
+// With fields set, each object carries only those keys, always present even
+// when empty; otherwise it has every field of scanner.Server.
+func FormatJSON(servers []scanner.Server, fields []string) (string, error) {
+	sorted := slices.Clone(servers)
+	sort.SliceStable(sorted, func(i, j int) bool { return portLess(sorted[i], sorted[j]) })
+
+	var v any = sorted
+	if len(fields) > 0 {
+		records := make([]map[string]any, len(sorted))
+		for i, s := range sorted {
+			records[i] = make(map[string]any, len(fields))
+			for _, f := range fields {
+				records[i][f] = jsonFields[f](s)
+			}
+		}
+		v = records
+	}
+	if len(sorted) == 0 {
+		v = []any{}
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(out) + "\n", nil
+}
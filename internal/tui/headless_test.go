@@ -0,0 +1,172 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+
+	"github.com/jeramiahgcoffey/portview/internal/config"
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+func TestFormatSummary(t *testing.T) {
+	servers := []scanner.Server{
+		{Port: 8080, Process: "node", Label: "web-api", Healthy: true},
+		{Port: 443, Process: "caddy", Healthy: false},
+		{Port: 3000, Process: "python3", Label: "docs", Healthy: true},
+	}
+	want := "" +
+		":443   caddy             ✗\n" +
+		":3000  python3  docs     ✓\n" +
+		":8080  node     web-api  ✓\n"
+	if got := FormatSummary(servers); got != want {
+		t.Errorf("FormatSummary() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFormatSummaryEmpty(t *testing.T) {
+	if got := FormatSummary(nil); got != "" {
+		t.Errorf("FormatSummary(nil) = %q, want empty", got)
+	}
+}
+
+func TestFormatDiff(t *testing.T) {
+	d := scanner.DiffServers(
+		[]scanner.Server{{Port: 5432, PID: 200, Process: "postgres"}, {Port: 8080, PID: 300, Process: "api"}},
+		[]scanner.Server{{Port: 8080, PID: 310, Process: "api"}, {Port: 4000, PID: 500, Process: "vite"}},
+	)
+	want := "" +
+		"+ :4000  vite (PID 500)\n" +
+		"- :5432  postgres (PID 200)\n" +
+		"~ :8080  api (PID 300) → api (PID 310)\n"
+	if got := FormatDiff(d); got != want {
+		t.Errorf("FormatDiff() =\n%s\nwant\n%s", got, want)
+	}
+	if got := FormatDiff(scanner.Diff{}); got != "no changes\n" {
+		t.Errorf("FormatDiff(empty) = %q", got)
+	}
+}
+
+func TestFormatJSONFields(t *testing.T) {
+	servers := []scanner.Server{
+		{Port: 8080, PID: 300, Process: "go", Command: "go run main.go", Label: "api", Healthy: true},
+		{Port: 3000, PID: 100, Process: "node", Command: "node server.js", State: "LISTEN"},
+	}
+	fields, err := ParseJSONFields("port,pid,process,label")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := FormatJSON(servers, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"label":"","pid":100,"port":3000,"process":"node"},{"label":"api","pid":300,"port":8080,"process":"go"}]` + "\n"
+	if got != want {
+		t.Errorf("FormatJSON() = %s, want %s", got, want)
+	}
+
+	if got, _ := FormatJSON(nil, fields); got != "[]\n" {
+		t.Errorf("FormatJSON(nil) = %q, want an empty array", got)
+	}
+	if all, _ := FormatJSON(servers[:1], nil); !strings.Contains(all, `"command":"go run main.go"`) {
+		t.Errorf("FormatJSON without fields should include every field: %s", all)
+	}
+}
+
+func TestParseJSONFields(t *testing.T) {
+	got, err := ParseJSONFields(" port, pid,port ")
+	if err != nil || strings.Join(got, ",") != "port,pid" {
+		t.Errorf("ParseJSONFields() = %v, %v; want [port pid]", got, err)
+	}
+	for _, in := range []string{"port,colour", "", "port,"} {
+		_, err := ParseJSONFields(in)
+		if err == nil || !strings.Contains(err.Error(), "valid fields: addr, addrs, backlog,") {
+			t.Errorf("ParseJSONFields(%q) err = %v, want the list of valid fields", in, err)
+		}
+	}
+}
+
+func TestSuspiciousAndFormatAudit(t *testing.T) {
+	servers := []scanner.Server{
+		{Port: 8080, PID: 300, ExePath: "/usr/local/go/bin/go"},
+		{Port: 4444, PID: 812, ExePath: "/tmp/.x/miner"},
+		{Port: 31337, PID: 9, ExePath: "/dev/shm/s"},
+		{Port: 3000, PID: 100},
+	}
+	got := FormatAudit(Suspicious(servers, config.Default()))
+	want := "" +
+		":4444   PID 812  /tmp/.x/miner\n" +
+		":31337  PID 9    /dev/shm/s\n"
+	if got != want {
+		t.Errorf("FormatAudit() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestScanOnceAppliesPipeline(t *testing.T) {
+	cfg := config.Default()
+	cfg.SetLabel(3000, "web")
+	cfg.Hidden = []int{5432}
+	got, err := ScanOnce(context.Background(), &scanner.MockScanner{Servers: testServers}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Label != "web" || got[1].Port != 8080 {
+		t.Errorf("ScanOnce() = %+v, want 3000 labelled web and 8080", got)
+	}
+}
+
+func TestCountListeningSkipsHidden(t *testing.T) {
+	cfg := config.Default()
+	cfg.Hidden = []int{5432, 8080}
+	got, err := CountListening(context.Background(), &scanner.MockScanner{Servers: testServers}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 1 {
+		t.Errorf("CountListening() = %d, want 1", got)
+	}
+
+	if _, err := CountListening(context.Background(), &scanner.MockScanner{Err: context.DeadlineExceeded}, cfg); err == nil {
+		t.Error("CountListening() should pass scan errors through")
+	}
+}
+
+func TestFormatLabels(t *testing.T) {
+	labels := map[int]string{8080: "api", 3000: "frontend", 5173: "api", 9229: "debug"}
+	want := "" +
+		"api       localhost:5173\n" +
+		"api       localhost:8080\n" +
+		"debug     localhost:9229\n" +
+		"frontend  localhost:3000\n"
+	if got := FormatLabels(labels); got != want {
+		t.Errorf("FormatLabels() =\n%s\nwant\n%s", got, want)
+	}
+	if got := FormatLabels(nil); got != "" {
+		t.Errorf("FormatLabels(nil) = %q, want empty", got)
+	}
+}
+
+func TestHeadlessOutputHasNoANSI(t *testing.T) {
+	// Even with a colour profile forced on, the headless formatters must
+	// write plain text.
+	lipgloss.SetColorProfile(termenv.TrueColor)
+	defer lipgloss.SetColorProfile(termenv.Ascii)
+
+	servers := []scanner.Server{
+		{Port: 3000, Process: "node", Label: "web", Healthy: true},
+		{Port: 8080, Process: "go"},
+	}
+	outputs := map[string]string{
+		"FormatSummary": FormatSummary(servers),
+		"FormatLabels":  FormatLabels(map[int]string{3000: "web", 8080: "api"}),
+		"plainTable":    plainTable(servers),
+	}
+	for name, out := range outputs {
+		if strings.Contains(out, "\x1b") {
+			t.Errorf("%s output contains an escape sequence: %q", name, out)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// hiddenKeyMap holds the bindings of the hidden-ports overlay.
+type hiddenKeyMap struct {
+	Unhide   key.Binding
+	ClearAll key.Binding
+}
+
+var hiddenKeys = hiddenKeyMap{
+	Unhide: key.NewBinding(
+		key.WithKeys("u", "enter"),
+		key.WithHelp("u/enter", "unhide"),
+	),
+	ClearAll: key.NewBinding(
+		key.WithKeys("C"),
+		key.WithHelp("C", "unhide all"),
+	),
+}
+
+// hiddenPorts is the config's hidden list in port order, as the overlay
+// shows it.
+func (m Model) hiddenPorts() []int {
+	ports := slices.Clone(m.config.Hidden)
+	slices.Sort(ports)
+	return ports
+}
+
+func (m Model) handleHiddenKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	ports := m.hiddenPorts()
+	switch {
+	case key.Matches(msg, keys.Quit), msg.Type == tea.KeyEsc:
+		m.mode = modeNormal
+
+	case key.Matches(msg, keys.Up):
+		m.hiddenRow = max(m.hiddenRow-1, 0)
+
+	case key.Matches(msg, keys.Down):
+		m.hiddenRow = max(min(m.hiddenRow+1, len(ports)-1), 0)
+
+	case key.Matches(msg, hiddenKeys.Unhide):
+		if m.hiddenRow >= len(ports) {
+			break
+		}
+		port := ports[m.hiddenRow]
+		m.config.ToggleHidden(port)
+		m.status = fmt.Sprintf("unhid port %d", port)
+		m.hiddenRow = max(min(m.hiddenRow, len(ports)-2), 0)
+		m.applyPipeline()
+		save := m.scheduleSave()
+		return m, save
+
+	case key.Matches(msg, hiddenKeys.ClearAll):
+		if len(ports) == 0 {
+			break
+		}
+		m.config.Hidden = nil
+		noun := "ports"
+		if len(ports) == 1 {
+			noun = "port"
+		}
+		m.status = fmt.Sprintf("unhid %d %s", len(ports), noun)
+		m.hiddenRow = 0
+		m.applyPipeline()
+		save := m.scheduleSave()
+		return m, save
+	}
+	return m, nil
+}
+
+func (m Model) hiddenView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("hidden ports"))
+	b.WriteString("\n\n")
+	ports := m.hiddenPorts()
+	if len(ports) == 0 {
+		b.WriteString("  none\n")
+	}
+	for i, port := range ports {
+		gutter := "  "
+		if i == m.hiddenRow {
+			gutter = "> "
+		}
+		line := strings.TrimRight(fmt.Sprintf("%s%-6d %s", gutter, port, m.config.LabelFor(port)), " ")
+		if i == m.hiddenRow {
+			line = selectedStyle.Render(line)
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	for _, kb := range []key.Binding{hiddenKeys.Unhide, hiddenKeys.ClearAll} {
+		h := kb.Help()
+		fmt.Fprintf(&b, "%s: %s  ", h.Key, h.Desc)
+	}
+	b.WriteString("esc: close")
+	return m.overlay(b.String())
+}
@@ -0,0 +1,81 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jeramiahgcoffey/portview/internal/config"
+)
+
+// openHidden hides ports through the config and opens the overlay from help.
+func openHidden(t *testing.T, ports ...int) Model {
+	t.Helper()
+	m := newTestModel(t, testServers)
+	for _, port := range ports {
+		m.config.ToggleHidden(port)
+	}
+	m.config.SetLabel(5432, "db")
+	m.applyPipeline()
+	m, _ = press(t, m, "?")
+	m, _ = press(t, m, "h")
+	if m.mode != modeHidden {
+		t.Fatalf("h from help: mode = %v, want modeHidden", m.mode)
+	}
+	return m
+}
+
+func TestHiddenOverlayUnhides(t *testing.T) {
+	m := openHidden(t, 8080, 5432)
+	view := m.View()
+	if !strings.Contains(view, "5432   db") || !strings.Contains(view, "8080") {
+		t.Fatalf("overlay should list the hidden ports with labels:\n%s", view)
+	}
+
+	m, _ = press(t, m, "down")
+	m, cmd := press(t, m, "u")
+	if !slices.Equal(m.config.Hidden, []int{5432}) {
+		t.Errorf("Hidden = %v, want [5432] after unhiding 8080", m.config.Hidden)
+	}
+	if findPort(m.servers, 8080).Port != 8080 {
+		t.Error("8080 should be listed again")
+	}
+	assertSaved(t, m, cmd)
+	saved, err := config.Load(m.configPath)
+	if err != nil || !slices.Equal(saved.Hidden, []int{5432}) {
+		t.Errorf("saved Hidden = %v (err %v), want [5432]", saved.Hidden, err)
+	}
+	if m.hiddenRow != 0 {
+		t.Errorf("hiddenRow = %d, want it clamped to the remaining entry", m.hiddenRow)
+	}
+}
+
+func TestHiddenOverlayClearAll(t *testing.T) {
+	m := openHidden(t, 8080, 5432)
+	m, cmd := press(t, m, "C")
+	if len(m.config.Hidden) != 0 || m.status != "unhid 2 ports" {
+		t.Errorf("Hidden = %v, status = %q; want empty and a count", m.config.Hidden, m.status)
+	}
+	assertSaved(t, m, cmd)
+	if !strings.Contains(m.View(), "none") {
+		t.Errorf("emptied overlay should say so:\n%s", m.View())
+	}
+	if _, cmd := press(t, m, "C"); cmd != nil {
+		t.Error("clearing an empty list should not save")
+	}
+	if m, _ = press(t, m, "esc"); m.mode != modeNormal {
+		t.Errorf("esc: mode = %v, want modeNormal", m.mode)
+	}
+}
+
+func TestHiddenOverlayRespectsDisabledHide(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m.config.DisabledActions = []string{"hide"}
+	m, _ = press(t, m, "?")
+	if strings.Contains(m.View(), "unhide hidden ports") {
+		t.Error("help should not offer the overlay when hide is disabled")
+	}
+	if m, _ = press(t, m, "h"); m.mode == modeHidden {
+		t.Error("the overlay should not open when hide is disabled")
+	}
+}
@@ -0,0 +1,141 @@
+package tui
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+// hookEvent is an event that may have a hook configured for it.
+type hookEvent struct {
+	name   string // one of config.HookEvents
+	server scanner.Server
+}
+
+type hookResultMsg struct {
+	event string
+	port  int
+	err   error
+}
+
+// healthEvents lists on_down and on_up events between two scans: a server
+// whose health check stopped or started answering, or that stopped
+// listening altogether. TCP and UDP on one port are separate servers.
+func healthEvents(prev, cur []scanner.Server) []hookEvent {
+	now := make(map[portKey]scanner.Server, len(cur))
+	for _, s := range cur {
+		now[portKeyOf(s)] = s
+	}
+	var out []hookEvent
+	for _, p := range prev {
+		if p.IsSocket() {
+			continue
+		}
+		c, ok := now[portKeyOf(p)]
+		switch {
+		case !ok && p.Healthy:
+			out = append(out, hookEvent{"on_down", p})
+		case ok && p.Healthy && !c.Healthy:
+			out = append(out, hookEvent{"on_down", c})
+		case ok && !p.Healthy && c.Healthy:
+			out = append(out, hookEvent{"on_up", c})
+		}
+	}
+	return out
+}
+
+// runHooks starts the configured hook for each event. Events without a
+// hook are skipped.
+func (m Model) runHooks(events []hookEvent) tea.Cmd {
+	var cmds []tea.Cmd
+	for _, e := range events {
+		tmpl, ok := m.config.Hooks[e.name]
+		if !ok {
+			continue
+		}
+		cmd, err := hookCommand(expandHook(tmpl, e.server), e.server)
+		if err != nil {
+			cmds = append(cmds, func() tea.Msg {
+				return hookResultMsg{event: e.name, port: e.server.Port, err: err}
+			})
+			continue
+		}
+		cmds = append(cmds, doHook(e, cmd))
+	}
+	return tea.Batch(cmds...)
+}
+
+// doHook starts cmd detached from portview; it is not waited for.
+func doHook(e hookEvent, cmd *exec.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		err := cmd.Start()
+		if err == nil {
+			err = cmd.Process.Release()
+		}
+		return hookResultMsg{event: e.name, port: e.server.Port, err: err}
+	}
+}
+
+// hookCommand runs command through sh, detached so it outlives portview and
+// its output stays off the TUI. The server's details are in its environment
+// as PORTVIEW_PORT, PORTVIEW_PID and PORTVIEW_PROCESS.
+func hookCommand(command string, s scanner.Server) (*exec.Cmd, error) {
+	sh, err := hookShell()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(sh, "-c", command)
+	cmd.Env = append(os.Environ(),
+		"PORTVIEW_PORT="+strconv.Itoa(s.Port),
+		"PORTVIEW_PID="+strconv.Itoa(s.PID),
+		"PORTVIEW_PROCESS="+s.Process,
+	)
+	detach(cmd)
+	return cmd, nil
+}
+
+// expandHook fills the {port}, {pid} and {process} placeholders in tmpl.
+// Process names are chosen by whoever started the process, so {process}
+// never puts the name itself into the command: it becomes a reference to
+// $PORTVIEW_PROCESS, quoted to suit where it sits, since single quotes mean
+// nothing inside double quotes and vice versa.
+func expandHook(tmpl string, s scanner.Server) string {
+	tmpl = strings.NewReplacer(
+		"{port}", strconv.Itoa(s.Port),
+		"{pid}", strconv.Itoa(s.PID),
+	).Replace(tmpl)
+
+	var b strings.Builder
+	var quote byte // the quote tmpl is inside at i, or 0
+	for i := 0; i < len(tmpl); i++ {
+		if rest, ok := strings.CutPrefix(tmpl[i:], "{process}"); ok {
+			switch quote {
+			case '"':
+				b.WriteString("$PORTVIEW_PROCESS")
+			case '\'':
+				b.WriteString(`'"$PORTVIEW_PROCESS"'`)
+			default:
+				b.WriteString(`"$PORTVIEW_PROCESS"`)
+			}
+			i = len(tmpl) - len(rest) - 1
+			continue
+		}
+		c := tmpl[i]
+		b.WriteByte(c)
+		switch {
+		case c == '\\' && quote != '\'' && i+1 < len(tmpl):
+			i++
+			b.WriteByte(tmpl[i])
+		case c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,128 @@
+package tui
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/jeramiahgcoffey/portview/internal/config"
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+func TestExpandHook(t *testing.T) {
+	s := scanner.Server{Port: 3000, PID: 4242, Process: "node"}
+	tests := []struct {
+		name, tmpl, want string
+		process          string
+	}{
+		{"all placeholders", "echo {process} {pid} on :{port}", `echo "$PORTVIEW_PROCESS" 4242 on :3000`, "node"},
+		{"repeated", "{port}-{port}", "3000-3000", "node"},
+		{"no placeholders", "true", "true", "node"},
+		{"hostile process name", "echo {process}", `echo "$PORTVIEW_PROCESS"`, "x'; touch pwned; '"},
+		{"inside double quotes", `notify-send "{process} on :{port} is down"`, `notify-send "$PORTVIEW_PROCESS on :3000 is down"`, "$(touch pwned)"},
+		{"inside single quotes", "echo '{process} is down'", `echo ''"$PORTVIEW_PROCESS"' is down'`, "node"},
+		{"escaped quote", `echo \"{process}`, `echo \""$PORTVIEW_PROCESS"`, "node"},
+		{"single quote inside double", `echo "it's {process}"`, `echo "it's $PORTVIEW_PROCESS"`, "node"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s.Process = tc.process
+			if got := expandHook(tc.tmpl, s); got != tc.want {
+				t.Errorf("expandHook(%q) = %q, want %q", tc.tmpl, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHealthEvents(t *testing.T) {
+	prev := []scanner.Server{
+		{Port: 3000, Healthy: true},
+		{Port: 5432, Healthy: false},
+		{Port: 8080, Healthy: true},
+		{Port: 9000, Healthy: true},
+	}
+	cur := []scanner.Server{
+		{Port: 3000, Healthy: false},
+		{Port: 5432, Healthy: true},
+		{Port: 9000, Healthy: true},
+	}
+	var got []string
+	for _, e := range healthEvents(prev, cur) {
+		got = append(got, fmt.Sprintf("%s:%d", e.name, e.server.Port))
+	}
+	want := []string{"on_down:3000", "on_up:5432", "on_down:8080"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("healthEvents() = %v, want %v", got, want)
+	}
+}
+
+func TestHealthEventsKeepProtocolsApart(t *testing.T) {
+	prev := []scanner.Server{
+		{Port: 5353, Healthy: true},
+		{Port: 5353, Healthy: true, Protocol: scanner.ProtoUDP},
+	}
+	cur := []scanner.Server{
+		{Port: 5353, Healthy: false},
+		{Port: 5353, Healthy: true, Protocol: scanner.ProtoUDP},
+	}
+	events := healthEvents(prev, cur)
+	if len(events) != 1 || events[0].name != "on_down" || events[0].server.Proto() != "TCP" {
+		t.Errorf("healthEvents() = %+v, want on_down for the TCP server only", events)
+	}
+	if events := healthEvents(prev, cur[1:]); len(events) != 1 || events[0].server.Proto() != "TCP" {
+		t.Errorf("healthEvents() with TCP gone = %+v, want on_down for TCP alone", events)
+	}
+}
+
+func TestKillHookCommand(t *testing.T) {
+	alive := []scanner.Server{{Port: 3000, PID: fakePIDOld, Process: "node"}, {Port: 5432, PID: 200, Process: "postgres"}}
+	cfg := config.Default()
+	cfg.Hooks = map[string]string{"on_kill": "logger killed {pid} {process} on {port}"}
+	m := New(&scanner.MockScanner{}, cfg, Options{})
+	m = update(t, m, scanResultMsg{servers: alive})
+	// The kill itself is never sent; its result is delivered by hand.
+	m = update(t, m, killResultMsg{pids: []int{fakePIDOld}})
+
+	m.scanned = alive[1:]
+	events := m.reconcileKills()
+	want := []hookEvent{{"on_kill", scanner.Server{Port: 3000, PID: fakePIDOld, Process: "node"}}}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("reconcileKills() = %+v, want %+v", events, want)
+	}
+
+	// Built, never run.
+	cmd, err := hookCommand(expandHook(cfg.Hooks["on_kill"], events[0].server), events[0].server)
+	if err != nil {
+		t.Skip("no sh to run hooks:", err)
+	}
+	wantArgs := []string{cmd.Args[0], "-c", fmt.Sprintf(`logger killed %d "$PORTVIEW_PROCESS" on 3000`, fakePIDOld)}
+	if !reflect.DeepEqual(cmd.Args, wantArgs) {
+		t.Errorf("hook args = %q, want %q", cmd.Args, wantArgs)
+	}
+	wantEnv := []string{"PORTVIEW_PORT=3000", fmt.Sprintf("PORTVIEW_PID=%d", fakePIDOld), "PORTVIEW_PROCESS=node"}
+	if got := cmd.Env[len(cmd.Env)-len(wantEnv):]; !reflect.DeepEqual(got, wantEnv) {
+		t.Errorf("hook env ends %q, want %q", got, wantEnv)
+	}
+	if cmd.SysProcAttr == nil {
+		t.Error("hooks should run detached")
+	}
+}
+
+func TestHooksOffByDefault(t *testing.T) {
+	m := newTestModel(t, testServers)
+	down := []scanner.Server{testServers[1]}
+	if _, cmd := mustUpdate(t, m, scanResultMsg{servers: down}); cmd != nil {
+		t.Error("a health change with no hooks configured should not start anything")
+	}
+	if cmd := m.runHooks([]hookEvent{{"on_down", testServers[0]}}); cmd != nil {
+		t.Error("runHooks with no hooks configured should return nil")
+	}
+
+	m.config.Hooks = map[string]string{"on_up": "true"}
+	if cmd := m.runHooks([]hookEvent{{"on_down", testServers[0]}}); cmd != nil {
+		t.Error("only the event's own hook should run")
+	}
+	if cmd := m.runHooks([]hookEvent{{"on_up", testServers[0]}}); cmd == nil {
+		t.Error("a configured hook should produce a command")
+	}
+}
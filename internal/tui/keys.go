@@ -0,0 +1,215 @@
+package tui
+
+import "github.com/charmbracelet/bubbles/key"
+
+// keyMap holds the normal-mode bindings. The help overlay is rendered from
+// the same definitions so the two never drift apart.
+type keyMap struct {
+	Up         key.Binding
+	Down       key.Binding
+	GoTo       key.Binding
+	Open       key.Binding
+	OpenAll    key.Binding
+	Kill       key.Binding
+	Restart    key.Binding
+	Label      key.Binding
+	Hide       key.Binding
+	ShowHidden key.Binding
+	Favorite   key.Binding
+	FavOnly    key.Binding
+	ViewMode   key.Binding
+	Services   key.Binding
+	ShowCmd    key.Binding
+	CmdNarrow  key.Binding
+	CmdWiden   key.Binding
+	Refresh    key.Binding
+	Resolve    key.Binding
+	Snapshot   key.Binding
+	CopyTable  key.Binding
+	Filter     key.Binding
+	Command    key.Binding
+	PortRange  key.Binding
+	WidenRange key.Binding
+	SameProc   key.Binding
+	Freeze     key.Binding
+	SortLock   key.Binding
+	Detail     key.Binding
+	TailLog    key.Binding
+	Help       key.Binding
+	Quit       key.Binding
+	PrintQuit  key.Binding
+}
+
+var keys = keyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("[n]↑/k", "move up n rows"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("[n]↓/j", "move down n rows"),
+	),
+	GoTo: key.NewBinding(
+		key.WithKeys("G"),
+		key.WithHelp("[n]G", "go to row n, or the last row"),
+	),
+	Open: key.NewBinding(
+		key.WithKeys("o", "enter"),
+		key.WithHelp("o/enter", "open in browser"),
+	),
+	OpenAll: key.NewBinding(
+		key.WithKeys("ctrl+o"),
+		key.WithHelp("ctrl+o", "open every healthy listed port"),
+	),
+	Kill: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "kill process"),
+	),
+	Restart: key.NewBinding(
+		key.WithKeys("ctrl+r"),
+		key.WithHelp("ctrl+r", "restart process (allow_restart)"),
+	),
+	Label: key.NewBinding(
+		key.WithKeys("l"),
+		key.WithHelp("l", "set label"),
+	),
+	Hide: key.NewBinding(
+		key.WithKeys("h"),
+		key.WithHelp("h", "hide/unhide port"),
+	),
+	ShowHidden: key.NewBinding(
+		key.WithKeys("H"),
+		key.WithHelp("H", "show hidden ports"),
+	),
+	Favorite: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "mark/unmark favorite port"),
+	),
+	FavOnly: key.NewBinding(
+		key.WithKeys("F"),
+		key.WithHelp("F", "show favorites only/all ports"),
+	),
+	ViewMode: key.NewBinding(
+		key.WithKeys("g"),
+		key.WithHelp("g", "group by: flat/pid/label"),
+	),
+	Services: key.NewBinding(
+		key.WithKeys("N"),
+		key.WithHelp("N", "show service names"),
+	),
+	ShowCmd: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "show process name/command"),
+	),
+	CmdNarrow: key.NewBinding(
+		key.WithKeys("["),
+		key.WithHelp("[", "narrow the command column"),
+	),
+	CmdWiden: key.NewBinding(
+		key.WithKeys("]"),
+		key.WithHelp("]", "widen the command column"),
+	),
+	Refresh: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "refresh now"),
+	),
+	Resolve: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "recheck owning PID"),
+	),
+	Snapshot: key.NewBinding(
+		key.WithKeys("S"),
+		key.WithHelp("S", "save snapshot"),
+	),
+	CopyTable: key.NewBinding(
+		key.WithKeys("Y"),
+		key.WithHelp("Y", "copy the list as a plain table"),
+	),
+	Filter: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "filter"),
+	),
+	Command: key.NewBinding(
+		key.WithKeys(":"),
+		key.WithHelp(":", "command: N, kill, label, unlabel, hide, unhide"),
+	),
+	PortRange: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "show only a port range"),
+	),
+	WidenRange: key.NewBinding(
+		key.WithKeys("w"),
+		key.WithHelp("w", "widen port_range to excluded listeners"),
+	),
+	SameProc: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "filter to this process"),
+	),
+	Freeze: key.NewBinding(
+		key.WithKeys("z"),
+		key.WithHelp("z", "freeze/unfreeze row position"),
+	),
+	SortLock: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "lock row order/unlock and re-sort"),
+	),
+	Detail: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "show details"),
+	),
+	TailLog: key.NewBinding(
+		key.WithKeys("L"),
+		key.WithHelp("L", "tail the log from log_paths"),
+	),
+	Help: key.NewBinding(
+		key.WithKeys("?"),
+		key.WithHelp("?", "toggle help"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("q", "ctrl+c"),
+		key.WithHelp("q", "quit"),
+	),
+	PrintQuit: key.NewBinding(
+		key.WithKeys("P"),
+		key.WithHelp("P", "quit and print the selected port"),
+	),
+}
+
+// helpBindings lists the bindings shown in the help overlay, in order.
+func (k keyMap) helpBindings() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.GoTo, k.Open, k.OpenAll, k.Kill, k.Restart, k.Label, k.Hide, k.ShowHidden, k.Favorite, k.FavOnly, k.ViewMode, k.Services, k.ShowCmd, k.CmdNarrow, k.CmdWiden, k.Refresh, k.Resolve, k.Snapshot, k.CopyTable, k.Filter, k.Command, k.PortRange, k.WidenRange, k.SameProc, k.Freeze, k.SortLock, k.Detail, k.TailLog, k.Help, k.Quit, k.PrintQuit}
+}
+
+// helpKeyMap holds the bindings that act from inside the help overlay.
+type helpKeyMap struct {
+	CopyConfigPath key.Binding
+	EditConfig     key.Binding
+	HiddenList     key.Binding
+}
+
+var helpKeys = helpKeyMap{
+	CopyConfigPath: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "copy config path"),
+	),
+	EditConfig: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "edit config in $EDITOR"),
+	),
+	HiddenList: key.NewBinding(
+		key.WithKeys("h"),
+		key.WithHelp("h", "list and unhide hidden ports"),
+	),
+}
+
+// actionBindings ties each config.Actions name to the bindings that
+// disabled_actions switches off.
+var actionBindings = map[string][]key.Binding{
+	"kill":        {keys.Kill},
+	"restart":     {keys.Restart},
+	"label":       {keys.Label},
+	"hide":        {keys.Hide},
+	"open":        {keys.Open, keys.OpenAll},
+	"snapshot":    {keys.Snapshot},
+	"edit_config": {helpKeys.EditConfig},
+}
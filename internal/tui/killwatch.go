@@ -0,0 +1,90 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+// killConfirmTimeout is how long a killed process may keep listening before
+// its "killing…" badge gives way to a "still alive" warning.
+const killConfirmTimeout = 10 * time.Second
+
+// killWatch is a kill that later scans have not yet confirmed.
+type killWatch struct {
+	pids    []int // the killed PIDs that were listening on the port
+	process string
+	since   time.Time
+}
+
+// watchKills starts watching every listed port owned by one of pids, which
+// were just sent a signal.
+func (m *Model) watchKills(pids []int) {
+	if m.kills == nil {
+		m.kills = make(map[portKey]killWatch)
+	}
+	for _, s := range m.scanned {
+		if s.IsSocket() {
+			continue
+		}
+		var owned []int
+		for _, pid := range s.AllPIDs() {
+			if slices.Contains(pids, pid) {
+				owned = append(owned, pid)
+			}
+		}
+		if len(owned) > 0 {
+			m.kills[portKeyOf(s)] = killWatch{pids: owned, process: s.Process, since: m.now()}
+		}
+	}
+}
+
+// reconcileKills checks the watched kills against the last scan. A kill is
+// settled once none of its PIDs listen on the port any more, or once it has
+// outlasted killConfirmTimeout; either way the outcome goes to the status
+// bar. Confirmed kills are returned as on_kill hook events.
+func (m *Model) reconcileKills() []hookEvent {
+	keys := make([]portKey, 0, len(m.kills))
+	for k := range m.kills {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, comparePortKeys)
+
+	var notes []string
+	var confirmed []hookEvent
+	for _, k := range keys {
+		w := m.kills[k]
+		var alive []int
+		for _, s := range m.scanned {
+			if s.IsSocket() || portKeyOf(s) != k {
+				continue
+			}
+			for _, pid := range s.AllPIDs() {
+				if slices.Contains(w.pids, pid) {
+					alive = append(alive, pid)
+				}
+			}
+		}
+		switch {
+		case len(alive) == 0:
+			notes = append(notes, formatPIDs(w.pids)+" terminated")
+			srv := scanner.Server{Port: k.port, PID: w.pids[0], Process: w.process}
+			if k.proto == scanner.ProtoUDP {
+				srv.Protocol = scanner.ProtoUDP
+			}
+			confirmed = append(confirmed, hookEvent{"on_kill", srv})
+		case m.now().Sub(w.since) >= killConfirmTimeout:
+			notes = append(notes, fmt.Sprintf("%s still alive after %s", formatPIDs(alive), killConfirmTimeout))
+		default:
+			continue
+		}
+		delete(m.kills, k)
+	}
+	if len(notes) > 0 {
+		m.status = strings.Join(notes, "; ")
+	}
+	return confirmed
+}
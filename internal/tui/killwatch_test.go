@@ -0,0 +1,84 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeramiahgcoffey/portview/internal/config"
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+func TestKillBadgeUntilProcessDies(t *testing.T) {
+	alive := []scanner.Server{{Port: 3000, PID: fakePIDOld, Process: "node"}, {Port: 5432, PID: 200, Process: "postgres"}}
+	seq := &sequenceScanner{results: [][]scanner.Server{alive, alive, alive[1:]}}
+	m := New(seq, config.Default(), Options{})
+	m = update(t, m, doScan(seq, time.Now)())
+
+	// The kill itself is never sent; its result is delivered by hand.
+	m = update(t, m, killResultMsg{pids: []int{fakePIDOld}})
+	if !strings.Contains(m.View(), "killing…") {
+		t.Fatalf("killed row should carry a badge:\n%s", m.View())
+	}
+
+	m = update(t, m, doScan(seq, time.Now)())
+	if !strings.Contains(m.View(), "killing…") || strings.Contains(m.status, "terminated") {
+		t.Fatalf("badge should stay while the PID still listens: status = %q", m.status)
+	}
+
+	m = update(t, m, doScan(seq, time.Now)())
+	if want := fmt.Sprintf("PID %d terminated", fakePIDOld); m.status != want {
+		t.Errorf("status = %q, want %q", m.status, want)
+	}
+	if strings.Contains(m.View(), "killing…") {
+		t.Errorf("badge should clear once the process is gone:\n%s", m.View())
+	}
+}
+
+func TestKillBadgeStillAliveAfterTimeout(t *testing.T) {
+	clock := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	servers := []scanner.Server{{Port: 3000, PID: fakePIDOld, Process: "node"}}
+	m := New(&scanner.MockScanner{Servers: servers}, config.Default(), Options{})
+	m.now = fixedClock(&clock)
+	m = update(t, m, scanResultMsg{servers: servers})
+	m = update(t, m, killResultMsg{pids: []int{fakePIDOld}})
+
+	clock = clock.Add(killConfirmTimeout - time.Second)
+	m = update(t, m, scanResultMsg{servers: servers})
+	if strings.Contains(m.status, "still alive") {
+		t.Fatalf("warned before the timeout: %q", m.status)
+	}
+
+	clock = clock.Add(time.Second)
+	m = update(t, m, scanResultMsg{servers: servers})
+	if want := fmt.Sprintf("PID %d still alive after 10s", fakePIDOld); m.status != want {
+		t.Errorf("status = %q, want %q", m.status, want)
+	}
+	if strings.Contains(m.View(), "killing…") {
+		t.Error("badge should clear after the timeout")
+	}
+}
+
+func TestKillWatchKeepsProtocolsApart(t *testing.T) {
+	both := []scanner.Server{
+		{Port: 53, PID: fakePIDOld, Process: "dnsd"},
+		{Port: 53, PID: fakePIDOld, Process: "dnsd", Protocol: scanner.ProtoUDP},
+	}
+	m := New(&scanner.MockScanner{Servers: both}, config.Default(), Options{})
+	m = update(t, m, scanResultMsg{servers: both})
+	m = update(t, m, killResultMsg{pids: []int{fakePIDOld}})
+	if len(m.kills) != 2 {
+		t.Fatalf("kills = %+v, want a watch each for TCP and UDP", m.kills)
+	}
+
+	// The TCP socket closes first; the UDP one is still held.
+	m.scanned = both[1:]
+	events := m.reconcileKills()
+	if len(events) != 1 || events[0].server.Proto() != "TCP" {
+		t.Errorf("reconcileKills() = %+v, want on_kill for TCP only", events)
+	}
+	if _, ok := m.kills[portKey{scanner.ProtoUDP, 53}]; !ok {
+		t.Error("the UDP kill should still be watched")
+	}
+}
@@ -0,0 +1,55 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// logClosedMsg reports that the tail of a port's log was exited.
+type logClosedMsg struct {
+	port int
+	err  error
+}
+
+// logPath returns the log_paths entry for port, checking that the file is
+// there so a typo is reported in the status bar rather than by tail.
+func (m Model) logPath(port int) (string, error) {
+	path, ok := m.config.LogPaths[port]
+	if !ok {
+		return "", fmt.Errorf("no log_paths entry for :%d", port)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("log for :%d: %w", port, err)
+	}
+	return path, nil
+}
+
+// doTailLog suspends the TUI and follows path, port's configured log, until
+// the user interrupts it.
+func doTailLog(port int, path string) tea.Cmd {
+	return tea.ExecProcess(tailCommand(path), func(err error) tea.Msg {
+		return logClosedMsg{port: port, err: err}
+	})
+}
+
+// tailCommand builds "tail -n 100 -F path". -F keeps following across log
+// rotation, and both GNU and BSD tail accept it.
+func tailCommand(path string) *exec.Cmd {
+	return exec.Command("tail", "-n", "100", "-F", path)
+}
+
+// interrupted reports whether err is tail being stopped by ctrl+c, which is
+// how the user leaves it rather than a failure.
+func interrupted(err error) bool {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return false
+	}
+	ws, ok := exitErr.Sys().(syscall.WaitStatus)
+	return ok && ws.Signaled() && ws.Signal() == syscall.SIGINT
+}
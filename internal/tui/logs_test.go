@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTailCommand(t *testing.T) {
+	cmd := tailCommand("/var/log/web.log")
+	want := []string{"tail", "-n", "100", "-F", "/var/log/web.log"}
+	if !slices.Equal(cmd.Args, want) {
+		t.Errorf("tailCommand() args = %q, want %q", cmd.Args, want)
+	}
+}
+
+func TestTailLogUsesSelectedPortsPath(t *testing.T) {
+	dir := t.TempDir()
+	web := filepath.Join(dir, "web.log")
+	if err := os.WriteFile(web, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := newTestModel(t, testServers)
+	m.config.LogPaths = map[int]string{
+		3000: web,
+		5432: filepath.Join(dir, "missing.log"),
+	}
+
+	path, err := m.logPath(3000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tailCommand(path).Args; got[len(got)-1] != web {
+		t.Errorf("tail args for :3000 = %q, want its log last", got)
+	}
+	// The command is only built, never run.
+	if _, cmd := press(t, m, "L"); cmd == nil {
+		t.Error("L on :3000 should tail its log")
+	}
+
+	m, _ = press(t, m, "j")
+	m, cmd := press(t, m, "L")
+	if cmd != nil || !strings.Contains(m.status, "log for :5432:") {
+		t.Errorf("missing log file: status = %q, cmd = %v", m.status, cmd)
+	}
+
+	m, _ = press(t, m, "j")
+	m, cmd = press(t, m, "L")
+	if cmd != nil || m.status != "no log_paths entry for :8080" {
+		t.Errorf("unconfigured port: status = %q, cmd = %v", m.status, cmd)
+	}
+}
@@ -0,0 +1,1234 @@
+// Package tui implements portview's Bubble Tea interface.
+package tui
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jeramiahgcoffey/portview/internal/config"
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+type mode int
+
+const (
+	modeNormal mode = iota
+	modeFilter
+	modeLabel
+	modeConfirmKill
+	modeHelp
+	modeDetail
+	modeRange
+	modeConfirmRestart
+	modeCommand
+	modeConfirmSudo
+	modeConfirmReset
+	modeConfirmQuit
+	modeHidden
+	modeConfirmRootKill
+	modeExposed
+)
+
+// Options carries per-run settings that are not part of the saved config.
+type Options struct {
+	// ConfigPath is where label and hide changes are persisted.
+	ConfigPath string
+	// Notice, if set, is shown in the status bar until the first key press.
+	Notice string
+	// Snapshot names the saved snapshot being viewed, if any. Kill, label
+	// and hide are disabled since the rows no longer describe live processes.
+	Snapshot string
+	// HighContrast forces high-contrast rendering for this run without
+	// saving it to the config.
+	HighContrast bool
+	// Title replaces the config's title, or "portview", in the header.
+	Title string
+	// Filter seeds the filter, so the first scan is already narrowed to
+	// matching rows. FilterMode starts with the filter input open.
+	Filter     string
+	FilterMode bool
+	// Favorites starts in the favorites-only view, as start_favorites does.
+	Favorites bool
+	// AsRoot reports that portview runs as root. The status bar says so,
+	// and confirm_root_kills then asks twice before a kill.
+	AsRoot bool
+	// Logger receives diagnostic entries for scans, kills and config saves.
+	// Nil discards them.
+	Logger *slog.Logger
+	// Unfiltered, if set, scans every port. It is used once at startup to
+	// point out common ports that are listening outside the port range.
+	Unfiltered scanner.Scanner
+	// NewScanner, if set, builds a scanner for the given config. It is
+	// called when the range is widened or a reloaded config changes how to
+	// scan.
+	NewScanner func(config.Config) scanner.Scanner
+	// BeforeSave, if set, adjusts the config just before it is written, so
+	// settings overridden for this run only do not leak into the file.
+	BeforeSave func(config.Config) config.Config
+}
+
+// Model is the Bubble Tea model for portview.
+type Model struct {
+	scanner    scanner.Scanner
+	config     config.Config
+	configPath string
+	snapshot   string // set when viewing a saved snapshot
+	title      string // Options.Title; overrides the config's title
+	unfiltered scanner.Scanner
+	newScanner func(config.Config) scanner.Scanner
+	beforeSave func(config.Config) config.Config
+	log        *slog.Logger
+
+	forceHighContrast bool // Options.HighContrast
+	asRoot            bool // Options.AsRoot
+
+	scanned  []scanner.Server // last scan result, as returned by the scanner
+	servers  []scanner.Server // scanned with hidden ports removed, labels merged, in default_sort order
+	filtered []scanner.Server // servers matching filterText; what the list shows
+	cursor   int
+	count    int // pending numeric prefix, e.g. the 5 of "5j"
+	mode     mode
+	viewMode viewMode
+
+	excluded    []int          // common ports listening outside the port range
+	outside     int            // listeners the last scan left out, for show_excluded
+	confirmPIDs []int          // PIDs shown in the kill prompt
+	restarting  scanner.Server // server shown in the restart prompt
+	sudoPIDs    []int          // PIDs a kill was denied on, offered for sudo
+	killing     int            // kills and restarts sent but not yet reported
+	hiddenRow   int            // cursor in the hidden-ports overlay
+	cmdWidth    int            // command column width set with [ and ]; zero means colCommand
+	frozen      map[int]int    // port → row it is pinned to, for this session
+	lockedOrder []rowKey       // row order kept across scans while sortLocked
+	sortLocked  bool           // s pinned the row order; new rows go at the end
+
+	health   map[portKey]healthHistory // recent health results per port
+	lastSeen map[int]time.Time         // last scan each port was listening in
+	flaps    map[portKey][]time.Time   // recent times each port appeared or vanished
+	notified map[portKey]time.Time     // last desktop notification per port
+	kills    map[portKey]killWatch     // port → kill awaiting confirmation by a scan
+
+	filterText string
+	labelInput string
+	rangeInput string
+	cmdInput   string           // text after ":" in modeCommand
+	portFilter config.PortRange // session-only port range; zero means off
+	showHidden bool             // list hidden ports instead of dropping them
+	favorites  bool             // list only the config's favorite ports
+
+	saveDelay   time.Duration // saveDebounce; zero in tests
+	saveSeq     int           // bumped by each scheduleSave
+	savePending bool          // an edit is waiting to be written
+
+	lastRefresh time.Time
+	lastScan    time.Duration    // how long the last scan took, start to finish
+	scanStarted time.Time        // when the scan in flight was started
+	now         func() time.Time // time.Now; replaced in tests for stable output
+	lastKey     time.Time        // for config.IdleQuit
+	blurred     bool             // terminal reported losing focus
+	degraded    error            // last scan's ErrDegraded warning; nil if processes resolved
+	scanning    bool             // a scan is in flight
+	chosen      *scanner.Server  // picked with P; main prints its port on exit
+	err         error
+	status      string
+
+	width  int
+	height int
+}
+
+// New returns a Model that scans with s and persists changes to
+// opts.ConfigPath.
+func New(s scanner.Scanner, cfg config.Config, opts Options) Model {
+	if opts.Logger == nil {
+		opts.Logger = slog.New(slog.DiscardHandler)
+	}
+	startMode := modeNormal
+	if opts.FilterMode {
+		startMode = modeFilter
+	}
+	return Model{
+		scanner:           s,
+		config:            cfg,
+		configPath:        opts.ConfigPath,
+		snapshot:          opts.Snapshot,
+		title:             opts.Title,
+		unfiltered:        opts.Unfiltered,
+		newScanner:        opts.NewScanner,
+		beforeSave:        opts.BeforeSave,
+		log:               opts.Logger,
+		forceHighContrast: opts.HighContrast,
+		asRoot:            opts.AsRoot,
+		viewMode:          parseViewMode(cfg.ViewMode),
+		filterText:        opts.Filter,
+		favorites:         opts.Favorites || cfg.StartFavorites,
+		mode:              startMode,
+		status:            opts.Notice,
+		lastKey:           time.Now(),
+		saveDelay:         saveDebounce,
+		scanning:          true, // Init starts the first scan
+		scanStarted:       time.Now(),
+		now:               time.Now,
+	}
+}
+
+// Chosen returns the server picked with P, which quits so the caller can
+// print it. It reports false after an ordinary quit.
+func (m Model) Chosen() (scanner.Server, bool) {
+	if m.chosen == nil {
+		return scanner.Server{}, false
+	}
+	return *m.chosen, true
+}
+
+// Init starts the first scan and the refresh ticker, plus the one-off port
+// range check when an unfiltered scanner was given.
+func (m Model) Init() tea.Cmd {
+	cmds := []tea.Cmd{doScan(m.scanner, m.now), doTick(m.tickInterval())}
+	if m.unfiltered != nil {
+		cmds = append(cmds, doRangeCheck(m.unfiltered, m.config.PortRange))
+	}
+	return tea.Batch(cmds...)
+}
+
+// Update handles messages and key presses.
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+
+	case tickMsg:
+		if m.idleExpired(time.Time(msg)) {
+			quit := m.quit()
+			return m, quit
+		}
+		if m.manualRefresh() || m.scanning {
+			// With auto-refresh off the tick only checks idle_quit. Otherwise
+			// the last scan is still running; let it finish rather than stack
+			// another behind it.
+			return m, doTick(m.tickInterval())
+		}
+		scan := m.startScan()
+		return m, tea.Batch(scan, doTick(m.tickInterval()))
+
+	case tea.BlurMsg:
+		m.blurred = true
+		return m, nil
+
+	case tea.FocusMsg:
+		// The pending tick may be a slow one; refresh now rather than wait,
+		// unless a scan is already on its way.
+		wasBlurred := m.blurred
+		m.blurred = false
+		if wasBlurred && !m.manualRefresh() && !m.scanning {
+			scan := m.startScan()
+			return m, scan
+		}
+		return m, nil
+
+	case scanResultMsg:
+		m.scanning = false
+		took := msg.completedAt.Sub(msg.startedAt)
+		if msg.err != nil && !errors.Is(msg.err, scanner.ErrDegraded) {
+			m.log.Error("scan failed", "duration", took, "err", msg.err)
+			m.err = msg.err
+			return m, nil
+		}
+		m.log.Info("scan", "servers", len(msg.servers), "duration", took, "degraded", msg.err != nil)
+		m.err = nil
+		m.degraded = msg.err
+		first := m.lastRefresh.IsZero()
+		m.lastRefresh = m.now()
+		m.lastScan = took
+		m.outside = msg.excluded
+		prevScanned := m.scanned
+		m.scanned = msg.servers
+		m.recordHealth(msg.servers)
+		m.recordSeen()
+		m.recordFlaps(prevScanned)
+		events := append(m.reconcileKills(), healthEvents(prevScanned, m.scanned)...)
+		hooks := m.runHooks(events)
+		prev := m.servers
+		m.applyPipeline()
+		if first {
+			m.showExposedBanner()
+		}
+		if m.config.Notify {
+			return m, tea.Batch(hooks, m.notifyTransitions(healthTransitions(prev, m.servers), m.lastRefresh))
+		}
+		return m, hooks
+
+	case hookResultMsg:
+		if msg.err != nil {
+			m.log.Error("hook failed", "event", msg.event, "port", msg.port, "err", msg.err)
+			m.status = fmt.Sprintf("%s hook for :%d: %v", msg.event, msg.port, msg.err)
+		}
+		return m, nil
+
+	case killResultMsg:
+		m.killing = max(m.killing-1, 0)
+		if msg.err != nil {
+			m.log.Error("kill failed", "pids", msg.pids, "err", msg.err)
+			m.status = fmt.Sprintf("kill: %v", msg.err)
+			switch {
+			case len(msg.denied) == 0 || m.mode != modeNormal:
+			case canSudo:
+				m.sudoPIDs = msg.denied
+				m.mode = modeConfirmSudo
+			default:
+				m.status += "; run portview as Administrator to stop it"
+			}
+			return m, nil
+		}
+		m.log.Info("kill", "pids", msg.pids)
+		m.status = "sent SIGTERM to " + formatPIDs(msg.pids)
+		m.watchKills(msg.pids)
+		scan := m.startScan()
+		return m, scan
+
+	case rangeCheckMsg:
+		m.excluded = msg.ports
+		if hint := rangeHint(msg.ports, m.config.PortRange); hint != "" {
+			hint += "; w widens it"
+			if m.status != "" {
+				hint = m.status + " · " + hint
+			}
+			m.status = hint
+		}
+		return m, nil
+
+	case sudoKillResultMsg:
+		m.killing = max(m.killing-1, 0)
+		if msg.err != nil {
+			m.log.Error("sudo kill failed", "pids", msg.pids, "err", msg.err)
+			m.status = fmt.Sprintf("sudo kill: %v", msg.err)
+			return m, nil
+		}
+		m.log.Info("sudo kill", "pids", msg.pids)
+		m.status = "sent SIGTERM to " + formatPIDs(msg.pids) + " with sudo"
+		m.watchKills(msg.pids)
+		scan := m.startScan()
+		return m, scan
+
+	case killStaleMsg:
+		m.killing = max(m.killing-1, 0)
+		if len(msg.current) == 0 {
+			m.status = fmt.Sprintf(":%d is no longer listening; nothing killed", msg.port)
+		} else {
+			m.status = fmt.Sprintf(":%d is now %s, not %s; nothing killed", msg.port, formatPIDs(msg.current), formatPIDs(msg.confirmed))
+		}
+		m.log.Info("kill skipped", "port", msg.port, "confirmed", msg.confirmed, "current", msg.current)
+		scan := m.startScan()
+		return m, scan
+
+	case restartResultMsg:
+		m.killing = max(m.killing-1, 0)
+		if msg.err != nil {
+			m.log.Error("restart failed", "port", msg.port, "err", msg.err)
+			m.status = fmt.Sprintf("restart :%d: %v", msg.port, msg.err)
+			return m, nil
+		}
+		m.log.Info("restart", "port", msg.port, "pid", msg.pid)
+		m.status = fmt.Sprintf("restarted :%d as PID %d", msg.port, msg.pid)
+		scan := m.startScan()
+		return m, scan
+
+	case resolvedPortMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("resolve %s: %v", msg.key, msg.err)
+			return m, nil
+		}
+		m.mergeResolved(msg.key, msg.server)
+		m.applyPipeline()
+		m.status = fmt.Sprintf("%s is %s", msg.key, formatPIDs(msg.server.AllPIDs()))
+		return m, nil
+
+	case snapshotSavedMsg:
+		if msg.err != nil {
+			m.status = msg.err.Error()
+		} else {
+			m.status = "saved snapshot " + msg.path
+		}
+		return m, nil
+
+	case openResultMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("open :%d: %v", msg.port, msg.err)
+		}
+		return m, nil
+
+	case saveDueMsg:
+		if msg.seq != m.saveSeq || !m.savePending {
+			return m, nil
+		}
+		m.savePending = false
+		return m, m.saveConfig()
+
+	case configSavedMsg:
+		if msg.err != nil {
+			m.log.Error("config save failed", "path", msg.path, "err", msg.err)
+			m.status = fmt.Sprintf("saving config: %v", msg.err)
+			return m, nil
+		}
+		m.log.Info("config saved", "path", msg.path)
+		return m, nil
+
+	case configLoadedMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("reloading config: %v", msg.err)
+			return m, nil
+		}
+		cmd := m.replaceConfig(msg.cfg)
+		m.status = "reloaded config"
+		return m, cmd
+
+	case editorClosedMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("editor: %v", msg.err)
+		}
+		return m, doReloadConfig(m.configPath)
+
+	case logClosedMsg:
+		if msg.err != nil && !interrupted(msg.err) {
+			m.status = fmt.Sprintf("tail :%d log: %v", msg.port, msg.err)
+		}
+		return m, nil
+
+	case copyResultMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("copy %s: %v", msg.what, msg.err)
+		} else {
+			m.status = "copied " + msg.what
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		m.lastKey = m.now()
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+// startScan marks a scan as in flight and returns the command running it.
+func (m *Model) startScan() tea.Cmd {
+	m.scanning = true
+	m.scanStarted = m.now()
+	return doScan(m.scanner, m.now)
+}
+
+// replaceConfig swaps in cfg wholesale, as a reload or reset does. The
+// scanner is rebuilt if a scanner setting changed, and ticking resumes if it
+// had stopped because auto-refresh was off.
+func (m *Model) replaceConfig(cfg config.Config) tea.Cmd {
+	rescan := !sameScan(cfg, m.config)
+	ticking := m.tickInterval() > 0
+	m.config = cfg
+	m.applyPipeline()
+	var cmds []tea.Cmd
+	if rescan && m.newScanner != nil {
+		m.scanner = m.newScanner(m.config)
+		cmds = append(cmds, m.startScan())
+	}
+	if !ticking {
+		cmds = append(cmds, doTick(m.tickInterval()))
+	}
+	return tea.Batch(cmds...)
+}
+
+// sameScan reports whether a and b build the same scanner.
+func sameScan(a, b config.Config) bool {
+	return a.PortRange == b.PortRange &&
+		a.IncludeUDP == b.IncludeUDP &&
+		a.HealthTimeout == b.HealthTimeout &&
+		a.LsofPath == b.LsofPath &&
+		slices.Equal(a.LsofArgs, b.LsofArgs)
+}
+
+// manualRefresh reports whether refresh_interval is 0, leaving scans to "r".
+func (m Model) manualRefresh() bool {
+	return m.config.RefreshInterval == 0
+}
+
+// tickInterval is the delay before the next periodic scan: the background
+// interval while the terminal is unfocused, if one is set. With auto-refresh
+// off, ticks only serve idle_quit, and there are none if that is unset.
+func (m Model) tickInterval() time.Duration {
+	if m.manualRefresh() {
+		return m.config.IdleQuit
+	}
+	if m.blurred && m.config.BackgroundInterval > 0 {
+		return m.config.BackgroundInterval
+	}
+	return m.config.RefreshInterval
+}
+
+// highContrast reports whether rows should be drawn without colour cues.
+func (m Model) highContrast() bool {
+	return m.config.HighContrast || m.forceHighContrast
+}
+
+// idleExpired reports whether config.IdleQuit has elapsed since the last key
+// press as of now.
+func (m Model) idleExpired(now time.Time) bool {
+	return m.config.IdleQuit > 0 && now.Sub(m.lastKey) >= m.config.IdleQuit
+}
+
+func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.Type == tea.KeyCtrlC {
+		quit := m.quit()
+		return m, quit
+	}
+	switch m.mode {
+	case modeFilter:
+		return m.handleFilterKey(msg)
+	case modeLabel:
+		return m.handleLabelKey(msg)
+	case modeConfirmKill, modeConfirmRootKill:
+		return m.handleConfirmKey(msg)
+	case modeHelp:
+		return m.handleHelpKey(msg)
+	case modeDetail:
+		return m.handleDetailKey(msg)
+	case modeRange:
+		return m.handleRangeKey(msg)
+	case modeConfirmRestart:
+		return m.handleConfirmRestartKey(msg)
+	case modeCommand:
+		return m.handleCommandKey(msg)
+	case modeConfirmSudo:
+		return m.handleConfirmSudoKey(msg)
+	case modeConfirmReset:
+		return m.handleConfirmResetKey(msg)
+	case modeConfirmQuit:
+		return m.handleConfirmQuitKey(msg)
+	case modeHidden:
+		return m.handleHiddenKey(msg)
+	case modeExposed:
+		return m.handleExposedKey(msg)
+	}
+	return m.handleNormalKey(msg)
+}
+
+func (m Model) handleNormalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.status = ""
+	// Digits build a vim-style count for the next motion; any other key
+	// consumes it. A leading 0 is not a count.
+	count := m.count
+	m.count = 0
+	if d, ok := digit(msg); ok && (d > 0 || count > 0) {
+		m.count = min(count*10+d, maxCount)
+		return m, nil
+	}
+	steps := max(count, 1)
+
+	if a, ok := m.disabledAction(msg); ok {
+		m.status = a + " is disabled in the config"
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(msg, keys.Quit):
+		if m.killing > 0 {
+			// The process may be half stopped; make leaving a choice.
+			m.mode = modeConfirmQuit
+			return m, nil
+		}
+		quit := m.quit()
+		return m, quit
+
+	case key.Matches(msg, keys.PrintQuit):
+		s, ok := m.selected()
+		if !ok {
+			m.status = "nothing selected"
+			return m, nil
+		}
+		m.chosen = &s
+		if m.killing > 0 {
+			m.mode = modeConfirmQuit
+			return m, nil
+		}
+		quit := m.quit()
+		return m, quit
+
+	case key.Matches(msg, keys.Up):
+		m.cursor = max(m.cursor-steps, 0)
+
+	case key.Matches(msg, keys.Down):
+		m.cursor = max(min(m.cursor+steps, len(m.filtered)-1), 0)
+
+	case key.Matches(msg, keys.GoTo):
+		if count > 0 {
+			m.goToRow(count)
+		} else {
+			m.cursor = max(len(m.filtered)-1, 0)
+		}
+
+	case m.socketSelected() && key.Matches(msg, keys.Open, keys.Kill, keys.Restart, keys.Label, keys.Hide, keys.Favorite, keys.Resolve, keys.Freeze, keys.TailLog):
+		m.status = "Unix sockets are listed only; that key needs a port"
+
+	case key.Matches(msg, keys.Open):
+		s, ok := m.selected()
+		if !ok {
+			break
+		}
+		if m.config.NoOpen(s.Port) {
+			m.status = fmt.Sprintf(":%d is not a browser-openable port", s.Port)
+			break
+		}
+		return m, doOpen(s.Port)
+
+	case key.Matches(msg, keys.OpenAll):
+		ports := m.openablePorts()
+		if len(ports) == 0 {
+			m.status = "no healthy ports to open"
+			break
+		}
+		noun := "ports"
+		if len(ports) == 1 {
+			noun = "port"
+		}
+		m.status = fmt.Sprintf("opening %d %s…", len(ports), noun)
+		return m, doOpenAll(ports)
+
+	case m.snapshot != "" && key.Matches(msg, keys.Kill, keys.Restart, keys.Label, keys.Hide, keys.Favorite):
+		m.status = "read-only snapshot"
+
+	case key.Matches(msg, keys.Kill):
+		s, ok := m.selected()
+		if !ok {
+			break
+		}
+		if s.PID == 0 {
+			m.status = fmt.Sprintf("no PID known for port %d", s.Port)
+			break
+		}
+		m.confirmPIDs = s.AllPIDs()
+		m.mode = modeConfirmKill
+
+	case key.Matches(msg, keys.Restart):
+		s, ok := m.selected()
+		switch {
+		case !ok:
+		case !m.config.AllowRestart:
+			m.status = "restart is off; set allow_restart: true in the config"
+		case s.PID == 0:
+			m.status = fmt.Sprintf("no PID known for port %d", s.Port)
+		case strings.TrimSpace(s.Command) == "":
+			m.status = fmt.Sprintf("no command captured for :%d", s.Port)
+		default:
+			m.restarting = s
+			m.mode = modeConfirmRestart
+		}
+
+	case key.Matches(msg, keys.Label):
+		if s, ok := m.selected(); ok {
+			m.labelInput = s.Label
+			m.mode = modeLabel
+		}
+
+	case key.Matches(msg, keys.Hide):
+		s, ok := m.selected()
+		if !ok {
+			break
+		}
+		if m.config.ToggleHidden(s.Port) {
+			m.status = fmt.Sprintf("hid port %d", s.Port)
+		} else {
+			m.status = fmt.Sprintf("unhid port %d", s.Port)
+		}
+		m.applyPipeline()
+		save := m.scheduleSave()
+		return m, save
+
+	case key.Matches(msg, keys.Favorite):
+		s, ok := m.selected()
+		if !ok {
+			break
+		}
+		if m.config.ToggleFavorite(s.Port) {
+			m.status = fmt.Sprintf("marked :%d as a favorite", s.Port)
+		} else {
+			m.status = fmt.Sprintf("unmarked :%d", s.Port)
+		}
+		m.applyFilter()
+		save := m.scheduleSave()
+		return m, save
+
+	case key.Matches(msg, keys.FavOnly):
+		m.favorites = !m.favorites
+		m.status = "showing all ports"
+		if m.favorites {
+			m.status = fmt.Sprintf("showing favorites only (%d marked)", len(m.config.Favorites))
+		}
+		m.applyFilter()
+
+	case key.Matches(msg, keys.ViewMode):
+		m.viewMode = m.viewMode.next()
+		m.config.ViewMode = m.viewMode.String()
+		m.status = "view: " + m.viewMode.String()
+		m.applyFilter()
+		save := m.scheduleSave()
+		return m, save
+
+	case key.Matches(msg, keys.Services):
+		m.config.ServiceNames = !m.config.ServiceNames
+		m.status = "service names off"
+		if m.config.ServiceNames {
+			m.status = "service names on"
+		}
+		save := m.scheduleSave()
+		return m, save
+
+	case key.Matches(msg, keys.ShowHidden):
+		m.showHidden = !m.showHidden
+		m.applyPipeline()
+
+	case key.Matches(msg, keys.ShowCmd):
+		m.config.ShowCommand = !m.config.ShowCommand
+		m.status = "process column: process name"
+		if m.config.ShowCommand {
+			m.status = "process column: command"
+		}
+		save := m.scheduleSave()
+		return m, save
+
+	case key.Matches(msg, keys.CmdNarrow, keys.CmdWiden):
+		step := commandColStep
+		if key.Matches(msg, keys.CmdNarrow) {
+			step = -step
+		}
+		m.cmdWidth = min(max(m.commandCol()+step, minCommandCol), maxCommandCol)
+		m.status = fmt.Sprintf("command column: %d cells", m.cmdWidth)
+
+	case key.Matches(msg, keys.Refresh):
+		if m.scanning {
+			m.status = "already scanning…"
+			break
+		}
+		scan := m.startScan()
+		return m, scan
+
+	case key.Matches(msg, keys.Resolve):
+		if s, ok := m.selected(); ok {
+			m.status = fmt.Sprintf("resolving :%d…", s.Port)
+			return m, doResolvePort(m.scanner, portKeyOf(s))
+		}
+
+	case key.Matches(msg, keys.Snapshot):
+		name := m.now().Format("20060102-150405")
+		path, err := config.SnapshotPath(m.configPath, name)
+		if err != nil {
+			m.status = err.Error()
+			break
+		}
+		return m, doSaveSnapshot(path, m.scanned)
+
+	case key.Matches(msg, keys.CopyTable):
+		return m, doCopy("table", plainTable(m.filtered))
+
+	case key.Matches(msg, keys.Filter):
+		m.mode = modeFilter
+
+	case key.Matches(msg, keys.Command):
+		m.cmdInput = ""
+		m.mode = modeCommand
+
+	case key.Matches(msg, keys.PortRange):
+		m.rangeInput = ""
+		if m.portFilter != (config.PortRange{}) {
+			m.rangeInput = fmt.Sprintf("%d-%d", m.portFilter.Min, m.portFilter.Max)
+		}
+		m.mode = modeRange
+
+	case key.Matches(msg, keys.WidenRange):
+		if len(m.excluded) == 0 {
+			m.status = "no listening ports outside the range"
+			break
+		}
+		m.config.PortRange = m.config.PortRange.Widen(m.excluded...)
+		m.excluded = nil
+		m.status = fmt.Sprintf("port range is now %d–%d", m.config.PortRange.Min, m.config.PortRange.Max)
+		save := m.scheduleSave()
+		if m.newScanner == nil {
+			return m, save
+		}
+		m.scanner = m.newScanner(m.config)
+		scan := m.startScan()
+		return m, tea.Batch(save, scan)
+
+	case key.Matches(msg, keys.Freeze):
+		s, ok := m.selected()
+		if !ok {
+			break
+		}
+		if _, ok := m.frozen[s.Port]; ok {
+			delete(m.frozen, s.Port)
+			m.status = fmt.Sprintf("unfroze :%d", s.Port)
+		} else {
+			if m.frozen == nil {
+				m.frozen = make(map[int]int)
+			}
+			m.frozen[s.Port] = m.cursor
+			m.status = fmt.Sprintf("froze :%d at row %d", s.Port, m.cursor+1)
+		}
+		m.applyFilter()
+
+	case key.Matches(msg, keys.SortLock):
+		m.sortLocked = !m.sortLocked
+		if m.sortLocked {
+			m.lockedOrder = rowOrder(m.servers)
+			m.status = "row order locked; new ports go at the end"
+			break
+		}
+		m.lockedOrder = nil
+		m.applyPipeline()
+		m.status = "row order unlocked; sorted by " + cmp.Or(m.config.DefaultSort, "port")
+
+	case key.Matches(msg, keys.SameProc):
+		// A second press on a row of the same process restores the list.
+		s, ok := m.selected()
+		if !ok || s.Process == "" {
+			break
+		}
+		if m.filterText == s.Process {
+			m.filterText = ""
+		} else {
+			m.filterText = s.Process
+		}
+		m.applyFilter()
+
+	case key.Matches(msg, keys.Help):
+		m.mode = modeHelp
+
+	case key.Matches(msg, keys.Detail):
+		if _, ok := m.selected(); ok {
+			m.mode = modeDetail
+		}
+
+	case key.Matches(msg, keys.TailLog):
+		s, ok := m.selected()
+		if !ok {
+			return m, nil
+		}
+		path, err := m.logPath(s.Port)
+		if err != nil {
+			m.status = err.Error()
+			return m, nil
+		}
+		return m, doTailLog(s.Port, path)
+
+	case msg.Type == tea.KeyEsc:
+		// Clear the text filter first, then the port range.
+		switch {
+		case m.filterText != "":
+			m.filterText = ""
+		case m.portFilter != (config.PortRange{}):
+			m.portFilter = config.PortRange{}
+		}
+		m.applyFilter()
+	}
+	return m, nil
+}
+
+func (m Model) handleFilterKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.filterText = ""
+		m.mode = modeNormal
+	case tea.KeyEnter:
+		m.mode = modeNormal
+	case tea.KeyBackspace:
+		m.filterText = dropLastRune(m.filterText)
+	case tea.KeySpace:
+		m.filterText += " "
+	case tea.KeyRunes:
+		m.filterText += string(msg.Runes)
+	default:
+		return m, nil
+	}
+	m.applyFilter()
+	return m, nil
+}
+
+func (m Model) handleLabelKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.mode = modeNormal
+		m.labelInput = ""
+	case tea.KeyEnter:
+		m.mode = modeNormal
+		s, ok := m.selected()
+		if !ok {
+			return m, nil
+		}
+		m.config.SetLabel(s.Port, strings.TrimSpace(m.labelInput))
+		m.labelInput = ""
+		m.applyPipeline()
+		save := m.scheduleSave()
+		return m, save
+	case tea.KeyBackspace:
+		m.labelInput = dropLastRune(m.labelInput)
+	case tea.KeySpace:
+		m.labelInput += " "
+	case tea.KeyRunes:
+		m.labelInput += string(msg.Runes)
+	}
+	return m, nil
+}
+
+// handleRangeKey edits the session-only port range. Enter with an empty
+// input clears it.
+func (m Model) handleRangeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.mode = modeNormal
+		m.rangeInput = ""
+	case tea.KeyEnter:
+		m.mode = modeNormal
+		input := strings.TrimSpace(m.rangeInput)
+		m.rangeInput = ""
+		if input == "" {
+			m.portFilter = config.PortRange{}
+		} else {
+			r, err := config.ParsePortRange(input)
+			if err != nil {
+				m.status = err.Error()
+				return m, nil
+			}
+			m.portFilter = r
+		}
+		m.applyFilter()
+	case tea.KeyBackspace:
+		m.rangeInput = dropLastRune(m.rangeInput)
+	case tea.KeyRunes:
+		m.rangeInput += string(msg.Runes)
+	}
+	return m, nil
+}
+
+// handleCommandKey edits the ":" command line and runs it on enter.
+func (m Model) handleCommandKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.mode = modeNormal
+		m.cmdInput = ""
+	case tea.KeyEnter:
+		m.mode = modeNormal
+		input := m.cmdInput
+		m.cmdInput = ""
+		if strings.TrimSpace(input) == "" {
+			return m, nil
+		}
+		c, err := parseCommand(input)
+		if err != nil {
+			m.status = err.Error()
+			return m, nil
+		}
+		return m.runCommand(c)
+	case tea.KeyBackspace:
+		m.cmdInput = dropLastRune(m.cmdInput)
+	case tea.KeySpace:
+		m.cmdInput += " "
+	case tea.KeyRunes:
+		m.cmdInput += string(msg.Runes)
+	}
+	return m, nil
+}
+
+// handleConfirmKey answers the kill prompt. The kill itself re-reads the
+// port's owners first and only goes ahead if the PIDs the prompt showed still
+// own it, so a restarted service's new PID, or a reused PID, is never
+// killed unseen.
+func (m Model) handleConfirmKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	first := m.mode == modeConfirmKill
+	m.mode = modeNormal
+	pids := m.confirmPIDs
+	m.confirmPIDs = nil
+	s, ok := m.selected()
+	if msg.String() != "y" || !ok {
+		m.status = "kill cancelled"
+		return m, nil
+	}
+	if first && m.asRoot && m.config.ConfirmRootKills {
+		m.confirmPIDs = pids
+		m.mode = modeConfirmRootKill
+		return m, nil
+	}
+	m.killing++
+	return m, doKillChecked(m.scanner, portKeyOf(s), pids)
+}
+
+// handleConfirmSudoKey answers the offer to retry a denied kill with sudo.
+// Nothing is escalated without an explicit "y".
+func (m Model) handleConfirmSudoKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.mode = modeNormal
+	pids := m.sudoPIDs
+	m.sudoPIDs = nil
+	if msg.String() != "y" {
+		m.status = "sudo kill cancelled"
+		return m, nil
+	}
+	m.killing++
+	return m, doSudoKill(pids, syscall.SIGTERM)
+}
+
+// handleConfirmQuitKey answers the prompt shown when q is pressed while a
+// kill or restart is still running.
+func (m Model) handleConfirmQuitKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.mode = modeNormal
+	if msg.String() != "y" {
+		m.chosen = nil
+		m.status = "quit cancelled"
+		return m, nil
+	}
+	quit := m.quit()
+	return m, quit
+}
+
+// handleConfirmResetKey answers the reset-config prompt, replacing the whole
+// config, labels and hidden ports included, with the defaults.
+func (m Model) handleConfirmResetKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.mode = modeNormal
+	if msg.String() != "y" {
+		m.status = "reset cancelled"
+		return m, nil
+	}
+	cmd := m.replaceConfig(config.Default())
+	m.status = "reset config to defaults"
+	save := m.scheduleSave()
+	return m, tea.Batch(save, cmd)
+}
+
+func (m Model) handleConfirmRestartKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.mode = modeNormal
+	s := m.restarting
+	m.restarting = scanner.Server{}
+	if msg.String() != "y" {
+		m.status = "restart cancelled"
+		return m, nil
+	}
+	m.status = fmt.Sprintf("restarting :%d…", s.Port)
+	m.killing++
+	return m, doRestartChecked(m.scanner, s)
+}
+
+func (m Model) handleHelpKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if a, ok := m.disabledAction(msg); ok {
+		m.mode = modeNormal
+		m.status = a + " is disabled in the config"
+		return m, nil
+	}
+	switch {
+	case key.Matches(msg, keys.Help), key.Matches(msg, keys.Quit), msg.Type == tea.KeyEsc:
+		m.mode = modeNormal
+	case key.Matches(msg, helpKeys.CopyConfigPath):
+		m.mode = modeNormal
+		return m, doCopy("config path", m.configPath)
+	case key.Matches(msg, helpKeys.EditConfig):
+		m.mode = modeNormal
+		return m, doEditConfig(m.configPath)
+	case key.Matches(msg, helpKeys.HiddenList):
+		if m.snapshot != "" {
+			m.mode = modeNormal
+			m.status = "read-only snapshot"
+			break
+		}
+		m.hiddenRow = 0
+		m.mode = modeHidden
+	}
+	return m, nil
+}
+
+func (m Model) handleDetailKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.Detail), key.Matches(msg, keys.Quit), msg.Type == tea.KeyEsc:
+		m.mode = modeNormal
+	}
+	return m, nil
+}
+
+// maxCount caps the numeric prefix so a held-down digit cannot overflow it.
+const maxCount = 99999
+
+// digit returns the value of a single digit key press.
+func digit(msg tea.KeyMsg) (int, bool) {
+	if msg.Type != tea.KeyRunes || len(msg.Runes) != 1 || msg.Runes[0] < '0' || msg.Runes[0] > '9' {
+		return 0, false
+	}
+	return int(msg.Runes[0] - '0'), true
+}
+
+// goToRow moves the cursor to the 1-based row n, clamped to the list.
+func (m *Model) goToRow(n int) {
+	m.cursor = max(min(n-1, len(m.filtered)-1), 0)
+}
+
+// disabledAction returns the disabled action msg would trigger, if any.
+func (m Model) disabledAction(msg tea.KeyMsg) (string, bool) {
+	for _, a := range m.config.DisabledActions {
+		if bs, ok := actionBindings[a]; ok && key.Matches(msg, bs...) {
+			return a, true
+		}
+	}
+	return "", false
+}
+
+// bindingDisabled reports whether kb belongs to a disabled action, so the
+// help overlay can leave it out.
+func (m Model) bindingDisabled(kb key.Binding) bool {
+	for _, a := range m.config.DisabledActions {
+		if slices.ContainsFunc(actionBindings[a], func(b key.Binding) bool { return slices.Equal(b.Keys(), kb.Keys()) }) {
+			return true
+		}
+	}
+	return false
+}
+
+// openablePorts are the listed ports ctrl+o opens: healthy TCP servers
+// outside no_open_ranges, in list order.
+func (m Model) openablePorts() []int {
+	var ports []int
+	for _, s := range m.filtered {
+		if s.Healthy && s.Proto() == "TCP" && !m.config.NoOpen(s.Port) && !slices.Contains(ports, s.Port) {
+			ports = append(ports, s.Port)
+		}
+	}
+	return ports
+}
+
+// socketSelected reports whether the cursor is on a Unix socket row.
+func (m Model) socketSelected() bool {
+	s, ok := m.selected()
+	return ok && s.IsSocket()
+}
+
+// selected returns the server under the cursor.
+func (m Model) selected() (scanner.Server, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.filtered) {
+		return scanner.Server{}, false
+	}
+	return m.filtered[m.cursor], true
+}
+
+// mergeResolved copies the process fields of r, a targeted lookup of k, into
+// the scanned rows for k, until the next full scan replaces them.
+func (m *Model) mergeResolved(k portKey, r scanner.Server) {
+	for i := range m.scanned {
+		if m.scanned[i].IsSocket() || portKeyOf(m.scanned[i]) != k {
+			continue
+		}
+		m.scanned[i].PID = r.PID
+		m.scanned[i].PIDs = r.PIDs
+		m.scanned[i].Process = r.Process
+		m.scanned[i].Command = r.Command
+		m.scanned[i].ExePath = r.ExePath
+	}
+}
+
+// applyPipeline rebuilds servers and filtered from the last scan, applying
+// the current hidden list, labels and filter.
+func (m *Model) applyPipeline() {
+	servers := m.scanned
+	if m.config.DedupeByPort {
+		servers = dedupeByPort(servers)
+	}
+	dim := m.config.DimHidden() && !m.showHidden
+	if !m.showHidden && !dim {
+		servers = filterHidden(servers, m.config)
+	}
+	m.servers = mergeLabels(servers, m.config)
+	normalizeNames(m.servers, m.config)
+	sortServers(m.servers, m.config.DefaultSort)
+	if m.sortLocked {
+		lockOrder(m.servers, m.lockedOrder)
+	}
+	if dim {
+		sinkHidden(m.servers, m.config)
+	}
+	if m.sortLocked {
+		m.lockedOrder = rowOrder(m.servers)
+	}
+	m.applyFilter()
+}
+
+// filterHidden drops servers whose port is in the config's hidden list.
+func filterHidden(servers []scanner.Server, cfg config.Config) []scanner.Server {
+	out := make([]scanner.Server, 0, len(servers))
+	for _, s := range servers {
+		if !cfg.IsHidden(s.Port) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// sinkHidden moves servers on hidden ports below the rest, keeping the order
+// within each part, for hidden_mode: dim.
+func sinkHidden(servers []scanner.Server, cfg config.Config) {
+	slices.SortStableFunc(servers, func(a, b scanner.Server) int {
+		ha, hb := cfg.IsHidden(a.Port), cfg.IsHidden(b.Port)
+		switch {
+		case ha == hb:
+			return 0
+		case hb:
+			return -1
+		}
+		return 1
+	})
+}
+
+// normalizeNames sets each server's DisplayName where normalizing its process
+// name changes it, for the list to show in place of the raw name.
+func normalizeNames(servers []scanner.Server, cfg config.Config) {
+	for i, s := range servers {
+		if name := scanner.NormalizeProcessName(s.Process, cfg.CollapseVersions); name != s.Process {
+			servers[i].DisplayName = name
+		}
+	}
+}
+
+// mergeLabels returns a copy of servers with each port's label applied,
+// explicit or from auto_labels.
+func mergeLabels(servers []scanner.Server, cfg config.Config) []scanner.Server {
+	out := make([]scanner.Server, len(servers))
+	for i, s := range servers {
+		s.Label = cfg.LabelFor(s.Port)
+		out[i] = s
+	}
+	return out
+}
+
+// formatPIDs renders pids as "PID 12" or "PIDs 12, 13".
+func formatPIDs(pids []int) string {
+	if len(pids) == 1 {
+		return fmt.Sprintf("PID %d", pids[0])
+	}
+	return "PIDs " + joinInts(pids)
+}
+
+// joinInts renders ns as "12, 13".
+func joinInts(ns []int) string {
+	parts := make([]string, len(ns))
+	for i, n := range ns {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func dropLastRune(s string) string {
+	r := []rune(s)
+	if len(r) == 0 {
+		return s
+	}
+	return string(r[:len(r)-1])
+}
@@ -0,0 +1,104 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+// ErrPortDown is returned by Monitor when a watched port goes down and
+// MonitorOptions.ExitOnDown is set.
+var ErrPortDown = errors.New("port down")
+
+// MonitorOptions configures Monitor.
+type MonitorOptions struct {
+	Ports    []int         // ports to watch
+	Interval time.Duration // time between scans
+	// ExitOnDown makes Monitor return ErrPortDown as soon as a watched port
+	// is down, including one that was never up.
+	ExitOnDown bool
+	Now        func() time.Time // time.Now if nil; stamps each line
+}
+
+// Monitor scans with s every opts.Interval until ctx is done, for --monitor.
+// It writes each watched port's state to w after the first scan, then a line
+// whenever one goes up or down:
+//
+//	12:03:04  :8080  up
+//	12:03:09  :8080  up → down
+//
+// A port is up when it is listening and answers its health check. A failed
+// scan is reported and retried on the next tick. Monitor returns nil once
+// ctx is done.
+func Monitor(ctx context.Context, s scanner.Scanner, w io.Writer, opts MonitorOptions) error {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	mon := portMonitor{ports: opts.Ports}
+	for {
+		servers, err := safeScan(ctx, s)
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case err != nil && !errors.Is(err, scanner.ErrDegraded):
+			fmt.Fprintf(w, "%s  scan failed: %v\n", opts.Now().Format(time.TimeOnly), err)
+		default:
+			down := mon.record(servers, opts.Now(), w)
+			if opts.ExitOnDown && len(down) > 0 {
+				return fmt.Errorf("%w: %s", ErrPortDown, joinInts(down))
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(opts.Interval):
+		}
+	}
+}
+
+// portMonitor tracks the watched ports' states between Monitor's scans.
+type portMonitor struct {
+	ports []int
+	up    map[int]bool // nil until the first scan
+}
+
+// record takes one scan's servers, writes a line stamped at for every port
+// whose state is new or changed, and returns the ports that are down.
+func (p *portMonitor) record(servers []scanner.Server, at time.Time, w io.Writer) (down []int) {
+	healthy := make(map[int]bool, len(servers))
+	for _, s := range servers {
+		if !s.IsSocket() {
+			healthy[s.Port] = healthy[s.Port] || s.Healthy
+		}
+	}
+	first := p.up == nil
+	if first {
+		p.up = make(map[int]bool, len(p.ports))
+	}
+	stamp := at.Format(time.TimeOnly)
+	for _, port := range p.ports {
+		was, now := p.up[port], healthy[port]
+		switch {
+		case first:
+			fmt.Fprintf(w, "%s  :%d  %s\n", stamp, port, upDown(now))
+		case was != now:
+			fmt.Fprintf(w, "%s  :%d  %s → %s\n", stamp, port, upDown(was), upDown(now))
+		}
+		p.up[port] = now
+		if !now {
+			down = append(down, port)
+		}
+	}
+	return down
+}
+
+func upDown(up bool) string {
+	if up {
+		return "up"
+	}
+	return "down"
+}
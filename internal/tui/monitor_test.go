@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+func TestPortMonitorRecord(t *testing.T) {
+	up := []scanner.Server{{Port: 8080, Healthy: true}, {Port: 3000, Healthy: true}}
+	apiDown := []scanner.Server{{Port: 8080, Healthy: false}, {Port: 3000, Healthy: true}}
+	webGone := []scanner.Server{{Port: 8080, Healthy: true}}
+	seq := &sequenceScanner{results: [][]scanner.Server{up, up, apiDown, webGone}}
+	at := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	var out strings.Builder
+	mon := portMonitor{ports: []int{8080, 3000}}
+	for range 4 {
+		servers, _ := seq.Scan(context.Background())
+		mon.record(servers, at, &out)
+		at = at.Add(5 * time.Second)
+	}
+	want := "" +
+		"09:00:00  :8080  up\n" +
+		"09:00:00  :3000  up\n" +
+		"09:00:10  :8080  up → down\n" +
+		"09:00:15  :8080  down → up\n" +
+		"09:00:15  :3000  up → down\n"
+	if out.String() != want {
+		t.Errorf("monitor output =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestMonitorExitOnDown(t *testing.T) {
+	seq := &sequenceScanner{results: [][]scanner.Server{
+		{{Port: 8080, Healthy: true}},
+		{{Port: 8080, Healthy: true}},
+		{},
+	}}
+	var out strings.Builder
+	err := Monitor(context.Background(), seq, &out, MonitorOptions{Ports: []int{8080}, ExitOnDown: true})
+	if !errors.Is(err, ErrPortDown) || seq.calls != 3 {
+		t.Fatalf("Monitor() = %v after %d scans, want ErrPortDown on the third", err, seq.calls)
+	}
+	if !strings.HasSuffix(out.String(), ":8080  up → down\n") {
+		t.Errorf("monitor output = %q, want the down transition last", out.String())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Monitor(ctx, seq, &out, MonitorOptions{Ports: []int{8080}, ExitOnDown: true}); err != nil {
+		t.Errorf("Monitor() after cancel = %v, want nil", err)
+	}
+}
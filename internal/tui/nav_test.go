@@ -0,0 +1,69 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+// manyServers returns n servers on consecutive ports from 3000.
+func manyServers(n int) []scanner.Server {
+	out := make([]scanner.Server, n)
+	for i := range out {
+		out[i] = scanner.Server{Port: 3000 + i, PID: 100 + i, Process: "node"}
+	}
+	return out
+}
+
+func TestCountPrefixMovesRows(t *testing.T) {
+	m := newTestModel(t, manyServers(8))
+	m = typeText(t, m, "5j")
+	if m.cursor != 5 {
+		t.Errorf("5j: cursor = %d, want 5", m.cursor)
+	}
+	m = typeText(t, m, "2k")
+	if m.cursor != 3 {
+		t.Errorf("2k: cursor = %d, want 3", m.cursor)
+	}
+	m = typeText(t, m, "j")
+	if m.cursor != 4 || m.count != 0 {
+		t.Errorf("j after a count: cursor = %d, count = %d; want 4 and the count consumed", m.cursor, m.count)
+	}
+}
+
+func TestCountPrefixClamps(t *testing.T) {
+	m := newTestModel(t, manyServers(8))
+	m = typeText(t, m, "50j")
+	if m.cursor != 7 {
+		t.Errorf("50j: cursor = %d, want the last row, 7", m.cursor)
+	}
+	m = typeText(t, m, "99k")
+	if m.cursor != 0 {
+		t.Errorf("99k: cursor = %d, want 0", m.cursor)
+	}
+}
+
+func TestGoToRow(t *testing.T) {
+	m := newTestModel(t, manyServers(8))
+	m = typeText(t, m, "G")
+	if m.cursor != 7 {
+		t.Errorf("G: cursor = %d, want the last row, 7", m.cursor)
+	}
+	m = typeText(t, m, "3G")
+	if m.cursor != 2 {
+		t.Errorf("3G: cursor = %d, want 2", m.cursor)
+	}
+	m = typeText(t, m, "0j")
+	if m.cursor != 3 {
+		t.Errorf("a leading 0 is not a count: cursor = %d, want 3", m.cursor)
+	}
+
+	m, _ = runTyped(t, m, "6")
+	if m.cursor != 5 || m.mode != modeNormal {
+		t.Errorf(":6: cursor = %d, mode = %v; want row 6 in normal mode", m.cursor, m.mode)
+	}
+	m, _ = runTyped(t, m, "42")
+	if m.cursor != 7 {
+		t.Errorf(":42: cursor = %d, want it clamped to 7", m.cursor)
+	}
+}
@@ -0,0 +1,94 @@
+package tui
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+// notifyDebounce is the minimum gap between notifications for one port, so a
+// flapping server does not spam the desktop.
+const notifyDebounce = time.Minute
+
+// transition is a change worth telling the user about.
+type transition struct {
+	key  portKey
+	text string // e.g. "web-api on :8080 is DOWN"
+}
+
+// healthTransitions lists the servers in prev that went from healthy to
+// unhealthy, or stopped listening, in cur.
+func healthTransitions(prev, cur []scanner.Server) []transition {
+	now := make(map[portKey]scanner.Server, len(cur))
+	for _, s := range cur {
+		now[portKeyOf(s)] = s
+	}
+	var out []transition
+	for _, p := range prev {
+		if p.IsSocket() {
+			continue
+		}
+		k := portKeyOf(p)
+		c, ok := now[k]
+		switch {
+		case !ok:
+			out = append(out, transition{k, fmt.Sprintf("%s on :%d is GONE", displayName(p), p.Port)})
+		case p.Healthy && !c.Healthy:
+			out = append(out, transition{k, fmt.Sprintf("%s on :%d is DOWN", displayName(c), c.Port)})
+		}
+	}
+	return out
+}
+
+// notifyTransitions returns a command notifying about each transition whose
+// port has not been notified within notifyDebounce, and records the ones it
+// sends.
+func (m *Model) notifyTransitions(ts []transition, now time.Time) tea.Cmd {
+	if m.notified == nil {
+		m.notified = make(map[portKey]time.Time)
+	}
+	var cmds []tea.Cmd
+	for _, t := range ts {
+		if last, ok := m.notified[t.key]; ok && now.Sub(last) < notifyDebounce {
+			continue
+		}
+		m.notified[t.key] = now
+		cmds = append(cmds, doNotify(t.text))
+	}
+	return tea.Batch(cmds...)
+}
+
+// doNotify shows a desktop notification. Failures are ignored: a missing
+// notify-send should not get in the way of the list.
+func doNotify(text string) tea.Cmd {
+	return func() tea.Msg {
+		_ = notifyCommand("portview", text).Run()
+		return nil
+	}
+}
+
+func notifyCommand(title, text string) *exec.Cmd {
+	if runtime.GOOS == "darwin" {
+		script := fmt.Sprintf("display notification %s with title %s", strconv.Quote(text), strconv.Quote(title))
+		return exec.Command("osascript", "-e", script)
+	}
+	return exec.Command("notify-send", title, text)
+}
+
+// displayName is how a server is named in messages: its label, else its
+// process name, else its port.
+func displayName(s scanner.Server) string {
+	if s.Label != "" {
+		return s.Label
+	}
+	if name := s.Name(); name != "" {
+		return name
+	}
+	return strconv.Itoa(s.Port)
+}
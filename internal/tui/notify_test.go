@@ -0,0 +1,61 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+func TestHealthTransitions(t *testing.T) {
+	prev := []scanner.Server{
+		{Port: 3000, Process: "node", Healthy: true},
+		{Port: 5432, Process: "postgres", Healthy: true},
+		{Port: 8080, Process: "api", Label: "web-api", Healthy: true},
+		{Port: 9000, Process: "worker", Healthy: false},
+	}
+	cur := []scanner.Server{
+		{Port: 3000, Process: "node", Healthy: true},
+		{Port: 8080, Process: "api", Label: "web-api", Healthy: false},
+		{Port: 9000, Process: "worker", Healthy: false},
+		{Port: 9229, Process: "node", Healthy: false},
+	}
+	got := healthTransitions(prev, cur)
+	want := []transition{
+		{portKey{"TCP", 5432}, "postgres on :5432 is GONE"},
+		{portKey{"TCP", 8080}, "web-api on :8080 is DOWN"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("healthTransitions() = %v, want %v", got, want)
+	}
+}
+
+func TestNotifyTransitionsDebounces(t *testing.T) {
+	var m Model
+	down := []transition{{portKey{"TCP", 8080}, "api on :8080 is DOWN"}}
+	start := time.Now()
+	if cmd := m.notifyTransitions(down, start); cmd == nil {
+		t.Fatal("first transition should notify")
+	}
+	if cmd := m.notifyTransitions(down, start.Add(notifyDebounce/2)); cmd != nil {
+		t.Error("a repeat within notifyDebounce should be suppressed")
+	}
+	if cmd := m.notifyTransitions(down, start.Add(notifyDebounce)); cmd == nil {
+		t.Error("a repeat after notifyDebounce should notify again")
+	}
+}
+
+func TestScanNotifiesOnlyWhenEnabled(t *testing.T) {
+	m := newTestModel(t, testServers)
+	next, cmd := m.Update(scanResultMsg{servers: testServers[1:]})
+	if cmd != nil {
+		t.Error("notify is off by default; a vanished server should not notify")
+	}
+
+	m = next.(Model)
+	m.config.Notify = true
+	if _, cmd := m.Update(scanResultMsg{servers: testServers[2:]}); cmd == nil {
+		t.Error("with notify on, a vanished server should notify")
+	}
+}
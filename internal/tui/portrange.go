@@ -0,0 +1,38 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/jeramiahgcoffey/portview/internal/config"
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+// commonPorts are ports where a dev server is likely enough that finding one
+// outside the configured range is worth mentioning.
+var commonPorts = []int{80, 443, 3000, 4200, 5000, 5173, 8000, 8080, 8443}
+
+// rangeExcludedListeners returns, sorted and without duplicates, the common
+// ports in servers that fall outside r.
+func rangeExcludedListeners(servers []scanner.Server, r config.PortRange) []int {
+	var out []int
+	for _, s := range servers {
+		if !r.Contains(s.Port) && slices.Contains(commonPorts, s.Port) && !slices.Contains(out, s.Port) {
+			out = append(out, s.Port)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// rangeHint explains ports found by rangeExcludedListeners, or returns "" if
+// there are none.
+func rangeHint(ports []int, r config.PortRange) string {
+	switch len(ports) {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprintf("port %d is listening but outside your configured range (%d–%d)", ports[0], r.Min, r.Max)
+	}
+	return fmt.Sprintf("ports %s are listening but outside your configured range (%d–%d)", joinInts(ports), r.Min, r.Max)
+}
@@ -0,0 +1,123 @@
+package tui
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jeramiahgcoffey/portview/internal/config"
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+func TestRangeExcludedListeners(t *testing.T) {
+	servers := []scanner.Server{
+		{Port: 22}, {Port: 443}, {Port: 80}, {Port: 443}, {Port: 3000}, {Port: 631},
+	}
+	r := config.PortRange{Min: 1024, Max: 65535}
+	if got, want := rangeExcludedListeners(servers, r), []int{80, 443}; !slices.Equal(got, want) {
+		t.Errorf("rangeExcludedListeners() = %v, want %v", got, want)
+	}
+	if got := rangeExcludedListeners(servers, config.PortRange{Min: 1, Max: 65535}); got != nil {
+		t.Errorf("full range should exclude nothing, got %v", got)
+	}
+}
+
+func TestRangeHintShownOnStartup(t *testing.T) {
+	full := &scanner.MockScanner{Servers: []scanner.Server{{Port: 443}, {Port: 3000}}}
+	m := New(&scanner.MockScanner{}, config.Default(), Options{Unfiltered: full})
+	m = update(t, m, doRangeCheck(full, m.config.PortRange)())
+	if want := "port 443 is listening but outside your configured range (1024–65535); w widens it"; m.status != want {
+		t.Errorf("status = %q, want %q", m.status, want)
+	}
+	if got := rangeHint([]int{80, 443}, config.PortRange{Min: 1024, Max: 65535}); got != "ports 80, 443 are listening but outside your configured range (1024–65535)" {
+		t.Errorf("rangeHint() = %q", got)
+	}
+}
+
+func TestExcludedCountInStatusBar(t *testing.T) {
+	s := &scanner.MockScanner{Servers: testServers, OutsideRange: 7}
+	cfg := config.Default()
+	cfg.ShowExcluded = true
+	m := New(s, cfg, Options{})
+	m = update(t, m, doScan(s, m.now)())
+	if !strings.Contains(m.statusBar(), "+7 outside range") {
+		t.Errorf("status bar = %q, want the excluded count", m.statusBar())
+	}
+
+	m.config.ShowExcluded = false
+	if strings.Contains(m.statusBar(), "outside range") {
+		t.Errorf("status bar = %q, want no count without show_excluded", m.statusBar())
+	}
+}
+
+func TestWidenRangeIncludesExcludedPort(t *testing.T) {
+	full := &scanner.MockScanner{Servers: []scanner.Server{{Port: 443}, {Port: 3000}}}
+	var built []config.PortRange
+	m := New(&scanner.MockScanner{}, config.Default(), Options{
+		ConfigPath: filepath.Join(t.TempDir(), "config.yaml"),
+		Unfiltered: full,
+		NewScanner: func(cfg config.Config) scanner.Scanner {
+			built = append(built, cfg.PortRange)
+			return full
+		},
+	})
+	m.saveDelay = 0
+	m = update(t, m, doRangeCheck(full, m.config.PortRange)())
+
+	m, cmd := press(t, m, "w")
+	want := config.PortRange{Min: 443, Max: 65535}
+	if m.config.PortRange != want {
+		t.Errorf("PortRange = %+v, want %+v", m.config.PortRange, want)
+	}
+	if len(built) != 1 || built[0] != want {
+		t.Errorf("NewScanner calls = %+v, want one for %+v", built, want)
+	}
+	if cmd == nil {
+		t.Fatal("widening should save and rescan")
+	}
+	var saved bool
+	for _, c := range cmd().(tea.BatchMsg) {
+		if msg, ok := c().(saveDueMsg); ok {
+			saved = flushSave(t, m, func() tea.Msg { return msg }).err == nil
+		}
+	}
+	if !saved {
+		t.Error("widened range was not saved")
+	}
+	cfg, err := config.Load(m.configPath)
+	if err != nil || cfg.PortRange != want {
+		t.Errorf("saved PortRange = %+v (err %v), want %+v", cfg.PortRange, err, want)
+	}
+
+	m, cmd = press(t, m, "w")
+	if cmd != nil || m.status != "no listening ports outside the range" {
+		t.Errorf("second w: status = %q, cmd = %v; want a no-op", m.status, cmd)
+	}
+}
+
+func TestReloadRebuildsScannerFromNewConfig(t *testing.T) {
+	var built []config.Config
+	m := New(&scanner.MockScanner{}, config.Default(), Options{
+		NewScanner: func(cfg config.Config) scanner.Scanner {
+			built = append(built, cfg)
+			return &scanner.MockScanner{}
+		},
+	})
+
+	cfg := config.Default()
+	cfg.Labels = map[int]string{3000: "web"}
+	m = update(t, m, configLoadedMsg{cfg: cfg})
+	if len(built) != 0 {
+		t.Errorf("a reload that leaves the scan settings alone rebuilt the scanner: %+v", built)
+	}
+
+	cfg.HealthTimeout = 250 * time.Millisecond
+	m = update(t, m, configLoadedMsg{cfg: cfg})
+	if len(built) != 1 || built[0].HealthTimeout != cfg.HealthTimeout {
+		t.Errorf("NewScanner calls = %+v, want one with the reloaded health_timeout", built)
+	}
+}
@@ -0,0 +1,35 @@
+//go:build unix
+
+package tui
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+)
+
+// terminate asks pid to exit with SIGTERM.
+func terminate(pid int) error {
+	return syscall.Kill(pid, syscall.SIGTERM)
+}
+
+// processAlive reports whether pid still exists, including processes we may
+// not signal.
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// canSudo reports whether a denied kill can be retried with sudo.
+const canSudo = true
+
+// hookShell is the sh that runs hooks.
+func hookShell() (string, error) {
+	return "sh", nil
+}
+
+// detach starts cmd in a new session, so it outlives portview and is out of
+// reach of the terminal's signals.
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
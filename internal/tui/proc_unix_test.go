@@ -0,0 +1,44 @@
+//go:build unix
+
+package tui
+
+import (
+	"os/exec"
+	"syscall"
+	"testing"
+
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+func TestDetach(t *testing.T) {
+	cmd := exec.Command("true")
+	detach(cmd)
+	if cmd.SysProcAttr == nil || !cmd.SysProcAttr.Setsid {
+		t.Error("detached commands should run in their own session")
+	}
+}
+
+func TestDoRestartRerunsCommand(t *testing.T) {
+	old := exec.Command("sleep", "30")
+	old.Dir = t.TempDir()
+	if err := old.Start(); err != nil {
+		t.Skip("sleep unavailable:", err)
+	}
+	go old.Wait() // reap it so it does not linger as a zombie
+
+	msg := doRestart(scanner.Server{Port: 3000, PID: old.Process.Pid, Command: "sleep 31"})().(restartResultMsg)
+	if msg.err != nil {
+		t.Fatalf("doRestart() error = %v", msg.err)
+	}
+	defer syscall.Kill(msg.pid, syscall.SIGKILL)
+	if msg.pid == 0 || msg.pid == old.Process.Pid {
+		t.Fatalf("new PID = %d, want a fresh process", msg.pid)
+	}
+	cwd, err := scanner.ProcessCwd(t.Context(), msg.pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cwd != old.Dir {
+		t.Errorf("restarted in %q, want the old process's cwd %q", cwd, old.Dir)
+	}
+}
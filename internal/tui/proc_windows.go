@@ -0,0 +1,53 @@
+//go:build windows
+
+package tui
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// terminate ends pid. Windows has no SIGTERM to ask politely with, so this
+// is TerminateProcess, as os.Process.Kill is.
+func terminate(pid int) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	defer p.Release()
+	return p.Kill()
+}
+
+// processAlive reports whether pid still exists. FindProcess opens a handle
+// to the process on Windows, which fails once it is gone.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
+
+// canSudo is false: Windows has no sudo, so a denied kill needs portview
+// itself run as Administrator.
+const canSudo = false
+
+// hookShell finds the sh that runs hooks. Hooks are sh command lines, which
+// cmd.exe would misread, so without an sh on PATH, such as Git for
+// Windows', they are refused.
+func hookShell() (string, error) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		return "", errors.New("hooks run with sh, which is not on PATH")
+	}
+	return sh, nil
+}
+
+// detach starts cmd in its own process group, so console interrupts meant
+// for portview do not reach it.
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
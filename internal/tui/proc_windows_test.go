@@ -0,0 +1,43 @@
+//go:build windows
+
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestDetach(t *testing.T) {
+	cmd := exec.Command("cmd")
+	detach(cmd)
+	if cmd.SysProcAttr == nil || cmd.SysProcAttr.CreationFlags&syscall.CREATE_NEW_PROCESS_GROUP == 0 {
+		t.Error("detached commands should run in their own process group")
+	}
+}
+
+func TestOpenCommandWindows(t *testing.T) {
+	cmd := openCommand("http://localhost:3000")
+	if want := []string{"rundll32", "url.dll,FileProtocolHandler", "http://localhost:3000"}; !slices.Equal(cmd.Args, want) {
+		t.Errorf("args = %q, want %q", cmd.Args, want)
+	}
+}
+
+func TestKillDeniedSuggestsAdministrator(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m = update(t, m, killResultMsg{
+		pids:   []int{fakePIDOld},
+		denied: []int{fakePIDOld},
+		err:    fmt.Errorf("PID %d: %w", fakePIDOld, os.ErrPermission),
+	})
+	if m.mode != modeNormal {
+		t.Errorf("mode = %v, want no sudo prompt on Windows", m.mode)
+	}
+	if !strings.Contains(m.status, "run portview as Administrator") {
+		t.Errorf("status = %q, want a hint to run as Administrator", m.status)
+	}
+}
@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+// restartExitTimeout bounds how long a restart waits for the old process to
+// exit, and so release its port, before starting the new one.
+const restartExitTimeout = 5 * time.Second
+
+type restartResultMsg struct {
+	port int
+	pid  int // PID of the new process
+	err  error
+}
+
+// doRestart stops every process on s's port and re-runs s's command line in
+// the old process's working directory, detached from portview.
+func doRestart(s scanner.Server) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), restartExitTimeout)
+		defer cancel()
+		cwd, err := scanner.ProcessCwd(ctx, s.PID)
+		if err != nil {
+			return restartResultMsg{port: s.Port, err: fmt.Errorf("reading working directory of PID %d: %w", s.PID, err)}
+		}
+		cmd, err := restartCommand(s, cwd)
+		if err != nil {
+			return restartResultMsg{port: s.Port, err: err}
+		}
+		for _, pid := range s.AllPIDs() {
+			if err := terminate(pid); err != nil {
+				return restartResultMsg{port: s.Port, err: fmt.Errorf("stopping PID %d: %w", pid, err)}
+			}
+		}
+		if err := waitForExit(ctx, s.AllPIDs()); err != nil {
+			return restartResultMsg{port: s.Port, err: err}
+		}
+		if err := cmd.Start(); err != nil {
+			return restartResultMsg{port: s.Port, err: fmt.Errorf("starting %s: %w", cmd.Args[0], err)}
+		}
+		pid := cmd.Process.Pid
+		_ = cmd.Process.Release()
+		return restartResultMsg{port: s.Port, pid: pid}
+	}
+}
+
+// doRestartChecked restarts s, as the restart prompt showed it, but only if
+// its PIDs still own the port, as doKillChecked does for kills.
+func doRestartChecked(sc scanner.Scanner, s scanner.Server) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), scanTimeout)
+		current, err := currentPIDs(ctx, sc, portKeyOf(s))
+		cancel()
+		if err != nil {
+			return restartResultMsg{port: s.Port, err: fmt.Errorf("re-checking :%d: %w", s.Port, err)}
+		}
+		if !ownsAll(current, s.AllPIDs()) {
+			return killStaleMsg{port: s.Port, confirmed: s.AllPIDs(), current: current}
+		}
+		return doRestart(s)()
+	}
+}
+
+// restartCommand builds the detached command that re-runs s. The captured
+// command line has lost any quoting, so it is split on whitespace.
+func restartCommand(s scanner.Server, cwd string) (*exec.Cmd, error) {
+	args := strings.Fields(s.Command)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("no command captured for :%d", s.Port)
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = cwd
+	// Detached, the server keeps running after portview exits.
+	detach(cmd)
+	return cmd, nil
+}
+
+// waitForExit polls until none of pids is alive or ctx is done.
+func waitForExit(ctx context.Context, pids []int) error {
+	for {
+		alive := false
+		for _, pid := range pids {
+			if processAlive(pid) {
+				alive = true
+				break
+			}
+		}
+		if !alive {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s still running after SIGTERM", formatPIDs(pids))
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
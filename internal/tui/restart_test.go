@@ -0,0 +1,101 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeramiahgcoffey/portview/internal/config"
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+func TestRestartCommand(t *testing.T) {
+	cmd, err := restartCommand(scanner.Server{Port: 3000, Command: "node  server.js --port 3000"}, "/srv/web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"node", "server.js", "--port", "3000"}; !slices.Equal(cmd.Args, want) {
+		t.Errorf("Args = %v, want %v", cmd.Args, want)
+	}
+	if cmd.Dir != "/srv/web" {
+		t.Errorf("Dir = %q, want /srv/web", cmd.Dir)
+	}
+	if cmd.SysProcAttr == nil {
+		t.Error("restarted process should run detached")
+	}
+
+	if _, err := restartCommand(scanner.Server{Port: 3000, Command: "  "}, "/"); err == nil {
+		t.Error("an empty command should be refused")
+	}
+}
+
+func TestRestartNeedsOptIn(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m, cmd := press(t, m, "ctrl+r")
+	if m.mode != modeNormal || cmd != nil || !strings.Contains(m.status, "allow_restart") {
+		t.Errorf("restart without opt-in: mode = %v, status = %q; want it refused", m.mode, m.status)
+	}
+}
+
+func TestRestartConfirmFlow(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m.config.AllowRestart = true
+
+	m, _ = press(t, m, "ctrl+r")
+	if m.mode != modeConfirmRestart {
+		t.Fatalf("mode = %v, want restart confirmation", m.mode)
+	}
+	if view := m.View(); !strings.Contains(view, "Restart :3000?") || !strings.Contains(view, "Stops PID 100 and runs:") || !strings.Contains(view, "node server.js") {
+		t.Errorf("confirmation should show the command to run:\n%s", view)
+	}
+	m, cmd := press(t, m, "n")
+	if m.mode != modeNormal || cmd != nil || m.status != "restart cancelled" {
+		t.Errorf("n: mode = %v, status = %q; want cancelled", m.mode, m.status)
+	}
+
+	m, _ = press(t, m, "ctrl+r")
+	if _, cmd := press(t, m, "y"); cmd == nil {
+		t.Error("y should return the restart command")
+	}
+}
+
+func TestRestartTargetsServerShownInPrompt(t *testing.T) {
+	web := scanner.Server{Port: 3000, PID: fakePIDOld, Process: "node", Command: "node server.js"}
+	db := scanner.Server{Port: 5432, PID: fakePIDOld + 10, Process: "postgres", Command: "postgres -D data"}
+	seq := &sequenceScanner{results: [][]scanner.Server{
+		{web, db},
+		{{Port: 3000, PID: fakePIDNew, Process: "node", Command: "node server.js"}, db},
+	}}
+	cfg := config.Default()
+	cfg.AllowRestart = true
+	m := New(seq, cfg, Options{})
+	m = update(t, m, doScan(seq, time.Now)())
+	m, _ = press(t, m, "ctrl+r")
+
+	// A scan while the prompt is up puts another server on the cursor row.
+	m = update(t, m, scanResultMsg{servers: []scanner.Server{db}})
+	if view := m.View(); !strings.Contains(view, "Restart :3000?") {
+		t.Errorf("prompt should keep showing the server it asked about:\n%s", view)
+	}
+
+	// Meanwhile :3000 restarted on its own; nothing is stopped.
+	_, cmd := press(t, m, "y")
+	msg := cmd()
+	stale, ok := msg.(killStaleMsg)
+	if !ok {
+		t.Fatalf("restart after a PID change returned %T, want killStaleMsg", msg)
+	}
+	if stale.port != 3000 || !slices.Equal(stale.confirmed, []int{fakePIDOld}) || !slices.Equal(stale.current, []int{fakePIDNew}) {
+		t.Errorf("killStaleMsg = %+v, want :3000 confirmed PID %d, current PID %d", stale, fakePIDOld, fakePIDNew)
+	}
+}
+
+func TestRestartRefusesEmptyCommand(t *testing.T) {
+	m := newTestModel(t, []scanner.Server{{Port: 3000, PID: 100, Process: "node"}})
+	m.config.AllowRestart = true
+	m, _ = press(t, m, "ctrl+r")
+	if m.mode != modeNormal || m.status != "no command captured for :3000" {
+		t.Errorf("mode = %v, status = %q; want the empty command refused", m.mode, m.status)
+	}
+}
@@ -0,0 +1,44 @@
+package tui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// saveDebounce is how long config edits must pause before they are written,
+// so a burst of label or hide changes becomes a single write.
+const saveDebounce = 500 * time.Millisecond
+
+// saveDueMsg fires saveDelay after a config edit. Only the one carrying the
+// latest seq writes; earlier ones were superseded by later edits.
+type saveDueMsg struct{ seq int }
+
+// scheduleSave arranges for the config to be written once edits go quiet.
+func (m *Model) scheduleSave() tea.Cmd {
+	m.saveSeq++
+	m.savePending = true
+	seq := m.saveSeq
+	return tea.Tick(m.saveDelay, func(time.Time) tea.Msg {
+		return saveDueMsg{seq: seq}
+	})
+}
+
+// quit exits, first writing any config edit still waiting on its debounce.
+func (m *Model) quit() tea.Cmd {
+	if !m.savePending {
+		return tea.Quit
+	}
+	m.savePending = false
+	return tea.Sequence(m.saveConfig(), tea.Quit)
+}
+
+// saveConfig writes the config, minus any session-only overrides that
+// Options.BeforeSave takes back out.
+func (m Model) saveConfig() tea.Cmd {
+	cfg := m.config
+	if m.beforeSave != nil {
+		cfg = m.beforeSave(cfg.Clone())
+	}
+	return doSaveConfig(m.configPath, cfg)
+}
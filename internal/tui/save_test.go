@@ -0,0 +1,80 @@
+package tui
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jeramiahgcoffey/portview/internal/config"
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+func TestRapidSavesCoalesce(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m, first := runTyped(t, m, "label 3000 web")
+	m, second := runTyped(t, m, "label 8080 api")
+
+	var writes int
+	for _, cmd := range []tea.Cmd{first, second} {
+		next, write := m.Update(cmd())
+		m = next.(Model)
+		if write != nil {
+			writes++
+			if msg, ok := write().(configSavedMsg); !ok || msg.err != nil {
+				t.Fatalf("write = %#v", msg)
+			}
+		}
+	}
+	if writes != 1 {
+		t.Fatalf("writes = %d, want the two edits coalesced into 1", writes)
+	}
+	saved, err := config.Load(m.configPath)
+	if err != nil || saved.Labels[3000] != "web" || saved.Labels[8080] != "api" {
+		t.Errorf("saved labels = %v (err %v), want both edits", saved.Labels, err)
+	}
+}
+
+func TestQuitFlushesPendingSave(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m, _ = runTyped(t, m, "hide 5432")
+	if !m.savePending {
+		t.Fatal("hide should leave a save pending")
+	}
+	m, cmd := press(t, m, "q")
+	if m.savePending || cmd == nil {
+		t.Fatal("quit should take over the pending save")
+	}
+	if _, ok := cmd().(tea.QuitMsg); ok {
+		t.Fatal("quit with a pending save should write before quitting")
+	}
+}
+
+func TestBeforeSaveAdjustsWrittenConfig(t *testing.T) {
+	cfg := config.Default()
+	cfg.RefreshInterval = time.Hour // a session-only override
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	m := New(&scanner.MockScanner{Servers: testServers}, cfg, Options{
+		ConfigPath: path,
+		BeforeSave: func(c config.Config) config.Config {
+			c.RefreshInterval = config.Default().RefreshInterval
+			return c
+		},
+	})
+	m.saveDelay = 0
+	m = update(t, m, scanResultMsg{servers: testServers})
+	m, cmd := runTyped(t, m, "label 3000 web")
+	assertSaved(t, m, cmd)
+
+	saved, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.RefreshInterval != config.Default().RefreshInterval || saved.Labels[3000] != "web" {
+		t.Errorf("saved config = %+v, want the label but not the override", saved)
+	}
+	if m.config.RefreshInterval != time.Hour {
+		t.Error("BeforeSave must not change the running config")
+	}
+}
@@ -0,0 +1,43 @@
+package tui
+
+// wellKnownServices names common ports as /etc/services does, for the
+// service_names display. It is deliberately small: a name is only useful
+// if it is recognisable at a glance.
+var wellKnownServices = map[int]string{
+	21:    "ftp",
+	22:    "ssh",
+	23:    "telnet",
+	25:    "smtp",
+	53:    "domain",
+	80:    "http",
+	110:   "pop3",
+	143:   "imap",
+	389:   "ldap",
+	443:   "https",
+	465:   "submissions",
+	587:   "submission",
+	631:   "ipp",
+	636:   "ldaps",
+	993:   "imaps",
+	995:   "pop3s",
+	1433:  "ms-sql-s",
+	1883:  "mqtt",
+	2375:  "docker",
+	2376:  "docker-s",
+	3306:  "mysql",
+	3389:  "ms-wbt-server",
+	5353:  "mdns",
+	5432:  "postgresql",
+	5672:  "amqp",
+	6379:  "redis",
+	8080:  "http-alt",
+	8443:  "https-alt",
+	9090:  "websm",
+	11211: "memcache",
+	27017: "mongodb",
+}
+
+// serviceName returns the well-known name of port, or "" if it has none.
+func serviceName(port int) string {
+	return wellKnownServices[port]
+}
@@ -0,0 +1,49 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+func TestPortCell(t *testing.T) {
+	tests := []struct {
+		s        scanner.Server
+		services bool
+		want     string
+	}{
+		{scanner.Server{Port: 80}, true, "80 (http)"},
+		{scanner.Server{Port: 80}, false, "80"},
+		{scanner.Server{Port: 4567}, true, "4567"},
+		{scanner.Server{Port: 4567, PIDs: []int{1, 2}}, true, "4567 (x2)"},
+		{scanner.Server{Port: 80, PIDs: []int{1, 2}}, true, "80 (http x2)"},
+	}
+	for _, tt := range tests {
+		if got := portCell(tt.s, tt.services); got != tt.want {
+			t.Errorf("portCell(:%d, %v) = %q, want %q", tt.s.Port, tt.services, got, tt.want)
+		}
+	}
+}
+
+func TestServiceNamesToggle(t *testing.T) {
+	m := newTestModel(t, []scanner.Server{
+		{Port: 80, PID: 1, Process: "nginx"},
+		{Port: 4567, PID: 2, Process: "ruby"},
+	})
+	if strings.Contains(m.View(), "(http)") {
+		t.Fatalf("service names should be off by default:\n%s", m.View())
+	}
+	m, cmd := press(t, m, "N")
+	if !m.config.ServiceNames {
+		t.Fatal("N should turn service names on")
+	}
+	assertSaved(t, m, cmd)
+	view := m.View()
+	if !strings.Contains(view, "80 (http)") {
+		t.Errorf("port 80 should be annotated with http:\n%s", view)
+	}
+	if !strings.Contains(view, "4567 ") || strings.Contains(view, "4567 (") {
+		t.Errorf("an unknown port should render plain:\n%s", view)
+	}
+}
@@ -0,0 +1,109 @@
+package tui
+
+import (
+	"cmp"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+// sortServers orders servers in place by a config.SortKeys key, breaking
+// ties by port. Unresolved PIDs and process names sort last. An empty or
+// unknown key means port order. Unix sockets follow every port, by path.
+func sortServers(servers []scanner.Server, by string) {
+	var less func(a, b scanner.Server) bool
+	switch by {
+	case "pid":
+		less = func(a, b scanner.Server) bool {
+			if a.PID != b.PID {
+				return a.PID != 0 && (b.PID == 0 || a.PID < b.PID)
+			}
+			return portLess(a, b)
+		}
+	case "process":
+		less = func(a, b scanner.Server) bool {
+			if a.Process != b.Process {
+				return a.Process != "" && (b.Process == "" || a.Process < b.Process)
+			}
+			return portLess(a, b)
+		}
+	default:
+		less = portLess
+	}
+	sort.SliceStable(servers, func(i, j int) bool { return less(servers[i], servers[j]) })
+}
+
+// portLess orders servers by port, then Unix sockets by path.
+func portLess(a, b scanner.Server) bool {
+	if a.IsSocket() != b.IsSocket() {
+		return b.IsSocket()
+	}
+	if a.IsSocket() {
+		return a.SocketPath < b.SocketPath
+	}
+	return a.Port < b.Port
+}
+
+// portKey identifies a port's server across scans. TCP and UDP on one port
+// are different servers, owned by different processes.
+type portKey struct {
+	proto string // Server.Proto
+	port  int
+}
+
+func portKeyOf(s scanner.Server) portKey {
+	return portKey{s.Proto(), s.Port}
+}
+
+// String names k as messages do: ":3000", or ":5353/udp" for UDP.
+func (k portKey) String() string {
+	if k.proto == scanner.ProtoUDP {
+		return fmt.Sprintf(":%d/udp", k.port)
+	}
+	return fmt.Sprintf(":%d", k.port)
+}
+
+// comparePortKeys orders keys by port, then protocol.
+func comparePortKeys(a, b portKey) int {
+	return cmp.Or(cmp.Compare(a.port, b.port), strings.Compare(a.proto, b.proto))
+}
+
+// rowKey identifies a list row across scans, for the sort lock.
+type rowKey struct {
+	proto string
+	port  int
+	path  string // SocketPath, for Unix sockets
+	addr  string
+}
+
+func rowKeyOf(s scanner.Server) rowKey {
+	return rowKey{s.Proto(), s.Port, s.SocketPath, s.Addr}
+}
+
+// rowOrder returns the keys of servers in order.
+func rowOrder(servers []scanner.Server) []rowKey {
+	order := make([]rowKey, len(servers))
+	for i, s := range servers {
+		order[i] = rowKeyOf(s)
+	}
+	return order
+}
+
+// lockOrder rearranges sorted servers in place to follow order, a row order
+// taken earlier. Rows that are not in it go after the rest, in their sorted
+// order.
+func lockOrder(servers []scanner.Server, order []rowKey) {
+	index := make(map[rowKey]int, len(order))
+	for i, k := range order {
+		index[k] = i
+	}
+	pos := func(s scanner.Server) int {
+		if i, ok := index[rowKeyOf(s)]; ok {
+			return i
+		}
+		return len(order)
+	}
+	sort.SliceStable(servers, func(i, j int) bool { return pos(servers[i]) < pos(servers[j]) })
+}
@@ -0,0 +1,70 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jeramiahgcoffey/portview/internal/config"
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+var sortInput = []scanner.Server{
+	{Port: 3000, PID: 300, Process: "node"},
+	{Port: 5432, PID: 100, Process: "postgres"},
+	{Port: 8080, PID: 0},
+	{Port: 9000, PID: 200, Process: "api"},
+}
+
+func TestDefaultSortAppliedToScan(t *testing.T) {
+	tests := []struct {
+		by   string
+		want []int
+	}{
+		{"", []int{3000, 5432, 8080, 9000}},
+		{"port", []int{3000, 5432, 8080, 9000}},
+		{"pid", []int{5432, 9000, 3000, 8080}},
+		{"process", []int{9000, 3000, 5432, 8080}},
+	}
+	for _, tt := range tests {
+		cfg := config.Default()
+		cfg.DefaultSort = tt.by
+		m := New(&scanner.MockScanner{}, cfg, Options{})
+		m = update(t, m, scanResultMsg{servers: slices.Clone(sortInput)})
+		var got []int
+		for _, s := range m.filtered {
+			got = append(got, s.Port)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("default_sort %q: order = %v, want %v", tt.by, got, tt.want)
+		}
+	}
+}
+
+func TestSortLockKeepsOrder(t *testing.T) {
+	cfg := config.Default()
+	cfg.DefaultSort = "pid"
+	m := New(&scanner.MockScanner{}, cfg, Options{})
+	m = update(t, m, scanResultMsg{servers: slices.Clone(sortInput)})
+	m, _ = press(t, m, "s")
+	if !m.sortLocked {
+		t.Fatal("s should lock the row order")
+	}
+
+	// New PIDs would reorder every row; the lock keeps the old order, drops
+	// 8080 and puts the new 4000 last.
+	refresh := []scanner.Server{
+		{Port: 3000, PID: 10, Process: "node"},
+		{Port: 4000, PID: 5, Process: "vite"},
+		{Port: 5432, PID: 900, Process: "postgres"},
+		{Port: 9000, PID: 20, Process: "api"},
+	}
+	m = update(t, m, scanResultMsg{servers: refresh})
+	if got, want := portsOf(m.filtered), []int{5432, 9000, 3000, 4000}; !slices.Equal(got, want) {
+		t.Errorf("locked order = %v, want %v", got, want)
+	}
+
+	m, _ = press(t, m, "s")
+	if got, want := portsOf(m.filtered), []int{4000, 3000, 9000, 5432}; m.sortLocked || !slices.Equal(got, want) {
+		t.Errorf("after unlocking: order = %v, want it re-sorted by PID as %v", got, want)
+	}
+}
@@ -0,0 +1,74 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+// healthHistorySize is how many health results each port remembers, and so
+// the width of the sparkline column.
+const healthHistorySize = 10
+
+// healthHistory is a fixed-size ring buffer of health results, oldest first.
+type healthHistory struct {
+	buf   [healthHistorySize]bool
+	start int
+	n     int
+}
+
+func (h *healthHistory) record(healthy bool) {
+	if h.n < healthHistorySize {
+		h.buf[(h.start+h.n)%healthHistorySize] = healthy
+		h.n++
+		return
+	}
+	h.buf[h.start] = healthy
+	h.start = (h.start + 1) % healthHistorySize
+}
+
+// values returns the recorded results, oldest first.
+func (h healthHistory) values() []bool {
+	out := make([]bool, h.n)
+	for i := range out {
+		out[i] = h.buf[(h.start+i)%healthHistorySize]
+	}
+	return out
+}
+
+// sparkline renders results as a row of blocks: "█" healthy, "▁" not.
+func sparkline(results []bool) string {
+	var b strings.Builder
+	for _, ok := range results {
+		if ok {
+			b.WriteString("█")
+		} else {
+			b.WriteString("▁")
+		}
+	}
+	return b.String()
+}
+
+// recordHealth appends each server's current health to its port's history
+// and forgets ports that are no longer listening.
+func (m *Model) recordHealth(servers []scanner.Server) {
+	if m.health == nil {
+		m.health = make(map[portKey]healthHistory)
+	}
+	present := make(map[portKey]bool, len(servers))
+	for _, s := range servers {
+		if s.IsSocket() {
+			continue // health history is kept per port
+		}
+		k := portKeyOf(s)
+		present[k] = true
+		h := m.health[k]
+		h.record(s.Healthy)
+		m.health[k] = h
+	}
+	for k := range m.health {
+		if !present[k] {
+			delete(m.health, k)
+		}
+	}
+}
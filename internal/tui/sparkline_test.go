@@ -0,0 +1,62 @@
+package tui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+func TestHealthHistoryRecordsInOrder(t *testing.T) {
+	var h healthHistory
+	for _, ok := range []bool{true, false, true} {
+		h.record(ok)
+	}
+	if got, want := h.values(), []bool{true, false, true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("values() = %v, want %v", got, want)
+	}
+}
+
+func TestHealthHistoryDropsOldest(t *testing.T) {
+	var h healthHistory
+	for i := range healthHistorySize + 3 {
+		h.record(i%2 == 0)
+	}
+	got := h.values()
+	if len(got) != healthHistorySize {
+		t.Fatalf("len(values()) = %d, want %d", len(got), healthHistorySize)
+	}
+	// The first three results (i = 0, 1, 2) were evicted, so the oldest kept
+	// result is i = 3, which was unhealthy.
+	if got[0] || !got[1] || !got[len(got)-1] {
+		t.Errorf("values() = %v, want to start at the fourth result", got)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if got, want := sparkline([]bool{false, false, true, false, true}), "▁▁█▁█"; got != want {
+		t.Errorf("sparkline() = %q, want %q", got, want)
+	}
+	if got := sparkline(nil); got != "" {
+		t.Errorf("sparkline(nil) = %q, want empty", got)
+	}
+}
+
+func TestScansBuildSparklineColumn(t *testing.T) {
+	m := newTestModel(t, []scanner.Server{{Port: 3000, PID: 1, Process: "node", Healthy: true}})
+	for _, ok := range []bool{false, true, false} {
+		m = update(t, m, scanResultMsg{servers: []scanner.Server{{Port: 3000, PID: 1, Process: "node", Healthy: ok}}})
+	}
+	if got, want := m.health[portKey{"TCP", 3000}].values(), []bool{true, false, true, false}; !reflect.DeepEqual(got, want) {
+		t.Errorf("health[3000] = %v, want %v", got, want)
+	}
+	if view := m.View(); !strings.Contains(view, "█▁█▁") {
+		t.Errorf("view missing sparkline:\n%s", view)
+	}
+
+	m = update(t, m, scanResultMsg{servers: nil})
+	if _, ok := m.health[portKey{"TCP", 3000}]; ok {
+		t.Error("history should be dropped once the port stops listening")
+	}
+}
@@ -0,0 +1,33 @@
+package tui
+
+import (
+	"os/exec"
+	"strconv"
+	"syscall"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sudoKillResultMsg reports the outcome of a kill retried through sudo.
+type sudoKillResultMsg struct {
+	pids []int
+	err  error
+}
+
+// doSudoKill sends sig to pids with "sudo kill". The TUI is suspended while
+// it runs so sudo can prompt for a password on the terminal.
+func doSudoKill(pids []int, sig syscall.Signal) tea.Cmd {
+	return tea.ExecProcess(sudoKillCommand(pids, sig), func(err error) tea.Msg {
+		return sudoKillResultMsg{pids: pids, err: err}
+	})
+}
+
+// sudoKillCommand builds "sudo kill -SIG PID...". The signal is given by
+// number, which every kill(1) accepts.
+func sudoKillCommand(pids []int, sig syscall.Signal) *exec.Cmd {
+	args := []string{"kill", "-" + strconv.Itoa(int(sig))}
+	for _, pid := range pids {
+		args = append(args, strconv.Itoa(pid))
+	}
+	return exec.Command("sudo", args...)
+}
@@ -0,0 +1,52 @@
+//go:build unix
+
+package tui
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestKillEPERMOffersSudo(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m = update(t, m, killResultMsg{
+		pids:   []int{fakePIDOld},
+		denied: []int{fakePIDOld},
+		err:    fmt.Errorf("PID %d: %w", fakePIDOld, syscall.EPERM),
+	})
+	if m.mode != modeConfirmSudo {
+		t.Fatalf("mode = %v, want modeConfirmSudo", m.mode)
+	}
+	if view := m.View(); !strings.Contains(view, fmt.Sprintf("Permission denied killing PID %d. Retry with sudo kill?", fakePIDOld)) {
+		t.Errorf("sudo prompt missing from view:\n%s", view)
+	}
+
+	// Anything but y declines. The y path is not exercised here since its
+	// command would run sudo.
+	m, cmd := press(t, m, "n")
+	if m.mode != modeNormal || cmd != nil || m.sudoPIDs != nil {
+		t.Errorf("n should cancel without a command, mode = %v", m.mode)
+	}
+}
+
+func TestKillOtherErrorDoesNotOfferSudo(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m = update(t, m, killResultMsg{
+		pids: []int{fakePIDOld},
+		err:  fmt.Errorf("PID %d: %w", fakePIDOld, syscall.ESRCH),
+	})
+	if m.mode != modeNormal {
+		t.Errorf("mode = %v, want modeNormal for a non-EPERM failure", m.mode)
+	}
+}
+
+func TestSudoKillCommand(t *testing.T) {
+	cmd := sudoKillCommand([]int{12, 13}, syscall.SIGTERM)
+	want := []string{"sudo", "kill", "-15", "12", "13"}
+	if !reflect.DeepEqual(cmd.Args, want) {
+		t.Errorf("args = %q, want %q", cmd.Args, want)
+	}
+}
@@ -0,0 +1,25 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+// plainTable renders servers with the list's columns and no styling, for
+// pasting elsewhere. Health is spelled out since the sparkline only makes
+// sense on screen.
+func plainTable(servers []scanner.Server) string {
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(formatRow(colCommand, "PORT", "PROCESS", "COMMAND", "HEALTH", "LABEL"), " "))
+	b.WriteString("\n")
+	for _, s := range servers {
+		health := "down"
+		if s.Healthy {
+			health = "healthy"
+		}
+		b.WriteString(strings.TrimRight(formatRow(colCommand, portCell(s, false), s.Name(), s.Command, health, s.Label), " "))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,31 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+func TestPlainTableMatchesVisibleRows(t *testing.T) {
+	m := newTestModel(t, append(testServers, scanner.Server{Port: 9000, PID: 400, PIDs: []int{400, 401}, Process: "api"}))
+	m.config.SetLabel(3000, "web")
+	m.applyPipeline()
+	// Only the visible rows are copied; the filter drops 5432.
+	m, _ = press(t, m, "/")
+	m = typeText(t, m, "0")
+	m, _ = press(t, m, "enter")
+
+	got := plainTable(m.filtered)
+	want := "" +
+		"PORT        PROCESS        COMMAND                          HEALTH     LABEL\n" +
+		"3000        node           node server.js                   healthy    web\n" +
+		"8080        go             go run main.go                   down\n" +
+		"9000 (x2)   api                                             down\n"
+	if got != want {
+		t.Errorf("plainTable() =\n%s\nwant\n%s", got, want)
+	}
+	if strings.Contains(got, "\x1b[") {
+		t.Error("plain table contains ANSI escapes")
+	}
+}
@@ -0,0 +1,1391 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+
+	"github.com/jeramiahgcoffey/portview/internal/config"
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+var testServers = []scanner.Server{
+	{Port: 3000, PID: 100, Process: "node", Command: "node server.js", State: "LISTEN", Healthy: true},
+	{Port: 5432, PID: 200, Process: "postgres", Command: "postgres -D /data", State: "LISTEN", Healthy: true},
+	{Port: 8080, PID: 300, Process: "go", Command: "go run main.go", State: "LISTEN"},
+}
+
+// newTestModel returns a model that has already received one scan of servers.
+func newTestModel(t *testing.T, servers []scanner.Server) Model {
+	t.Helper()
+	cfg := config.Default()
+	m := New(&scanner.MockScanner{Servers: servers}, cfg, Options{
+		ConfigPath: filepath.Join(t.TempDir(), "config.yaml"),
+	})
+	m.saveDelay = 0
+	return update(t, m, scanResultMsg{servers: servers})
+}
+
+// flushSave runs the debounced save cmd scheduled and returns the result of
+// the write it leads to.
+func flushSave(t *testing.T, m Model, cmd tea.Cmd) configSavedMsg {
+	t.Helper()
+	due, ok := cmd().(saveDueMsg)
+	if !ok {
+		t.Fatal("expected a scheduled save")
+	}
+	_, write := m.Update(due)
+	if write == nil {
+		t.Fatal("the latest scheduled save did not write")
+	}
+	msg, _ := write().(configSavedMsg)
+	return msg
+}
+
+func update(t *testing.T, m Model, msg tea.Msg) Model {
+	t.Helper()
+	next, _ := m.Update(msg)
+	return next.(Model)
+}
+
+// press sends a key to m. Named keys ("enter", "esc", "backspace", "up",
+// "down", "space") map to their key types; anything else is sent as runes.
+func press(t *testing.T, m Model, k string) (Model, tea.Cmd) {
+	t.Helper()
+	next, cmd := m.Update(keyMsg(k))
+	return next.(Model), cmd
+}
+
+func keyMsg(k string) tea.KeyMsg {
+	switch k {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "backspace":
+		return tea.KeyMsg{Type: tea.KeyBackspace}
+	case "up":
+		return tea.KeyMsg{Type: tea.KeyUp}
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	case "space":
+		return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+	case "ctrl+r":
+		return tea.KeyMsg{Type: tea.KeyCtrlR}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+}
+
+func typeText(t *testing.T, m Model, s string) Model {
+	t.Helper()
+	for _, r := range s {
+		k := string(r)
+		if r == ' ' {
+			k = "space"
+		}
+		m, _ = press(t, m, k)
+	}
+	return m
+}
+
+func TestNavigationClampsAtEnds(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m, _ = press(t, m, "k")
+	if m.cursor != 0 {
+		t.Fatalf("cursor = %d after moving up at top, want 0", m.cursor)
+	}
+	for range 5 {
+		m, _ = press(t, m, "j")
+	}
+	if m.cursor != len(testServers)-1 {
+		t.Errorf("cursor = %d after moving past bottom, want %d", m.cursor, len(testServers)-1)
+	}
+}
+
+func TestScanResultHidesAndMergesLabels(t *testing.T) {
+	cfg := config.Default()
+	cfg.SetLabel(8080, "backend")
+	cfg.Hidden = []int{5432}
+	m := New(&scanner.MockScanner{}, cfg, Options{})
+	m = update(t, m, scanResultMsg{servers: testServers})
+
+	if len(m.filtered) != 2 {
+		t.Fatalf("len(filtered) = %d, want 2 (5432 hidden)", len(m.filtered))
+	}
+	if m.filtered[1].Port != 8080 || m.filtered[1].Label != "backend" {
+		t.Errorf("filtered[1] = %+v, want 8080 labelled backend", m.filtered[1])
+	}
+}
+
+func TestFilterNarrowsList(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m, _ = press(t, m, "/")
+	m = typeText(t, m, "post")
+	if m.mode != modeFilter {
+		t.Fatalf("mode = %v, want modeFilter", m.mode)
+	}
+	if len(m.filtered) != 1 || m.filtered[0].Port != 5432 {
+		t.Fatalf("filtered = %+v, want only 5432", m.filtered)
+	}
+
+	m, _ = press(t, m, "enter")
+	if m.mode != modeNormal || m.filterText != "post" {
+		t.Errorf("after enter: mode = %v, filter = %q; want normal with filter kept", m.mode, m.filterText)
+	}
+
+	m, _ = press(t, m, "esc")
+	if m.filterText != "" || len(m.filtered) != len(testServers) {
+		t.Errorf("esc should clear the filter, got %q with %d rows", m.filterText, len(m.filtered))
+	}
+}
+
+func TestNewSeedsFilter(t *testing.T) {
+	m := New(&scanner.MockScanner{}, config.Default(), Options{Filter: "post", FilterMode: true})
+	if m.filterText != "post" || m.mode != modeFilter {
+		t.Fatalf("New: filter = %q, mode = %v; want post in filter mode", m.filterText, m.mode)
+	}
+	m = update(t, m, scanResultMsg{servers: testServers})
+	if len(m.filtered) != 1 || m.filtered[0].Port != 5432 {
+		t.Errorf("first scan filtered = %+v, want only 5432", m.filtered)
+	}
+	m = typeText(t, m, "gres")
+	if m.filterText != "postgres" || len(m.filtered) != 1 {
+		t.Errorf("typing should extend the seeded filter, got %q", m.filterText)
+	}
+}
+
+func TestFilterMatchesPortAndLabel(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m.config.SetLabel(3000, "frontend")
+	m.applyPipeline()
+
+	m.filterText = "808"
+	m.applyFilter()
+	if len(m.filtered) != 1 || m.filtered[0].Port != 8080 {
+		t.Errorf("port filter = %+v, want only 8080", m.filtered)
+	}
+
+	m.filterText = "FRONT"
+	m.applyFilter()
+	if len(m.filtered) != 1 || m.filtered[0].Port != 3000 {
+		t.Errorf("label filter = %+v, want only 3000", m.filtered)
+	}
+}
+
+func TestKillConfirmFlow(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m, _ = press(t, m, "x")
+	if m.mode != modeConfirmKill {
+		t.Fatalf("mode = %v, want modeConfirmKill", m.mode)
+	}
+	if !strings.Contains(m.View(), "Kill PID 100") {
+		t.Errorf("confirm prompt missing from view:\n%s", m.View())
+	}
+
+	m, cmd := press(t, m, "n")
+	if m.mode != modeNormal || cmd != nil {
+		t.Fatalf("n should cancel without a command, mode = %v", m.mode)
+	}
+
+	m, _ = press(t, m, "x")
+	m, cmd = press(t, m, "y")
+	if m.mode != modeNormal || cmd == nil {
+		t.Fatalf("y should return to normal with a kill command, mode = %v", m.mode)
+	}
+}
+
+func TestKillConfirmOverlay(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m = update(t, m, tea.WindowSizeMsg{Width: 100, Height: 20})
+	m, _ = press(t, m, "x")
+	view := m.View()
+	for _, want := range []string{"╭", "Kill PID 100?", "node on :3000", "node server.js", "y: yes"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("confirm overlay missing %q:\n%s", want, view)
+		}
+	}
+	if !strings.Contains(view, "postgres") {
+		t.Errorf("the list should still show behind the overlay:\n%s", view)
+	}
+	if lines := strings.Count(view, "\n") + 1; lines != 20 {
+		t.Errorf("overlay view is %d lines, want the window's 20", lines)
+	}
+
+	m.config.QuickConfirm = true
+	view = m.View()
+	if strings.Contains(view, "╭") || !strings.Contains(view, "Kill PID 100 (node on :3000)? (y/n)") {
+		t.Errorf("quick_confirm should ask in the status bar:\n%s", view)
+	}
+}
+
+func TestRootKillNeedsSecondConfirm(t *testing.T) {
+	cfg := config.Default()
+	cfg.ConfirmRootKills = true
+	m := New(&scanner.MockScanner{Servers: testServers}, cfg, Options{AsRoot: true})
+	m = update(t, m, scanResultMsg{servers: testServers})
+	if !strings.Contains(m.View(), "[root]") {
+		t.Errorf("status bar should say portview runs as root:\n%s", m.View())
+	}
+
+	// The kill commands returned here are never run.
+	m, _ = press(t, m, "x")
+	m, cmd := press(t, m, "y")
+	if m.mode != modeConfirmRootKill || cmd != nil {
+		t.Fatalf("first y as root: mode = %v, cmd = %v; want a second prompt", m.mode, cmd)
+	}
+	if !strings.Contains(m.View(), "Running as root: PID 100") {
+		t.Errorf("second prompt missing from view:\n%s", m.View())
+	}
+	if m, cmd = press(t, m, "n"); m.mode != modeNormal || cmd != nil || m.status != "kill cancelled" {
+		t.Fatalf("n at the second prompt should cancel, mode = %v", m.mode)
+	}
+
+	m, _ = press(t, m, "x")
+	m, _ = press(t, m, "y")
+	if m, cmd = press(t, m, "y"); m.mode != modeNormal || cmd == nil {
+		t.Fatalf("second y should send the kill, mode = %v", m.mode)
+	}
+
+	// Without the setting, root kills take one confirmation as before.
+	m = New(&scanner.MockScanner{Servers: testServers}, config.Default(), Options{AsRoot: true})
+	m = update(t, m, scanResultMsg{servers: testServers})
+	m, _ = press(t, m, "x")
+	if m, cmd = press(t, m, "y"); m.mode != modeNormal || cmd == nil {
+		t.Fatalf("without confirm_root_kills: mode = %v, want the kill sent", m.mode)
+	}
+}
+
+func TestKillWithoutPID(t *testing.T) {
+	m := newTestModel(t, []scanner.Server{{Port: 9000}})
+	m, _ = press(t, m, "x")
+	if m.mode != modeNormal {
+		t.Errorf("mode = %v, want modeNormal when PID is unknown", m.mode)
+	}
+}
+
+func TestLabelEditSavesConfig(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m, _ = press(t, m, "l")
+	if m.mode != modeLabel {
+		t.Fatalf("mode = %v, want modeLabel", m.mode)
+	}
+	m = typeText(t, m, "web app")
+	m, cmd := press(t, m, "enter")
+	if m.config.Labels[3000] != "web app" {
+		t.Errorf("Labels[3000] = %q, want %q", m.config.Labels[3000], "web app")
+	}
+	if m.filtered[0].Label != "web app" {
+		t.Errorf("row label = %q, want %q", m.filtered[0].Label, "web app")
+	}
+	if cmd == nil {
+		t.Fatal("saving a label should return a save command")
+	}
+	if msg := flushSave(t, m, cmd); msg.err != nil || msg.path != m.configPath {
+		t.Errorf("save = %#v, want a successful write to %s", msg, m.configPath)
+	}
+	saved, err := config.Load(m.configPath)
+	if err != nil || saved.Labels[3000] != "web app" {
+		t.Errorf("saved config labels = %v (err %v)", saved.Labels, err)
+	}
+}
+
+func TestLabelEditCancel(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m, _ = press(t, m, "l")
+	m = typeText(t, m, "nope")
+	m, cmd := press(t, m, "esc")
+	if cmd != nil || m.config.Labels[3000] != "" {
+		t.Errorf("esc should discard the label, got %q", m.config.Labels[3000])
+	}
+}
+
+func TestHideRemovesRowAndSaves(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m, cmd := press(t, m, "h")
+	if !m.config.IsHidden(3000) {
+		t.Fatal("3000 should be hidden")
+	}
+	if len(m.filtered) != 2 || m.filtered[0].Port == 3000 {
+		t.Errorf("filtered = %+v, want 3000 removed", m.filtered)
+	}
+	if cmd == nil {
+		t.Error("hiding should return a save command")
+	}
+}
+
+func TestHelpListsFavoriteKeys(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m, _ = press(t, m, "?")
+	for _, want := range []string{"mark/unmark favorite port", "show favorites only/all ports"} {
+		if !strings.Contains(m.View(), want) {
+			t.Errorf("help overlay missing %q:\n%s", want, m.View())
+		}
+	}
+}
+
+func TestHelpListsEveryBinding(t *testing.T) {
+	listed := make(map[string]bool)
+	for _, b := range keys.helpBindings() {
+		listed[b.Help().Key] = true
+	}
+	v := reflect.ValueOf(keys)
+	for i := range v.NumField() {
+		b := v.Field(i).Interface().(key.Binding)
+		if !listed[b.Help().Key] {
+			t.Errorf("keyMap.%s (%s) is missing from helpBindings", v.Type().Field(i).Name, b.Help().Key)
+		}
+	}
+}
+
+func TestHelpToggle(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m, _ = press(t, m, "?")
+	if m.mode != modeHelp || !strings.Contains(m.View(), "kill process") {
+		t.Fatalf("help overlay not shown:\n%s", m.View())
+	}
+	m, _ = press(t, m, "?")
+	if m.mode != modeNormal {
+		t.Errorf("mode = %v, want modeNormal after toggling help off", m.mode)
+	}
+}
+
+func TestNoticeShownUntilKeyPress(t *testing.T) {
+	m := New(&scanner.MockScanner{}, config.Default(), Options{Notice: "wrote default config to /tmp/c.yaml"})
+	m = update(t, m, scanResultMsg{servers: testServers})
+	if !strings.Contains(m.View(), "wrote default config to /tmp/c.yaml") {
+		t.Fatalf("notice missing from view:\n%s", m.View())
+	}
+	m, _ = press(t, m, "j")
+	if strings.Contains(m.View(), "wrote default config") {
+		t.Error("notice should clear after a key press")
+	}
+}
+
+func TestViewListsServers(t *testing.T) {
+	m := newTestModel(t, testServers)
+	view := m.View()
+	for _, want := range []string{"portview", "PORT", "3000", "postgres", "go run main.go", "3 servers"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+}
+
+func TestViewProtoColumnOnlyWithUDP(t *testing.T) {
+	m := newTestModel(t, testServers)
+	if view := m.View(); strings.Contains(view, "PROTO") {
+		t.Errorf("TCP-only list should have no PROTO column:\n%s", view)
+	}
+
+	servers := append(slices.Clone(testServers), scanner.Server{Port: 5353, Addr: "0.0.0.0", Protocol: scanner.ProtoUDP, PID: 400, Process: "avahi-daemon", Healthy: true})
+	m = update(t, m, scanResultMsg{servers: servers})
+	view := m.View()
+	for _, want := range []string{"PROTO PORT", "UDP   5353", "TCP   3000"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+}
+
+func TestViewCustomTitle(t *testing.T) {
+	cfg := config.Default()
+	cfg.Title = "portview — myproject (staging)"
+	m := New(&scanner.MockScanner{Servers: testServers}, cfg, Options{})
+	m = update(t, m, scanResultMsg{servers: testServers})
+	if first := strings.SplitN(m.View(), "\n", 2)[0]; !strings.Contains(first, cfg.Title) {
+		t.Errorf("title line = %q, want the configured %q", first, cfg.Title)
+	}
+
+	m = New(&scanner.MockScanner{Servers: testServers}, cfg, Options{Title: "ci box"})
+	m = update(t, m, scanResultMsg{servers: testServers})
+	if first := strings.SplitN(m.View(), "\n", 2)[0]; !strings.Contains(first, "ci box") || strings.Contains(first, "staging") {
+		t.Errorf("title line = %q, want --title to win over the config", first)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"node", 10, "node"},
+		{"postgres -D /data", 8, "postgre…"},
+		{"héllo wörld", 6, "héllo…"},
+		{"abc", 1, "a"},
+	}
+	for _, tt := range tests {
+		if got := truncate(tt.in, tt.max); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.in, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateLeft(t *testing.T) {
+	tests := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"/usr/bin/node", 20, "/usr/bin/node"},
+		{"/usr/local/bin/node", 10, "…/bin/node"},
+		{"/opt/wörk/bïn/app", 9, "…/bïn/app"},
+		{"abc", 1, "c"},
+	}
+	for _, tt := range tests {
+		if got := truncateLeft(tt.in, tt.max); got != tt.want {
+			t.Errorf("truncateLeft(%q, %d) = %q, want %q", tt.in, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestCommandLines(t *testing.T) {
+	command := "node ./node_modules/.bin/vite --port 5173 --host 0.0.0.0 --strictPort -c vite.config.dev.ts"
+	want := []string{
+		"node",
+		"  ./node_modules",
+		"  /.bin/vite",
+		"  --port 5173",
+		"  --host",
+		"    0.0.0.0",
+		"  --strictPort",
+		"  -c",
+		"    vite.config.",
+		"    dev.ts",
+	}
+	if got := commandLines(command, 16); !slices.Equal(got, want) {
+		t.Errorf("commandLines() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if got := argSplit(command); !slices.Equal(got, []string{"node ./node_modules/.bin/vite", "--port 5173", "--host 0.0.0.0", "--strictPort", "-c vite.config.dev.ts"}) {
+		t.Errorf("argSplit() = %q", got)
+	}
+	if got := wrap("héllo wörld", 7); !slices.Equal(got, []string{"héllo", "wörld"}) {
+		t.Errorf("wrap() = %q, want a break at the space", got)
+	}
+	if got := commandLines("", 12); !slices.Equal(got, []string{""}) {
+		t.Errorf("commandLines(\"\") = %q, want one empty line", got)
+	}
+}
+
+func TestSharedPortShowsCountAndKillsAll(t *testing.T) {
+	shared := []scanner.Server{
+		{Port: 8080, PID: 71, PIDs: []int{71, 72, 73}, Process: "worker", Command: "worker --reuseport"},
+	}
+	m := newTestModel(t, shared)
+	if view := m.View(); !strings.Contains(view, "8080 (x3)") {
+		t.Errorf("view missing shared-port count:\n%s", view)
+	}
+
+	m, _ = press(t, m, "i")
+	if m.mode != modeDetail || !strings.Contains(m.View(), "71, 72, 73") {
+		t.Errorf("detail overlay should list every PID:\n%s", m.View())
+	}
+	m, _ = press(t, m, "esc")
+
+	m, _ = press(t, m, "x")
+	if !strings.Contains(m.View(), "Kill PIDs 71, 72, 73") {
+		t.Errorf("confirm prompt should name every PID:\n%s", m.View())
+	}
+}
+
+func TestIdleQuitAfterInactivity(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m.config.IdleQuit = time.Minute
+
+	_, cmd := m.Update(tickMsg(m.lastKey.Add(30 * time.Second)))
+	if isQuit(cmd) {
+		t.Fatal("tick before the idle threshold should not quit")
+	}
+
+	_, cmd = m.Update(tickMsg(m.lastKey.Add(2 * time.Minute)))
+	if !isQuit(cmd) {
+		t.Error("tick past the idle threshold should quit")
+	}
+}
+
+func TestIdleQuitDisabledByDefault(t *testing.T) {
+	m := newTestModel(t, testServers)
+	_, cmd := m.Update(tickMsg(m.lastKey.Add(24 * time.Hour)))
+	if isQuit(cmd) {
+		t.Error("idle quit should be off when IdleQuit is zero")
+	}
+}
+
+func TestZeroIntervalDisablesAutoRefresh(t *testing.T) {
+	cfg := config.Default()
+	cfg.RefreshInterval = 0
+	m := New(&scanner.MockScanner{Servers: testServers}, cfg, Options{})
+	if _, ok := m.Init()().(scanResultMsg); !ok {
+		t.Fatal("Init with interval 0 should return only the initial scan, no tick")
+	}
+
+	m = update(t, m, scanResultMsg{servers: testServers})
+	if _, cmd := m.Update(tickMsg(m.now())); cmd != nil {
+		t.Error("a stray tick should neither scan nor re-arm with auto-refresh off")
+	}
+	if _, cmd := press(t, m, "r"); cmd == nil {
+		t.Error("r should still scan with auto-refresh off")
+	}
+
+	// idle_quit still needs ticks to notice inactivity.
+	m.config.IdleQuit = time.Minute
+	if got := m.tickInterval(); got != time.Minute {
+		t.Errorf("tickInterval() = %s with idle_quit set, want 1m", got)
+	}
+}
+
+func isQuit(cmd tea.Cmd) bool {
+	if cmd == nil {
+		return false
+	}
+	_, ok := cmd().(tea.QuitMsg)
+	return ok
+}
+
+func TestDetailShowsExePath(t *testing.T) {
+	m := newTestModel(t, []scanner.Server{{Port: 3000, PID: 1, Process: "node", Command: "node ./node_modules/.bin/vite --port 3000 --config ./config/vite.config.development.ts", ExePath: "/home/dev/.nvm/versions/node/v20.11.0/bin/node"}})
+	m, _ = press(t, m, "i")
+	if !strings.Contains(m.View(), "/home/dev/.nvm/versions/node/v20.11.0/bin/node") {
+		t.Errorf("detail overlay missing exe path:\n%s", m.View())
+	}
+
+	m = update(t, m, tea.WindowSizeMsg{Width: 40, Height: 20})
+	view := m.View()
+	if !strings.Contains(view, "…s/node/v20.11.0/bin/node") {
+		t.Errorf("narrow detail overlay should keep the end of the exe path:\n%s", view)
+	}
+	if !strings.Contains(view, "  --port 3000") {
+		t.Errorf("narrow detail overlay should put each flag on its own line:\n%s", view)
+	}
+	for _, line := range strings.Split(view, "\n") {
+		if lipgloss.Width(line) > 40 {
+			t.Errorf("line wider than the window: %q", line)
+		}
+	}
+}
+
+func TestDetailShowsBacklog(t *testing.T) {
+	m := newTestModel(t, []scanner.Server{{Port: 3000, PID: 1, Process: "node", Backlog: 97, MaxBacklog: 128}})
+	m, _ = press(t, m, "i")
+	if !strings.Contains(m.View(), "Backlog  97/128 waiting") {
+		t.Errorf("detail overlay missing backlog:\n%s", m.View())
+	}
+}
+
+func TestHiddenModes(t *testing.T) {
+	lipgloss.SetColorProfile(termenv.TrueColor)
+	defer lipgloss.SetColorProfile(termenv.Ascii)
+
+	cfg := config.Default()
+	cfg.Hidden = []int{3000}
+	m := New(&scanner.MockScanner{}, cfg, Options{})
+	m = update(t, m, scanResultMsg{servers: testServers})
+	if strings.Contains(m.View(), "node server.js") {
+		t.Errorf("hidden_mode remove should leave 3000 out:\n%s", m.View())
+	}
+
+	cfg.HiddenMode = "dim"
+	m = New(&scanner.MockScanner{}, cfg, Options{})
+	m = update(t, m, scanResultMsg{servers: testServers})
+	var ports []int
+	for _, s := range m.filtered {
+		ports = append(ports, s.Port)
+	}
+	if !slices.Equal(ports, []int{5432, 8080, 3000}) {
+		t.Fatalf("dim order = %v, want the hidden 3000 last", ports)
+	}
+	view := m.View()
+	if i, j := strings.Index(view, "go run main.go"), strings.Index(view, "node server.js"); i < 0 || j < i {
+		t.Errorf("dimmed 3000 should render below 8080:\n%s", view)
+	}
+	hidden := m.filtered[2]
+	down := hidden
+	down.Healthy = false
+	if got, want := m.renderRow(hidden, false), m.renderRow(down, false); got != want {
+		t.Errorf("a healthy hidden row should render like an unhealthy one:\n%q\n%q", got, want)
+	}
+	if !strings.Contains(m.renderRow(hidden, false), "(hidden)") {
+		t.Error("a dimmed row should be marked hidden")
+	}
+}
+
+func TestShowHiddenKeepsCursorOnServer(t *testing.T) {
+	cfg := config.Default()
+	cfg.Hidden = []int{3000, 5432}
+	m := New(&scanner.MockScanner{}, cfg, Options{})
+	m = update(t, m, scanResultMsg{servers: testServers})
+	if len(m.filtered) != 1 {
+		t.Fatalf("len(filtered) = %d, want 1 with 3000 and 5432 hidden", len(m.filtered))
+	}
+	if s, _ := m.selected(); s.Port != 8080 {
+		t.Fatalf("cursor on %d, want 8080", s.Port)
+	}
+
+	m, _ = press(t, m, "H")
+	if len(m.filtered) != 3 {
+		t.Fatalf("len(filtered) = %d, want 3 with hidden ports shown", len(m.filtered))
+	}
+	if s, _ := m.selected(); s.Port != 8080 {
+		t.Errorf("cursor moved to %d after showing hidden ports, want 8080", s.Port)
+	}
+	if !strings.Contains(m.View(), "(hidden)") {
+		t.Errorf("hidden rows should be marked:\n%s", m.View())
+	}
+
+	m, _ = press(t, m, "H")
+	if s, _ := m.selected(); s.Port != 8080 || len(m.filtered) != 1 {
+		t.Errorf("cursor on %d with %d rows after hiding again, want 8080 alone", s.Port, len(m.filtered))
+	}
+}
+
+func TestScanKeepsCursorOnSamePort(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m, _ = press(t, m, "j")
+	m = update(t, m, scanResultMsg{servers: append([]scanner.Server{{Port: 1500, PID: 9}}, testServers...)})
+	if s, _ := m.selected(); s.Port != 5432 {
+		t.Errorf("cursor on %d after a new row appeared above, want 5432", s.Port)
+	}
+}
+
+func TestEditConfigReloadsAfterEditorExits(t *testing.T) {
+	dir := t.TempDir()
+	editor := filepath.Join(dir, "fake-editor")
+	script := "#!/bin/sh\nprintf 'labels:\\n  3000: edited\\n' > \"$1\"\n"
+	if err := os.WriteFile(editor, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("EDITOR", editor)
+
+	m := newTestModel(t, testServers)
+	m, _ = press(t, m, "?")
+	m, cmd := press(t, m, "e")
+	if m.mode != modeNormal || cmd == nil {
+		t.Fatalf("e in help should leave help and return the editor command")
+	}
+
+	// tea.ExecProcess needs a running program, so run the same command
+	// directly and hand its result to the completion callback.
+	c := editorCommand(m.configPath)
+	if c.Args[len(c.Args)-1] != m.configPath {
+		t.Fatalf("editor args = %v, want config path last", c.Args)
+	}
+	m, reload := mustUpdate(t, m, editorClosed(c.Run()))
+	if reload == nil {
+		t.Fatal("closing the editor should reload the config")
+	}
+	m, _ = mustUpdate(t, m, reload())
+	if m.config.Labels[3000] != "edited" || m.filtered[0].Label != "edited" {
+		t.Errorf("config not reloaded: labels = %v", m.config.Labels)
+	}
+}
+
+func TestEditorCommandSplitsArgs(t *testing.T) {
+	t.Setenv("EDITOR", "code -w")
+	c := editorCommand("/tmp/c.yaml")
+	if want := []string{"code", "-w", "/tmp/c.yaml"}; strings.Join(c.Args, " ") != strings.Join(want, " ") {
+		t.Errorf("editorCommand args = %v, want %v", c.Args, want)
+	}
+	t.Setenv("EDITOR", "")
+	if c := editorCommand("/tmp/c.yaml"); c.Args[0] != "vi" {
+		t.Errorf("editorCommand without $EDITOR = %v, want vi", c.Args)
+	}
+}
+
+func mustUpdate(t *testing.T, m Model, msg tea.Msg) (Model, tea.Cmd) {
+	t.Helper()
+	next, cmd := m.Update(msg)
+	return next.(Model), cmd
+}
+
+func TestHeaderSummaryCounts(t *testing.T) {
+	m := newTestModel(t, []scanner.Server{
+		{Port: 3000, Addr: "127.0.0.1", Healthy: true},
+		{Port: 5432, Addr: "0.0.0.0", Healthy: true},
+		{Port: 8080, Addr: "*", Healthy: false},
+		{Port: 9000, Addr: "[::1]", Healthy: false},
+	})
+	if view := m.View(); !strings.Contains(view, "4 listening • 2 unhealthy • 2 exposed • scanned ") {
+		t.Errorf("view missing header summary:\n%s", view)
+	}
+
+	m = update(t, m, scanResultMsg{servers: []scanner.Server{{Port: 3000, Addr: "0.0.0.0", Healthy: false}}})
+	if got := m.headerSummary(); !strings.HasPrefix(got, "1 listening • 1 unhealthy • 1 exposed") {
+		t.Errorf("headerSummary() after rescan = %q", got)
+	}
+}
+
+func TestStalePIDMarked(t *testing.T) {
+	m := newTestModel(t, []scanner.Server{{Port: 3000, PID: 100, Process: "node", PIDStale: true}})
+	if row := m.renderRow(m.filtered[0], false); !strings.Contains(row, "node~") {
+		t.Errorf("row = %q, want the stale PID marked with ~", row)
+	}
+	m, _ = press(t, m, "i")
+	if view := m.View(); !strings.Contains(view, "100 ~ (from an earlier scan") {
+		t.Errorf("detail view should say the PID may be stale:\n%s", view)
+	}
+}
+
+func TestScanTimestamps(t *testing.T) {
+	clock := time.Date(2024, 5, 1, 12, 3, 4, 0, time.UTC)
+	m := newTestModel(t, testServers)
+	m.now = fixedClock(&clock)
+
+	scan := m.startScan()
+	clock = clock.Add(2 * time.Second)
+	if got := m.headerSummary(); !strings.HasSuffix(got, "scanning… (started 2s ago)") {
+		t.Errorf("headerSummary() in flight = %q", got)
+	}
+
+	msg, ok := scan().(scanResultMsg)
+	if !ok {
+		t.Fatal("scan command did not return a scanResultMsg")
+	}
+	if want := clock.Add(-2 * time.Second); !msg.startedAt.Equal(want) || !msg.completedAt.Equal(clock) {
+		t.Errorf("scanResultMsg stamped %v–%v, want %v–%v", msg.startedAt, msg.completedAt, want, clock)
+	}
+	m = update(t, m, msg)
+	if got := m.headerSummary(); !strings.HasSuffix(got, "scanned at 12:03:06 (2s)") {
+		t.Errorf("headerSummary() after scan = %q", got)
+	}
+}
+
+func TestTimeFormats(t *testing.T) {
+	refreshed := time.Date(2024, 5, 1, 12, 3, 4, 0, time.UTC)
+	now := refreshed.Add(3*time.Second + 400*time.Millisecond)
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"", "3 servers · refreshed 3s ago"},
+		{"relative", "3 servers · refreshed 3s ago"},
+		{"clock", "3 servers · refreshed at 12:03:04"},
+		{"both", "3 servers · refreshed 12:03:04, 3s ago"},
+	}
+	for _, tc := range tests {
+		m := newTestModel(t, testServers)
+		m.config.TimeFormat = tc.format
+		m.lastRefresh = refreshed
+		m.now = fixedClock(&now)
+		if got := m.summary(); got != tc.want {
+			t.Errorf("time_format %q: summary() = %q, want %q", tc.format, got, tc.want)
+		}
+	}
+}
+
+func TestSnapshotIsReadOnly(t *testing.T) {
+	m := New(&scanner.MockScanner{Servers: testServers}, config.Default(), Options{ConfigPath: filepath.Join(t.TempDir(), "config.yaml"), Snapshot: "before"})
+	m = update(t, m, doScan(m.scanner, time.Now)())
+	for _, k := range []string{"x", "l", "h"} {
+		next, cmd := press(t, m, k)
+		if next.mode != modeNormal || cmd != nil || next.status != "read-only snapshot" {
+			t.Errorf("%s on a snapshot: mode = %v, status = %q, want it refused", k, next.mode, next.status)
+		}
+	}
+	if !strings.Contains(m.View(), "snapshot before") {
+		t.Error("title should name the snapshot")
+	}
+}
+
+func TestSaveSnapshotKey(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m, cmd := press(t, m, "S")
+	if cmd == nil {
+		t.Fatal("S should return a save command")
+	}
+	m = update(t, m, cmd())
+	if !strings.HasPrefix(m.status, "saved snapshot ") {
+		t.Fatalf("status = %q, want saved snapshot", m.status)
+	}
+	saved, err := scanner.LoadSnapshot(strings.TrimPrefix(m.status, "saved snapshot "))
+	if err != nil || len(saved) != len(testServers) {
+		t.Errorf("LoadSnapshot() = %d servers, %v; want %d", len(saved), err, len(testServers))
+	}
+}
+
+func TestStatusBarFitsWidth(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m.status = "sent SIGTERM to PIDs 100, 200, 300, 400, 500, 600, 700"
+	for _, width := range []int{60, 120} {
+		m = update(t, m, tea.WindowSizeMsg{Width: width, Height: 30})
+		lines := strings.Split(m.statusBar(), "\n")
+		for _, line := range lines {
+			if w := lipgloss.Width(line); w > width {
+				t.Errorf("width %d: status bar line is %d cells: %q", width, w, line)
+			}
+		}
+		if hint := lines[len(lines)-1]; !strings.HasSuffix(hint, "?:help") {
+			t.Errorf("width %d: hint line %q should end with ?:help", width, hint)
+		}
+	}
+	if got, want := hintLine(120), "j/k:nav  o:open  x:kill  l:label  /:filter  q:quit  ?:help"; got != want {
+		t.Errorf("hintLine(120) = %q, want the full set %q", got, want)
+	}
+	if got, want := hintLine(31), "j/k:nav  o:open  x:kill  ?:help"; got != want {
+		t.Errorf("hintLine(31) = %q, want %q", got, want)
+	}
+}
+
+func TestOpenRefusedInNoOpenRange(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m.config.NoOpenRanges = []string{"5000-6000"}
+	m, _ = press(t, m, "j")
+	m, cmd := press(t, m, "o")
+	if cmd != nil {
+		t.Error("o on :5432 should not issue an open command")
+	}
+	if m.status != ":5432 is not a browser-openable port" {
+		t.Errorf("status = %q", m.status)
+	}
+
+	m, _ = press(t, m, "k")
+	if _, cmd := press(t, m, "o"); cmd == nil {
+		t.Error("o on :3000 should still open")
+	}
+}
+
+func TestOpenAllHealthyPorts(t *testing.T) {
+	servers := []scanner.Server{
+		{Port: 3000, PID: 100, Process: "node", Healthy: true},
+		{Port: 5432, PID: 200, Process: "postgres", Healthy: true},
+		{Port: 5353, PID: 300, Process: "avahi-daemon", Protocol: scanner.ProtoUDP, Healthy: true},
+		{Port: 8080, PID: 400, Process: "api", Healthy: true},
+		{Port: 9000, PID: 500, Process: "php", Healthy: false},
+		{SocketPath: "/run/app.sock", PID: 600, Process: "app", Healthy: true},
+	}
+	m := newTestModel(t, servers)
+	m.config.NoOpenRanges = []string{"5000-6000"}
+	if got := m.openablePorts(); !slices.Equal(got, []int{3000, 8080}) {
+		t.Fatalf("openablePorts() = %v, want only the healthy TCP ports outside no_open_ranges", got)
+	}
+
+	m, cmd := press(t, m, "ctrl+o")
+	if m.status != "opening 2 ports…" || cmd == nil {
+		t.Fatalf("ctrl+o: status = %q, cmd = %v; want two opens", m.status, cmd)
+	}
+	// The batch is unpacked but its commands are not run, so no browser
+	// starts.
+	if batch, ok := cmd().(tea.BatchMsg); !ok || len(batch) != 2 {
+		t.Errorf("ctrl+o dispatched %#v, want a batch of 2 opens", batch)
+	}
+
+	m.config.DisabledActions = []string{"open"}
+	if m, cmd := press(t, m, "ctrl+o"); cmd != nil || m.status != "open is disabled in the config" {
+		t.Errorf("ctrl+o with open disabled: status = %q, cmd = %v", m.status, cmd)
+	}
+	m.config.DisabledActions = nil
+	m.filterText = "php"
+	m.applyFilter()
+	if m, cmd := press(t, m, "ctrl+o"); cmd != nil || m.status != "no healthy ports to open" {
+		t.Errorf("ctrl+o with nothing healthy listed: status = %q, cmd = %v", m.status, cmd)
+	}
+}
+
+func TestRecheckFillsMissingPID(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m = update(t, m, scanResultMsg{servers: []scanner.Server{{Port: 3000, State: "LISTEN", Healthy: true}}})
+	if m.filtered[0].PID != 0 {
+		t.Fatal("setup: want a row with no PID")
+	}
+	m, cmd := press(t, m, "R")
+	if cmd == nil {
+		t.Fatal("R should start a targeted lookup")
+	}
+	m = update(t, m, cmd())
+	if s := m.filtered[0]; s.PID != 100 || s.Process != "node" || s.Command != "node server.js" || !s.Healthy {
+		t.Errorf("after recheck row = %+v, want PID 100 node with health kept", s)
+	}
+
+	m = update(t, m, doResolvePort(m.scanner, portKey{"TCP", 4000})())
+	if !strings.Contains(m.status, "owning process not found") {
+		t.Errorf("status = %q, want the unresolved error", m.status)
+	}
+}
+
+func TestScanIsLogged(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&buf, nil))
+	m := New(&scanner.MockScanner{Servers: testServers}, config.Default(), Options{Logger: log})
+	m = update(t, m, doScan(m.scanner, time.Now)())
+	if got := buf.String(); !strings.Contains(got, "msg=scan servers=3 duration=") {
+		t.Errorf("log = %q, want a scan entry with the server count", got)
+	}
+
+	buf.Reset()
+	update(t, m, scanResultMsg{err: errors.New("lsof: not found")})
+	if got := buf.String(); !strings.Contains(got, `level=ERROR msg="scan failed"`) || !strings.Contains(got, "lsof: not found") {
+		t.Errorf("log = %q, want the scan error", got)
+	}
+}
+
+func TestNilLoggerDiscards(t *testing.T) {
+	m := New(&scanner.MockScanner{Servers: testServers}, config.Default(), Options{})
+	update(t, m, doScan(m.scanner, time.Now)())
+}
+
+// sequenceScanner returns its results in order, repeating the last one. It
+// has no ResolvePort, so owner re-checks fall back to a full scan.
+type sequenceScanner struct {
+	results [][]scanner.Server
+	calls   int
+}
+
+func (s *sequenceScanner) Scan(context.Context) ([]scanner.Server, error) {
+	i := min(s.calls, len(s.results)-1)
+	s.calls++
+	return s.results[i], nil
+}
+
+// PIDs above the kernel's pid_max limit, so a kill that slips through
+// cannot hit a real process.
+const (
+	fakePIDOld = 9_000_001
+	fakePIDNew = 9_000_002
+)
+
+func TestKillSkippedWhenPIDChangesMidConfirm(t *testing.T) {
+	seq := &sequenceScanner{results: [][]scanner.Server{
+		{{Port: 3000, PID: fakePIDOld, Process: "node"}},
+		{{Port: 3000, PID: fakePIDNew, Process: "node"}},
+	}}
+	m := New(seq, config.Default(), Options{})
+	m = update(t, m, doScan(seq, time.Now)())
+	m, _ = press(t, m, "x")
+	if view := m.View(); !strings.Contains(view, fmt.Sprintf("Kill PID %d", fakePIDOld)) {
+		t.Fatalf("prompt should name the PID being confirmed:\n%s", view)
+	}
+
+	// The service restarts while the prompt is up.
+	_, cmd := press(t, m, "y")
+	msg := cmd()
+	stale, ok := msg.(killStaleMsg)
+	if !ok {
+		t.Fatalf("kill after a PID change returned %T, want killStaleMsg", msg)
+	}
+	if !slices.Equal(stale.current, []int{fakePIDNew}) || !slices.Equal(stale.confirmed, []int{fakePIDOld}) {
+		t.Errorf("killStaleMsg = %+v", stale)
+	}
+	m = update(t, m, stale)
+	if want := fmt.Sprintf(":3000 is now PID %d, not PID %d; nothing killed", fakePIDNew, fakePIDOld); m.status != want {
+		t.Errorf("status = %q, want %q", m.status, want)
+	}
+}
+
+func TestKillProceedsWhenPIDUnchanged(t *testing.T) {
+	mock := &scanner.MockScanner{Servers: []scanner.Server{{Port: 3000, PID: fakePIDOld, Process: "node"}}}
+	m := New(mock, config.Default(), Options{})
+	m = update(t, m, doScan(mock, time.Now)())
+	m, _ = press(t, m, "x")
+	m, cmd := press(t, m, "y")
+	msg, ok := cmd().(killResultMsg)
+	if !ok || !slices.Equal(msg.pids, []int{fakePIDOld}) {
+		t.Fatalf("kill with unchanged PID = %+v, want a kill of PID %d", msg, fakePIDOld)
+	}
+
+	mock.Servers = nil
+	m, _ = press(t, m, "x")
+	_, cmd = press(t, m, "y")
+	stale, ok := cmd().(killStaleMsg)
+	if !ok || stale.current != nil {
+		t.Errorf("kill after the port closed = %+v, want killStaleMsg with no owners", stale)
+	}
+}
+
+func TestKillUDPRowChecksUDPOwner(t *testing.T) {
+	servers := []scanner.Server{
+		{Port: 5353, PID: fakePIDOld, Process: "web"},
+		{Port: 5353, PID: fakePIDNew, Process: "mdns", Protocol: scanner.ProtoUDP},
+	}
+	mock := &scanner.MockScanner{Servers: servers}
+	m := New(mock, config.Default(), Options{})
+	m = update(t, m, doScan(mock, time.Now)())
+	m, _ = press(t, m, "j")
+	if s, _ := m.selected(); s.Proto() != scanner.ProtoUDP {
+		t.Fatalf("setup: cursor on %+v, want the UDP row", s)
+	}
+	m, _ = press(t, m, "x")
+	_, cmd := press(t, m, "y")
+	msg, ok := cmd().(killResultMsg)
+	if !ok || !slices.Equal(msg.pids, []int{fakePIDNew}) {
+		t.Errorf("kill of the UDP row = %+v, want a kill of PID %d, not the TCP owner", msg, fakePIDNew)
+	}
+}
+
+func TestResolveMergesIntoMatchingProtocol(t *testing.T) {
+	servers := []scanner.Server{
+		{Port: 5353, PID: fakePIDOld, Process: "web"},
+		{Port: 5353, Protocol: scanner.ProtoUDP},
+	}
+	m := newTestModel(t, servers)
+	m = update(t, m, resolvedPortMsg{
+		key:    portKey{scanner.ProtoUDP, 5353},
+		server: scanner.Server{Port: 5353, Protocol: scanner.ProtoUDP, PID: fakePIDNew, Process: "mdns"},
+	})
+	for _, s := range m.scanned {
+		want := fakePIDOld
+		if s.Proto() == scanner.ProtoUDP {
+			want = fakePIDNew
+		}
+		if s.PID != want {
+			t.Errorf("%s row PID = %d, want %d", s.Proto(), s.PID, want)
+		}
+	}
+	if m.status != fmt.Sprintf(":5353/udp is PID %d", fakePIDNew) {
+		t.Errorf("status = %q", m.status)
+	}
+}
+
+func TestQuitDuringPendingKillConfirms(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m, _ = press(t, m, "x")
+	m, _ = press(t, m, "y") // the kill command is never run
+	m, cmd := press(t, m, "q")
+	if m.mode != modeConfirmQuit || cmd != nil {
+		t.Fatalf("q with a kill pending: mode = %v, cmd = %v; want modeConfirmQuit and no quit", m.mode, cmd)
+	}
+	if !strings.Contains(m.View(), "A kill is in progress, quit anyway? (y/n)") {
+		t.Errorf("quit prompt missing from view:\n%s", m.View())
+	}
+	m, cmd = press(t, m, "n")
+	if m.mode != modeNormal || cmd != nil {
+		t.Fatalf("n should stay: mode = %v, cmd = %v", m.mode, cmd)
+	}
+	m, _ = press(t, m, "q")
+	if m, cmd = press(t, m, "y"); cmd == nil {
+		t.Fatal("y should quit")
+	}
+
+	// Once the kill reports back, q quits straight away.
+	m = update(t, m, killStaleMsg{port: 3000, confirmed: []int{fakePIDOld}})
+	if m.killing != 0 {
+		t.Errorf("killing = %d after the result, want 0", m.killing)
+	}
+	if m, cmd = press(t, m, "q"); cmd == nil || m.mode != modeNormal {
+		t.Errorf("q after the kill finished: mode = %v, cmd = %v; want a quit", m.mode, cmd)
+	}
+}
+
+func TestHighContrastMarkers(t *testing.T) {
+	m := newTestModel(t, testServers)
+	if view := m.View(); strings.Contains(view, "[OK]") {
+		t.Errorf("markers should only appear in high-contrast mode:\n%s", view)
+	}
+
+	m = New(&scanner.MockScanner{Servers: testServers}, config.Default(), Options{HighContrast: true})
+	m = update(t, m, scanResultMsg{servers: testServers})
+	view := m.View()
+	for _, want := range []string{"[OK]", "[DOWN]"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("high-contrast view missing %q:\n%s", want, view)
+		}
+	}
+	if got := strings.Count(view, "[OK]"); got != 2 {
+		t.Errorf("view has %d [OK] markers, want 2 (3000 and 5432)", got)
+	}
+	if m.config.HighContrast {
+		t.Error("the flag must not leak into the saved config")
+	}
+
+	cfg := config.Default()
+	cfg.HighContrast = true
+	m = New(&scanner.MockScanner{}, cfg, Options{})
+	m = update(t, m, scanResultMsg{servers: testServers})
+	if !strings.Contains(m.View(), "[DOWN]") {
+		t.Error("high_contrast in the config should enable the markers")
+	}
+}
+
+func TestCursorStyles(t *testing.T) {
+	lipgloss.SetColorProfile(termenv.TrueColor)
+	defer lipgloss.SetColorProfile(termenv.Ascii)
+
+	cfg := config.Default()
+	cfg.CursorStyle = "block"
+	m := New(&scanner.MockScanner{}, cfg, Options{})
+	m = update(t, m, tea.WindowSizeMsg{Width: 120, Height: 20})
+	m = update(t, m, scanResultMsg{servers: testServers})
+	var row string
+	for _, line := range strings.Split(m.View(), "\n") {
+		if strings.Contains(line, "node server.js") {
+			row = line
+		}
+	}
+	// One reverse-video span from the gutter to the window edge, with no
+	// ">" marker.
+	params, _, _ := strings.Cut(strings.TrimPrefix(row, "\x1b["), "m")
+	if !strings.HasPrefix(row, "\x1b[") || !slices.Contains(strings.Split(params, ";"), "7") {
+		t.Errorf("block cursor row should start in reverse video: %q", row)
+	}
+	plain := ansi.Strip(row)
+	if strings.HasPrefix(plain, ">") || lipgloss.Width(plain) != 120 || strings.Count(row, "\x1b[0m") != 1 {
+		t.Errorf("block cursor should highlight the whole row without a marker: %q", row)
+	}
+
+	m.config.CursorStyle = "▶"
+	view := ansi.Strip(m.View())
+	if !strings.Contains(view, "▶ 3000") || !strings.Contains(view, "  5432") {
+		t.Errorf("custom cursor marker missing:\n%s", view)
+	}
+}
+
+func TestViewAsciiProfileHasNoEscapes(t *testing.T) {
+	// NO_COLOR selects the Ascii profile at startup; every style in the view
+	// must then degrade to plain text.
+	lipgloss.SetColorProfile(termenv.TrueColor)
+	defer lipgloss.SetColorProfile(termenv.Ascii)
+	m := newTestModel(t, testServers)
+	if !strings.Contains(m.View(), "\x1b[") {
+		t.Fatal("expected escape codes with a colour profile; the check below would prove nothing")
+	}
+
+	lipgloss.SetColorProfile(termenv.Ascii)
+	for _, mode := range []mode{modeNormal, modeDetail, modeHelp} {
+		m.mode = mode
+		if view := m.View(); strings.Contains(view, "\x1b[") {
+			t.Errorf("mode %v: view has escape codes under the Ascii profile:\n%q", mode, view)
+		}
+	}
+}
+
+func TestShowCommandToggle(t *testing.T) {
+	m := newTestModel(t, []scanner.Server{{Port: 3000, PID: 100, Process: "node", Command: "/usr/bin/node server.js"}})
+	m, cmd := press(t, m, "v")
+	if !m.config.ShowCommand {
+		t.Fatal("v should put the command in the process column")
+	}
+	assertSaved(t, m, cmd)
+	row := m.renderRow(m.filtered[0], false)
+	if !strings.Contains(row, "3000 ") || !strings.Contains(row, "/usr/bin/node server.js") || strings.Contains(row, " node ") {
+		t.Errorf("row after v = %q, want the command in place of the process name", row)
+	}
+	if view := m.View(); strings.Contains(view, "PROCESS") {
+		t.Errorf("header should drop PROCESS while commands are shown:\n%s", view)
+	}
+
+	m, _ = press(t, m, "v")
+	if row := m.renderRow(m.filtered[0], false); !strings.Contains(row, " node ") {
+		t.Errorf("row after a second v = %q, want the process name back", row)
+	}
+}
+
+func TestResizeCommandColumn(t *testing.T) {
+	long := "node " + strings.Repeat("x", 40) + " --port 3000"
+	m := newTestModel(t, []scanner.Server{{Port: 3000, PID: 100, Process: "node", Command: long}})
+	if strings.Contains(m.View(), "--port") {
+		t.Fatal("the default command column should cut the tail of a long command")
+	}
+
+	for range 7 {
+		m, _ = press(t, m, "]")
+	}
+	if got, want := m.commandCol(), colCommand+7*commandColStep; got != want {
+		t.Fatalf("commandCol() after seven ] = %d, want %d", got, want)
+	}
+	if view := m.View(); !strings.Contains(view, "--port") {
+		t.Errorf("widened command column should show more of the command:\n%s", view)
+	}
+
+	for range 50 {
+		m, _ = press(t, m, "[")
+	}
+	if got := m.commandCol(); got != minCommandCol {
+		t.Errorf("commandCol() after many [ = %d, want it clamped to %d", got, minCommandCol)
+	}
+	for range 50 {
+		m, _ = press(t, m, "]")
+	}
+	if got := m.commandCol(); got != maxCommandCol {
+		t.Errorf("commandCol() after many ] = %d, want it clamped to %d", got, maxCommandCol)
+	}
+}
+
+func TestBlurSlowsNextTick(t *testing.T) {
+	cfg := config.Default()
+	cfg.BackgroundInterval = 30 * time.Second
+	m := New(&scanner.MockScanner{Servers: testServers}, cfg, Options{})
+	m = update(t, m, scanResultMsg{servers: testServers})
+	if got := m.tickInterval(); got != cfg.RefreshInterval {
+		t.Fatalf("focused interval = %s, want %s", got, cfg.RefreshInterval)
+	}
+
+	m = update(t, m, tea.BlurMsg{})
+	if got := m.tickInterval(); got != 30*time.Second {
+		t.Errorf("blurred interval = %s, want 30s", got)
+	}
+
+	next, cmd := m.Update(tea.FocusMsg{})
+	m = next.(Model)
+	if got := m.tickInterval(); got != cfg.RefreshInterval {
+		t.Errorf("interval after focus = %s, want %s", got, cfg.RefreshInterval)
+	}
+	if cmd == nil {
+		t.Error("regaining focus should rescan straight away")
+	}
+}
+
+func TestNoScanStackedOnRunningScan(t *testing.T) {
+	m := New(&scanner.MockScanner{Servers: testServers}, config.Default(), Options{})
+	if !m.scanning {
+		t.Fatal("the first scan should be in flight until its result arrives")
+	}
+	m = update(t, m, tea.BlurMsg{})
+	if _, cmd := mustUpdate(t, m, tea.FocusMsg{}); cmd != nil {
+		t.Error("regaining focus mid-scan should not start another")
+	}
+	m, cmd := press(t, m, "r")
+	if cmd != nil || m.status != "already scanning…" {
+		t.Errorf("r mid-scan: cmd = %v, status = %q; want no new scan", cmd, m.status)
+	}
+
+	m = update(t, m, scanResultMsg{servers: testServers})
+	if _, cmd := press(t, m, "r"); cmd == nil {
+		t.Error("r once the scan is done should rescan")
+	}
+}
+
+func TestBlurWithoutBackgroundInterval(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m = update(t, m, tea.BlurMsg{})
+	if got := m.tickInterval(); got != m.config.RefreshInterval {
+		t.Errorf("blurred interval with no background_interval = %s, want %s", got, m.config.RefreshInterval)
+	}
+}
+
+func TestDegradedScanShowsBanner(t *testing.T) {
+	unresolved := []scanner.Server{{Port: 3000}, {Port: 8080}}
+	m := newTestModel(t, nil)
+	m = update(t, m, scanResultMsg{servers: unresolved, err: scanner.ErrDegraded})
+	if m.err != nil {
+		t.Fatalf("a degraded scan is not a failure, err = %v", m.err)
+	}
+	if len(m.filtered) != 2 {
+		t.Errorf("rows = %d, want the degraded scan's 2 ports", len(m.filtered))
+	}
+	if view := m.View(); !strings.Contains(view, "process info unavailable") {
+		t.Errorf("degraded banner missing from view:\n%s", view)
+	}
+
+	m = update(t, m, scanResultMsg{servers: testServers})
+	if strings.Contains(m.View(), "process info unavailable") {
+		t.Error("banner should clear after a full scan")
+	}
+}
+
+func TestTickDuringScanDoesNotStackScans(t *testing.T) {
+	cfg := config.Default()
+	cfg.RefreshInterval = time.Millisecond
+	m := New(&scanner.MockScanner{Servers: testServers}, cfg, Options{})
+	if !m.scanning {
+		t.Fatal("New should count Init's scan as in flight")
+	}
+
+	next, cmd := m.Update(tickMsg(time.Now()))
+	m = next.(Model)
+	if _, ok := cmd().(tickMsg); !ok {
+		t.Fatal("a tick during a scan should only re-arm the tick")
+	}
+
+	m = update(t, m, scanResultMsg{servers: testServers})
+	if m.scanning {
+		t.Fatal("a scan result should clear the in-flight flag")
+	}
+	next, cmd = m.Update(tickMsg(time.Now()))
+	m = next.(Model)
+	if _, ok := cmd().(tea.BatchMsg); !ok || !m.scanning {
+		t.Error("a tick with no scan in flight should start one alongside the next tick")
+	}
+}
+
+// panicScanner panics on every scan, like a parser hitting an edge case.
+type panicScanner struct{}
+
+func (panicScanner) Scan(context.Context) ([]scanner.Server, error) {
+	panic("index out of range")
+}
+
+func TestPanickingScannerReportsError(t *testing.T) {
+	m := New(panicScanner{}, config.Default(), Options{})
+	msg, ok := doScan(panicScanner{}, time.Now)().(scanResultMsg)
+	if !ok || msg.err == nil {
+		t.Fatalf("doScan() = %#v, want a scanResultMsg carrying the panic", msg)
+	}
+	m = update(t, m, msg)
+	if view := m.View(); !strings.Contains(view, "scan failed: scanner panicked: index out of range") {
+		t.Errorf("view should report the panic:\n%s", view)
+	}
+}
+
+func TestDisabledKillIsIgnored(t *testing.T) {
+	m := newTestModel(t, testServers)
+	m.config.DisabledActions = []string{"kill", "edit_config"}
+	m, cmd := press(t, m, "x")
+	if m.mode != modeNormal || cmd != nil {
+		t.Fatalf("disabled kill: mode = %v, cmd = %v; want normal mode and no command", m.mode, cmd)
+	}
+	if m.status != "kill is disabled in the config" {
+		t.Errorf("status = %q", m.status)
+	}
+
+	m, _ = runTyped(t, m, "kill 3000")
+	if m.mode != modeNormal {
+		t.Errorf(":kill should be disabled too, mode = %v", m.mode)
+	}
+
+	m, _ = press(t, m, "?")
+	help := m.View()
+	if strings.Contains(help, "kill process") || strings.Contains(help, "$EDITOR") {
+		t.Errorf("help should omit disabled actions:\n%s", help)
+	}
+	if !strings.Contains(help, "set label") {
+		t.Errorf("help should keep enabled actions:\n%s", help)
+	}
+	m, cmd = press(t, m, "e")
+	if cmd != nil {
+		t.Error("disabled edit_config should not open the editor")
+	}
+}
+
+func TestUnixSocketRows(t *testing.T) {
+	servers := append(slices.Clone(testServers), scanner.Server{SocketPath: "/var/run/app.sock", PID: 400, Process: "app", State: "LISTEN", Healthy: true})
+	m := newTestModel(t, servers)
+	if last := m.filtered[len(m.filtered)-1]; last.SocketPath == "" {
+		t.Fatalf("sockets should sort after every port, got %+v", m.filtered)
+	}
+	view := m.View()
+	if !strings.Contains(view, "…n/app.sock") {
+		t.Errorf("socket row should show its path:\n%s", view)
+	}
+
+	m, _ = press(t, m, "G")
+	// The kill command is never built for a socket row.
+	for _, k := range []string{"o", "x", "l", "h", "z"} {
+		next, cmd := press(t, m, k)
+		if next.mode != modeNormal || cmd != nil || !strings.Contains(next.status, "Unix socket") {
+			t.Errorf("%q on a socket row: mode = %v, status = %q; want it refused", k, next.mode, next.status)
+		}
+	}
+	if m, _ = press(t, m, "i"); !strings.Contains(m.View(), "socket /var/run/app.sock") {
+		t.Errorf("detail view should name the socket:\n%s", m.View())
+	}
+
+	m.mode = modeNormal
+	m.filterText = "app.sock"
+	m.applyFilter()
+	if len(m.filtered) != 1 || !m.filtered[0].IsSocket() {
+		t.Errorf("filter by socket path = %+v", m.filtered)
+	}
+}
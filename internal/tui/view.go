@@ -0,0 +1,732 @@
+package tui
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/jeramiahgcoffey/portview/internal/config"
+	"github.com/jeramiahgcoffey/portview/internal/scanner"
+)
+
+var (
+	titleStyle     = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205"))
+	headerStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("245"))
+	selectedStyle  = lipgloss.NewStyle().Bold(true)
+	healthyStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	unhealthyStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	labelStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
+	statusStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+	errorStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	blockStyle     = lipgloss.NewStyle().Reverse(true) // cursor_style: block
+	// High-contrast mode drops colour cues for text markers, weight and
+	// reverse video.
+	hcUnhealthyStyle = lipgloss.NewStyle().Bold(true)
+	hcSelectedStyle  = lipgloss.NewStyle().Reverse(true)
+	hcErrorStyle     = lipgloss.NewStyle().Bold(true).Reverse(true)
+	helpOverlayStyle = lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(lipgloss.Color("62")).
+				Padding(1, 2)
+)
+
+// Column widths, in cells, for the server list.
+const (
+	colProto   = 5
+	colPort    = 11
+	colProcess = 14
+	colCommand = 32
+	colHealth  = healthHistorySize
+)
+
+// Bounds and step for resizing the command column with "[" and "]".
+const (
+	minCommandCol  = 12
+	maxCommandCol  = 120
+	commandColStep = 4
+)
+
+// commandCol is the command column's width: colCommand unless resized for
+// this session.
+func (m Model) commandCol() int {
+	return cmp.Or(m.cmdWidth, colCommand)
+}
+
+// hints are the key reminders on the last line, most important first.
+// Narrow terminals drop them from the end, but "?:help" is always shown.
+var hints = []string{"j/k:nav", "o:open", "x:kill", "l:label", "/:filter", "q:quit"}
+
+const (
+	helpHint = "?:help"
+	hintSep  = "  "
+)
+
+// chromeLines is the number of lines View spends outside the server
+// list: title, header summary, column header, the blank line and two status
+// lines.
+const chromeLines = 6
+
+// View renders the model.
+func (m Model) View() string {
+	if m.mode == modeHelp {
+		return m.helpView()
+	}
+	if m.mode == modeDetail {
+		return m.detailView()
+	}
+	if m.mode == modeHidden {
+		return m.hiddenView()
+	}
+	if m.mode == modeExposed {
+		return m.exposedView(m.listView())
+	}
+	if m.confirmOverlay() {
+		return m.confirmView(m.listView())
+	}
+	return m.listView()
+}
+
+// listView renders the title, server list and status bar.
+func (m Model) listView() string {
+	var b strings.Builder
+	title := cmp.Or(m.title, m.config.Title, "portview")
+	if m.snapshot != "" {
+		title += " · snapshot " + m.snapshot
+	}
+	b.WriteString(titleStyle.Render(title))
+	b.WriteString("\n")
+	b.WriteString(statusStyle.Render(m.headerSummary()))
+	b.WriteString("\n")
+	for _, line := range m.banners() {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	for _, line := range m.filterBars() {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	b.WriteString(headerStyle.Render(m.blankGutter() + m.listRow("PROTO", "PORT", "PROCESS", "COMMAND", "HEALTH", "LABEL")))
+	b.WriteString("\n")
+
+	if len(m.filtered) == 0 {
+		switch {
+		case m.lastRefresh.IsZero():
+			b.WriteString("  scanning…\n")
+		case m.filterText != "":
+			b.WriteString("  no servers match the filter\n")
+		default:
+			b.WriteString("  no listening servers found\n")
+		}
+	}
+	lines, cursorLine := m.listLines()
+	start, end := m.visibleRange(len(lines), cursorLine)
+	for _, line := range lines[start:end] {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(m.statusBar())
+	return b.String()
+}
+
+// banners returns warnings shown under the header summary, already styled.
+func (m Model) banners() []string {
+	var lines []string
+	if m.degraded != nil {
+		style := errorStyle
+		if m.highContrast() {
+			style = hcErrorStyle
+		}
+		lines = append(lines, style.Render("! "+m.degraded.Error()))
+	}
+	return lines
+}
+
+// filterBars returns the lines shown above the column header for the text
+// filter and the port range, while they are being edited or are active.
+func (m Model) filterBars() []string {
+	var lines []string
+	if m.mode == modeFilter || m.filterText != "" {
+		line := "Filter: " + m.filterText
+		if m.mode == modeFilter {
+			line += "▏"
+		}
+		if m.filterText != "" {
+			noun := "matches"
+			if len(m.filtered) == 1 {
+				noun = "match"
+			}
+			line += fmt.Sprintf(" (%d %s)", len(m.filtered), noun)
+		}
+		lines = append(lines, line)
+	}
+	switch {
+	case m.mode == modeRange:
+		lines = append(lines, "Show ports in range: "+m.rangeInput+"▏")
+	case m.portFilter != (config.PortRange{}):
+		lines = append(lines, fmt.Sprintf("Range: %d–%d", m.portFilter.Min, m.portFilter.Max))
+	}
+	return lines
+}
+
+// listLines renders the server rows, with a heading above each group in the
+// grouped view modes. It also returns the index of the cursor's line.
+func (m Model) listLines() (lines []string, cursorLine int) {
+	var group string
+	for i, s := range m.filtered {
+		if h := m.viewMode.groupHeading(s); h != "" && (i == 0 || h != group) {
+			group = h
+			lines = append(lines, headerStyle.Render("── "+h))
+		}
+		if i == m.cursor {
+			cursorLine = len(lines)
+		}
+		lines = append(lines, m.renderRow(s, i == m.cursor))
+	}
+	if gone := m.gone(); len(gone) > 0 {
+		lines = append(lines, headerStyle.Render("── not listening"))
+		for _, g := range gone {
+			down := "down for " + formatDown(m.now().Sub(g.since))
+			lines = append(lines, unhealthyStyle.Render(m.blankGutter()+m.listRow("", fmt.Sprint(g.port), "", down, "", g.label)))
+		}
+	}
+	return lines, cursorLine
+}
+
+// visibleRange returns the window of n list lines that fits on screen,
+// scrolled so the cursor line stays visible.
+func (m Model) visibleRange(n, cursor int) (start, end int) {
+	if m.height == 0 {
+		return 0, n
+	}
+	avail := m.height - chromeLines - len(m.banners()) - len(m.filterBars())
+	if avail < 1 {
+		avail = 1
+	}
+	if n <= avail {
+		return 0, n
+	}
+	if cursor >= avail {
+		start = cursor - avail + 1
+	}
+	return start, start + avail
+}
+
+func (m Model) renderRow(s scanner.Server, selected bool) string {
+	label := s.Label
+	hidden := m.config.IsHidden(s.Port)
+	if hidden {
+		label = strings.TrimSpace(label + " (hidden)")
+	}
+	if _, ok := m.kills[portKeyOf(s)]; ok {
+		label = strings.TrimSpace(label + " killing…")
+	}
+	flapping := !s.IsSocket() && m.flapping(portKeyOf(s))
+	if flapping {
+		label = strings.TrimSpace(label + " flapping")
+	}
+	if selected && m.mode == modeLabel {
+		label = m.labelInput + "▏"
+	}
+	gutter := m.gutter(s, selected)
+	if m.highContrast() {
+		return m.renderRowHighContrast(s, gutter, label, selected)
+	}
+	row := m.listRow(s.Proto(), portCell(s, m.config.ServiceNames), processCell(s), s.Command, sparkline(m.health[portKeyOf(s)].values()), "")
+	style := unhealthyStyle
+	if s.Healthy && !(hidden && m.config.DimHidden()) {
+		style = healthyStyle
+	}
+	if flapping {
+		style = flappingStyle
+	}
+	if selected {
+		style = style.Inherit(selectedStyle)
+	}
+	if selected && m.blockCursor() {
+		return style.Inherit(blockStyle).Render(m.fullRow(gutter + row + label))
+	}
+	return gutter + style.Render(row) + labelStyle.Render(label)
+}
+
+// gutter is the cells left of a row: the cursor marker, then "*" if the row
+// is frozen in place.
+func (m Model) gutter(s scanner.Server, selected bool) string {
+	marker := ""
+	if selected {
+		marker = m.cursorMarker()
+	}
+	frozen := " "
+	if _, ok := m.frozen[s.Port]; ok {
+		frozen = "*"
+	}
+	return marker + strings.Repeat(" ", m.gutterWidth()-1-ansi.StringWidth(marker)) + frozen
+}
+
+// cursorMarker is what cursor_style puts in the selected row's gutter: ">"
+// by default, nothing for block, or the custom marker.
+func (m Model) cursorMarker() string {
+	switch m.config.CursorStyle {
+	case "", "arrow":
+		return ">"
+	case "block":
+		return ""
+	}
+	return m.config.CursorStyle
+}
+
+// gutterWidth is how many cells the gutter takes: the widest cursor marker,
+// at least one, and the frozen mark.
+func (m Model) gutterWidth() int {
+	return max(ansi.StringWidth(m.cursorMarker()), 1) + 1
+}
+
+// blankGutter indents the header and other unselectable rows to line up
+// with the rows.
+func (m Model) blankGutter() string {
+	return strings.Repeat(" ", m.gutterWidth())
+}
+
+func (m Model) blockCursor() bool {
+	return m.config.CursorStyle == "block"
+}
+
+// fullRow pads line with spaces to the window width, so a block cursor
+// spans the whole row.
+func (m Model) fullRow(line string) string {
+	if w := ansi.StringWidth(line); w < m.width {
+		line += strings.Repeat(" ", m.width-w)
+	}
+	return line
+}
+
+// renderRowHighContrast marks health in text, "[OK]" or "[DOWN]" ahead of
+// the most recent sparkline results, and shows the selection in reverse
+// video rather than relying on colour.
+func (m Model) renderRowHighContrast(s scanner.Server, gutter, label string, selected bool) string {
+	marker, style := "[OK]", lipgloss.NewStyle()
+	if !s.Healthy {
+		marker, style = "[DOWN]", hcUnhealthyStyle
+	}
+	recent := m.health[portKeyOf(s)].values()
+	if n := colHealth - len("[DOWN] "); len(recent) > n {
+		recent = recent[len(recent)-n:]
+	}
+	health := fmt.Sprintf("%-6s %s", marker, sparkline(recent))
+	row := m.listRow(s.Proto(), portCell(s, m.config.ServiceNames), processCell(s), s.Command, health, label)
+	if selected {
+		style = style.Inherit(hcSelectedStyle)
+	}
+	if selected && m.blockCursor() {
+		return style.Render(m.fullRow(gutter + row))
+	}
+	return gutter + style.Render(row)
+}
+
+// processCell is the display name, marked "~" when its PID was reused from
+// an earlier scan and may be out of date.
+func processCell(s scanner.Server) string {
+	if s.PIDStale {
+		return truncate(s.Name(), colProcess-1) + "~"
+	}
+	return s.Name()
+}
+
+// portCell renders the port, noting how many processes share it and, with
+// services set, its well-known service name: "80 (http)", "80 (x2)" or
+// "80 (http x2)".
+func portCell(s scanner.Server, services bool) string {
+	if s.IsSocket() {
+		return truncateLeft(s.SocketPath, colPort)
+	}
+	var notes []string
+	if name := serviceName(s.Port); services && name != "" {
+		notes = append(notes, name)
+	}
+	if n := len(s.AllPIDs()); n > 1 {
+		notes = append(notes, fmt.Sprintf("x%d", n))
+	}
+	if len(notes) == 0 {
+		return fmt.Sprint(s.Port)
+	}
+	return fmt.Sprintf("%d (%s)", s.Port, strings.Join(notes, " "))
+}
+
+// formatRow lays out one list row, the command column commandW cells wide.
+func formatRow(commandW int, port, process, command, health, label string) string {
+	return fmt.Sprintf("%-*s %-*s %-*s %-*s %s",
+		colPort, truncate(port, colPort),
+		colProcess, truncate(process, colProcess),
+		commandW, truncate(command, commandW),
+		colHealth, health,
+		label)
+}
+
+// listRow is formatRow at the list's current layout, led by a PROTO column
+// while UDP sockets are listed. With show_command set, the command fills the
+// process and command columns as one.
+func (m Model) listRow(proto, port, process, command, health, label string) string {
+	lead := ""
+	if m.showProto() {
+		lead = fmt.Sprintf("%-*s ", colProto, truncate(proto, colProto))
+	}
+	if !m.config.ShowCommand {
+		return lead + formatRow(m.commandCol(), port, process, command, health, label)
+	}
+	nameW := colProcess + 1 + m.commandCol()
+	return lead + fmt.Sprintf("%-*s %-*s %-*s %s",
+		colPort, truncate(port, colPort),
+		nameW, truncate(command, nameW),
+		colHealth, health,
+		label)
+}
+
+// showProto reports whether the list has UDP sockets, which alone need the
+// PROTO column to tell them from TCP listeners on the same port.
+func (m Model) showProto() bool {
+	return slices.ContainsFunc(m.servers, func(s scanner.Server) bool {
+		return s.Protocol == scanner.ProtoUDP
+	})
+}
+
+func (m Model) statusBar() string {
+	var line string
+	style := statusStyle
+	mode := m.mode
+	if m.confirmOverlay() {
+		mode = modeNormal // the overlay asks instead
+	}
+	switch {
+	case mode == modeConfirmKill:
+		s, _ := m.selected()
+		line = fmt.Sprintf("Kill %s (%s on :%d)? (y/n)", formatPIDs(m.confirmPIDs), s.Name(), s.Port)
+		style = lipgloss.NewStyle()
+	case mode == modeConfirmRootKill:
+		line = fmt.Sprintf("Running as root: %s may belong to another user. Kill anyway? (y/n)", formatPIDs(m.confirmPIDs))
+		style = lipgloss.NewStyle()
+	case mode == modeConfirmSudo:
+		line = fmt.Sprintf("Permission denied killing %s. Retry with sudo kill? (y/n)", formatPIDs(m.sudoPIDs))
+		style = lipgloss.NewStyle()
+	case mode == modeConfirmQuit:
+		line = "A kill is in progress, quit anyway? (y/n)"
+		style = lipgloss.NewStyle()
+	case mode == modeConfirmReset:
+		line = "Reset the config to defaults? Labels and hidden ports are lost. (y/n)"
+		style = lipgloss.NewStyle()
+	case mode == modeCommand:
+		line = ":" + m.cmdInput + "▏"
+		style = lipgloss.NewStyle()
+	case mode == modeConfirmRestart:
+		s := m.restarting
+		line = fmt.Sprintf("Restart :%d? Stops %s and runs: %s (y/n)", s.Port, formatPIDs(s.AllPIDs()), s.Command)
+		style = lipgloss.NewStyle()
+	case m.err != nil:
+		line = "scan failed: " + m.err.Error()
+		style = errorStyle
+		if m.highContrast() {
+			style = hcErrorStyle
+		}
+	default:
+		line = m.summary()
+		if m.config.ShowExcluded && m.outside > 0 {
+			line += fmt.Sprintf(" · +%d outside range", m.outside)
+		}
+		if m.asRoot {
+			line = "[root] " + line
+		}
+		if note := m.flapNote(); note != "" {
+			line += " · " + note
+		}
+		if m.status != "" {
+			line += " · " + m.status
+		}
+	}
+	if m.width > 0 {
+		line = truncate(line, m.width)
+	}
+	return style.Render(line) + "\n" + statusStyle.Render(hintLine(m.width))
+}
+
+// hintLine joins as many hints as fit in width, always ending with the help
+// hint. A width of 0 means unknown and shows them all.
+func hintLine(width int) string {
+	shown := hints
+	for len(shown) > 0 && width > 0 && lipgloss.Width(joinHints(shown)) > width {
+		shown = shown[:len(shown)-1]
+	}
+	return joinHints(shown)
+}
+
+func joinHints(shown []string) string {
+	return strings.Join(append(slices.Clone(shown), helpHint), hintSep)
+}
+
+// summary is the "N servers · refreshed Xs ago" part of the status bar,
+// with the refresh time written as time_format asks.
+func (m Model) summary() string {
+	if m.lastRefresh.IsZero() {
+		return "scanning…"
+	}
+	noun := "servers"
+	if len(m.filtered) == 1 {
+		noun = "server"
+	}
+	return fmt.Sprintf("%d %s · refreshed %s", len(m.filtered), noun, m.refreshedWhen())
+}
+
+// refreshedWhen is "3s ago", "at 12:03:04" or "12:03:04, 3s ago".
+func (m Model) refreshedWhen() string {
+	ago := m.now().Sub(m.lastRefresh).Truncate(time.Second)
+	clock := m.lastRefresh.Format(time.TimeOnly)
+	switch m.config.TimeFormat {
+	case "clock":
+		return "at " + clock
+	case "both":
+		return fmt.Sprintf("%s, %s ago", clock, ago)
+	}
+	return fmt.Sprintf("%s ago", ago)
+}
+
+// slowScan is how long a rescan runs before the header says it is in
+// flight; quicker ones would only make the line flicker.
+const slowScan = time.Second
+
+// headerSummary is the posture line under the title, e.g.
+// "42 listening • 3 unhealthy • 5 exposed • scanned at 12:03:04 (85ms)". It
+// counts the servers currently shown.
+func (m Model) headerSummary() string {
+	if m.lastRefresh.IsZero() {
+		return m.scanProgress()
+	}
+	var unhealthy, exposed int
+	for _, s := range m.filtered {
+		if !s.Healthy {
+			unhealthy++
+		}
+		if s.Exposed() {
+			exposed++
+		}
+	}
+	stamp := fmt.Sprintf("scanned at %s (%s)", m.lastRefresh.Format(time.TimeOnly), m.lastScan.Round(time.Millisecond))
+	if m.scanning && m.now().Sub(m.scanStarted) >= slowScan {
+		stamp = m.scanProgress()
+	}
+	if m.sortLocked {
+		stamp += " • order locked"
+	}
+	if m.favorites {
+		stamp += " • favorites only"
+	}
+	return fmt.Sprintf("%d listening • %d unhealthy • %d exposed • %s",
+		len(m.filtered), unhealthy, exposed, stamp)
+}
+
+// scanProgress is "scanning… (started 2s ago)" for the scan in flight.
+func (m Model) scanProgress() string {
+	ago := m.now().Sub(m.scanStarted).Truncate(time.Second)
+	return fmt.Sprintf("scanning… (started %s ago)", ago)
+}
+
+func (m Model) helpView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("portview keys"))
+	b.WriteString("\n\n")
+	for _, kb := range keys.helpBindings() {
+		if m.bindingDisabled(kb) {
+			continue
+		}
+		h := kb.Help()
+		fmt.Fprintf(&b, "%-10s %s\n", h.Key, h.Desc)
+	}
+	b.WriteString("\nfilter/label: enter to apply, esc to cancel\n\n")
+	if m.configPath != "" {
+		fmt.Fprintf(&b, "config: %s\n", m.configPath)
+	}
+	for _, kb := range []key.Binding{helpKeys.CopyConfigPath, helpKeys.EditConfig, helpKeys.HiddenList} {
+		if m.bindingDisabled(kb) {
+			continue
+		}
+		h := kb.Help()
+		fmt.Fprintf(&b, "%-10s %s\n", h.Key, h.Desc)
+	}
+	return m.overlay(strings.TrimRight(b.String(), "\n"))
+}
+
+func (m Model) detailView() string {
+	s, _ := m.selected()
+	health := "not responding"
+	if s.Healthy {
+		health = "healthy"
+	}
+	pids := "unknown"
+	if all := s.AllPIDs(); len(all) > 0 {
+		pids = joinInts(all)
+	}
+	if s.PIDStale {
+		pids += " ~ (from an earlier scan; ss failed)"
+	}
+	bind := s.Addr
+	if len(s.Addrs) > 0 {
+		bind = strings.Join(s.Addrs, ", ")
+	}
+	backlog := ""
+	if s.MaxBacklog > 0 {
+		backlog = fmt.Sprintf("%d/%d waiting", s.Backlog, s.MaxBacklog)
+	}
+	w, exe := m.detailValueWidth(), s.ExePath
+	if w > 0 {
+		exe = truncateLeft(exe, w)
+	}
+	var b strings.Builder
+	title := fmt.Sprintf("port %d", s.Port)
+	if s.IsSocket() {
+		title = "socket " + s.SocketPath
+	}
+	b.WriteString(titleStyle.Render(title))
+	b.WriteString("\n\n")
+	rows := [][2]string{{"PID", pids}, {"Process", s.Process}}
+	for i, line := range commandLines(s.Command, w) {
+		name := ""
+		if i == 0 {
+			name = "Command"
+		}
+		rows = append(rows, [2]string{name, line})
+	}
+	rows = append(rows, [][2]string{
+		{"Exe", exe},
+		{"Label", s.Label},
+		{"Bind", bind},
+		{"State", s.State},
+		{"Backlog", backlog},
+		{"Health", health},
+	}...)
+	for _, row := range rows {
+		fmt.Fprintf(&b, "%-8s %s\n", row[0], row[1])
+	}
+	b.WriteString("\ni/esc: close")
+	return m.overlay(b.String())
+}
+
+// detailValueWidth is how many cells a detail row's value may use before the
+// overlay would overflow the window, or 0 if the width is unknown.
+func (m Model) detailValueWidth() int {
+	if m.width == 0 {
+		return 0
+	}
+	const labelCol = 9 // "%-8s "
+	return max(m.width-helpOverlayStyle.GetHorizontalFrameSize()-labelCol, 10)
+}
+
+// overlay boxes content and centers it in the window.
+func (m Model) overlay(content string) string {
+	box := helpOverlayStyle.Render(content)
+	if m.width == 0 || m.height == 0 {
+		return box
+	}
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, box)
+}
+
+// truncateLeft shortens s to at most maxLen runes by dropping its start,
+// marking the cut with "…". It suits paths, whose last element is the most
+// telling: "/usr/local/bin/node" becomes "…/bin/node".
+func truncateLeft(s string, maxLen int) string {
+	r := []rune(s)
+	if len(r) <= maxLen {
+		return s
+	}
+	if maxLen <= 1 {
+		return string(r[len(r)-maxLen:])
+	}
+	return "…" + string(r[len(r)-maxLen+1:])
+}
+
+// truncate shortens s to at most maxLen runes, marking the cut with "…".
+func truncate(s string, maxLen int) string {
+	r := []rune(s)
+	if len(r) <= maxLen {
+		return s
+	}
+	if maxLen <= 1 {
+		return string(r[:maxLen])
+	}
+	return string(r[:maxLen-1]) + "…"
+}
+
+// commandLines lays a command line out for the detail overlay: the program
+// and its leading arguments first, then each flag with its values on its own
+// line, indented, all wrapped to width. A width of 0 means no wrapping.
+func commandLines(command string, width int) []string {
+	var lines []string
+	for i, arg := range argSplit(command) {
+		indent := ""
+		if i > 0 {
+			indent = "  "
+		}
+		// Wrapped parts hang two cells under the start of their argument.
+		wrapAt := 0
+		if width > 0 {
+			wrapAt = max(width-len(indent)-2, 1)
+		}
+		for j, part := range wrap(arg, wrapAt) {
+			if j > 0 {
+				part = "  " + part
+			}
+			lines = append(lines, indent+part)
+		}
+	}
+	if len(lines) == 0 {
+		return []string{""}
+	}
+	return lines
+}
+
+// argSplit groups the words of a command line so that each flag starts a new
+// group and keeps the values after it: "vite --port 5173 --host" becomes
+// "vite", "--port 5173" and "--host".
+func argSplit(command string) []string {
+	var groups []string
+	for _, word := range strings.Fields(command) {
+		if len(groups) == 0 || strings.HasPrefix(word, "-") && word != "-" {
+			groups = append(groups, word)
+			continue
+		}
+		groups[len(groups)-1] += " " + word
+	}
+	return groups
+}
+
+// wrap breaks s into lines of at most width runes, at spaces where it can and
+// mid-word where a word alone is too long. A width of 0 returns s whole.
+func wrap(s string, width int) []string {
+	if width <= 0 {
+		return []string{s}
+	}
+	var lines []string
+	var line []rune
+	for _, word := range strings.Fields(s) {
+		w := []rune(word)
+		if len(line) > 0 && len(line)+1+len(w) > width {
+			lines = append(lines, string(line))
+			line = nil
+		}
+		if len(line) > 0 {
+			line = append(line, ' ')
+		}
+		line = append(line, w...)
+		for len(line) > width {
+			lines = append(lines, string(line[:width]))
+			line = line[width:]
+		}
+	}
+	if len(line) > 0 || len(lines) == 0 {
+		lines = append(lines, string(line))
+	}
+	return lines
+}